@@ -1,11 +1,18 @@
 package mcp
 
 import (
+	"context"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/openshift/cluster-health-analyzer/pkg/common"
 	"github.com/openshift/cluster-health-analyzer/pkg/mcp"
 	"github.com/spf13/cobra"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 var (
@@ -17,6 +24,16 @@ var (
 var (
 	promURL         string
 	alertManagerURL string
+	transport       string
+	listenAddress   string
+	certFile        string
+	certKey         string
+	kubeconfig      string
+	tokenFile       string
+
+	disableAuthForTesting bool
+	authCacheTTL          time.Duration
+	authAttrs             authorizationv1.ResourceAttributes
 )
 
 var (
@@ -41,17 +58,46 @@ var (
 				}
 			}
 
+			// with the stdio transport there's no caller providing the token
+			var token string
+			if transport == mcp.TransportStdio {
+				var err error
+				token, err = mcp.LoadToken(tokenFile, kubeconfig)
+				if err != nil {
+					slog.Error("Failed to load the token for the stdio transport", "error", err)
+					return
+				}
+			}
+
+			// the callers of the http transport are authenticated with their token
+			var authenticator mcp.Authenticator
+			if transport != mcp.TransportStdio && !disableAuthForTesting {
+				var err error
+				authenticator, err = newAuthenticator()
+				if err != nil {
+					slog.Error("Failed to create the authenticator", "error", err)
+					return
+				}
+			}
+
 			serverCfg := mcp.MCPHealthServerCfg{
 				Name:            MCPServerName,
 				Version:         MCPServerVersion,
-				Url:             ":8085",
+				Url:             listenAddress,
+				Transport:       transport,
+				CertFile:        certFile,
+				CertKey:         certKey,
+				Token:           token,
+				Authenticator:   authenticator,
 				PrometheusURL:   promURL,
 				AlertManagerURL: alertManagerURL,
 			}
 
 			server := mcp.NewMCPHealthServer(serverCfg)
 
-			err := server.Start()
+			ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer cancel()
+			err := server.Start(ctx)
 			if err != nil {
 				slog.Error("Failed to start the MCP server", "error", err)
 				return
@@ -60,7 +106,45 @@ var (
 	}
 )
 
+// newAuthenticator creates the authenticator validating the callers with
+// the TokenReview and SubjectAccessReview, with the decisions cached.
+func newAuthenticator() (mcp.Authenticator, error) {
+	restConfig, err := common.GetKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	kubeClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewCachingAuthenticator(mcp.NewKubeAuthenticator(kubeClient, authAttrs), authCacheTTL), nil
+}
+
 func init() {
 	MCPCmd.Flags().StringVarP(&promURL, "prom-url", "u", "", "URL of the Prometheus server")
 	MCPCmd.Flags().StringVar(&alertManagerURL, "alertmanager-url", "", "URL of the AlertManager server")
+	MCPCmd.Flags().StringVar(&transport, "transport", mcp.TransportHTTP, "Transport of the MCP server: http or stdio")
+	MCPCmd.Flags().StringVar(&listenAddress, "listen-address", ":8085", "Listen address of the http transport")
+	MCPCmd.Flags().StringVar(&certFile, "tls-cert-file", "", "The path to the server certificate of the http transport")
+	MCPCmd.Flags().StringVar(&certKey, "tls-private-key-file", "", "The path to the server key of the http transport")
+	MCPCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "",
+		"The path to the kubeconfig (defaults to in-cluster config for the http transport, KUBECONFIG or ~/.kube/config with the token used by the stdio transport)")
+	MCPCmd.Flags().StringVar(&tokenFile, "token-file", "",
+		"The path to the file with the token used by the stdio transport (takes precedence over the kubeconfig)")
+	MCPCmd.Flags().BoolVar(&disableAuthForTesting, "disable-auth-for-testing", false,
+		"Flag for testing purposes to disable the authentication of the http transport callers")
+	MCPCmd.Flags().DurationVar(&authCacheTTL, "auth-cache-ttl", 30*time.Second,
+		"Time the authentication and authorization decisions are cached for")
+	MCPCmd.Flags().StringVar(&authAttrs.Verb, "auth-verb", "get",
+		"Verb the callers must be allowed to perform on the auth resource")
+	MCPCmd.Flags().StringVar(&authAttrs.Group, "auth-api-group", "monitoring.coreos.com",
+		"API group of the resource the callers must have access to")
+	MCPCmd.Flags().StringVar(&authAttrs.Resource, "auth-resource", "prometheuses",
+		"Resource the callers must have access to")
+	MCPCmd.Flags().StringVar(&authAttrs.Subresource, "auth-subresource", "api",
+		"Subresource the callers must have access to")
+	MCPCmd.Flags().StringVar(&authAttrs.Namespace, "auth-namespace", "openshift-monitoring",
+		"Namespace of the resource the callers must have access to")
+	MCPCmd.Flags().StringVar(&authAttrs.Name, "auth-name", "k8s",
+		"Name of the resource the callers must have access to")
 }
@@ -31,6 +31,10 @@ func (s APIServer) Handle(pattern string, handler http.Handler) {
 	s.Handler.NonGoRestfulMux.Handle(pattern, handler)
 }
 
+func (s APIServer) HandlePrefix(prefix string, handler http.Handler) {
+	s.Handler.NonGoRestfulMux.HandlePrefix(prefix, handler)
+}
+
 func (s APIServer) Start(ctx context.Context) error {
 	return s.PrepareRun().RunWithContext(ctx)
 }
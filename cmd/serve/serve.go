@@ -60,8 +60,13 @@ func newOptions() common.Options {
 	secureServingOptions.BindPort = 8443
 
 	return common.Options{
-		RefreshInterval: refreshInterval,
-		PromURL:         promURL,
-		AlertManagerURL: alertManagerURL,
+		RefreshInterval:   refreshInterval,
+		PromURL:           promURL,
+		AlertManagerURL:   alertManagerURL,
+		StateNamespace:    "openshift-cluster-health-analyzer",
+		StateName:         "cluster-health-analyzer-state",
+		StateSaveInterval: 5 * time.Minute,
+		EventsMinCount:    2,
+		EventsInterval:    2 * time.Minute,
 	}
 }
@@ -17,6 +17,7 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/spf13/cobra"
 
+	"github.com/openshift/cluster-health-analyzer/pkg/common"
 	"github.com/openshift/cluster-health-analyzer/pkg/processor"
 	"github.com/openshift/cluster-health-analyzer/pkg/utils"
 )
@@ -29,18 +30,29 @@ func must(err error) {
 
 var outputFile = "cluster-health-analyzer-openmetrics.txt"
 var scenarioFile string
+var mappingsFile string
+var groupingOptions common.GroupingOptions
 
 var SimulateCmd = &cobra.Command{
 	Use:   "simulate",
 	Short: "Generate simulated data in openmetrics format",
 	Run: func(cmd *cobra.Command, args []string) {
-		simulate(outputFile, scenarioFile)
+		if mappingsFile != "" {
+			mappings, err := processor.LoadMappings(mappingsFile)
+			must(err)
+			processor.SetMappings(mappings)
+		}
+		groupingConfig, err := processor.BuildGroupingConfig(groupingOptions)
+		must(err)
+		simulate(outputFile, scenarioFile, groupingConfig)
 	},
 }
 
 func init() {
 	SimulateCmd.Flags().StringVarP(&outputFile, "output", "o", outputFile, "output file")
 	SimulateCmd.Flags().StringVarP(&scenarioFile, "scenario", "s", "", "CSV file with the scenario to simulate")
+	SimulateCmd.Flags().StringVar(&mappingsFile, "component-mappings", "", "YAML file with the component mappings (defaults to the built-in mappings)")
+	groupingOptions.AddFlags(SimulateCmd.Flags())
 }
 
 var defaultRelativeIntervals = []utils.RelativeInterval{
@@ -404,7 +416,7 @@ func fmtInterval(
 	return nil
 }
 
-func simulate(outputFile, scenarioFile string) {
+func simulate(outputFile, scenarioFile string, groupingConfig *processor.GroupingConfig) {
 	// Build sample intervals.
 	intervals, err := buildAlertIntervals(scenarioFile)
 	must(err)
@@ -468,7 +480,7 @@ func simulate(outputFile, scenarioFile string) {
 		must(err)
 	}
 
-	gc := &processor.GroupsCollection{}
+	gc := &processor.GroupsCollection{Config: groupingConfig}
 	var groupedIntervalsSet []processor.GroupedInterval
 
 	for _, change := range changes {
@@ -0,0 +1,76 @@
+package common
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"os"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// FileWatcher periodically checks a file for changes and notifies about
+// the new content.
+//
+// Polling is used instead of filesystem notifications, as files mounted from
+// a ConfigMap are updated by swapping symlinks, which is not reliably
+// reported by the notifications.
+type FileWatcher struct {
+	path     string
+	interval time.Duration
+	onChange func(data []byte) error
+	lastHash uint64
+}
+
+// NewFileWatcher creates a new FileWatcher for the file on the given path.
+//
+// The onChange function is called with the new content of the file every time
+// the content changes. The current content of the file is considered as
+// already processed.
+func NewFileWatcher(path string, interval time.Duration, onChange func(data []byte) error) *FileWatcher {
+	w := &FileWatcher{
+		path:     path,
+		interval: interval,
+		onChange: onChange,
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		w.lastHash = hashData(data)
+	}
+	return w
+}
+
+// Start starts watching the file in a goroutine and returns immediately.
+func (w *FileWatcher) Start(ctx context.Context) {
+	go wait.Until(w.check, w.interval, ctx.Done())
+}
+
+// check reads the file and calls the onChange function when the content
+// changed since the last successful check.
+func (w *FileWatcher) check() {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		slog.Error("Failed to read the watched file", "path", w.path, "err", err)
+		return
+	}
+
+	hash := hashData(data)
+	if hash == w.lastHash {
+		return
+	}
+	// Remember the hash even if the change is rejected, so that we don't
+	// report the same error on every check.
+	w.lastHash = hash
+
+	slog.Info("Detected change of the watched file", "path", w.path)
+	if err := w.onChange(data); err != nil {
+		slog.Error("Failed to apply the change of the watched file, keeping the previous version",
+			"path", w.path, "err", err)
+	}
+}
+
+func hashData(data []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(data)
+	return h.Sum64()
+}
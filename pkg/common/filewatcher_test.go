@@ -0,0 +1,48 @@
+package common
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileWatcher_check(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("v1"), 0o600))
+
+	var changes []string
+	var changeErr error
+	w := NewFileWatcher(path, time.Second, func(data []byte) error {
+		changes = append(changes, string(data))
+		return changeErr
+	})
+
+	// The initial content is considered as already processed.
+	w.check()
+	assert.Empty(t, changes)
+
+	require.NoError(t, os.WriteFile(path, []byte("v2"), 0o600))
+	w.check()
+	assert.Equal(t, []string{"v2"}, changes)
+
+	// No change since the last check.
+	w.check()
+	assert.Equal(t, []string{"v2"}, changes)
+
+	// Rejected change is not retried until the content changes again.
+	changeErr = errors.New("invalid config")
+	require.NoError(t, os.WriteFile(path, []byte("invalid"), 0o600))
+	w.check()
+	w.check()
+	assert.Equal(t, []string{"v2", "invalid"}, changes)
+
+	// Missing file doesn't trigger the change.
+	require.NoError(t, os.Remove(path))
+	w.check()
+	assert.Equal(t, []string{"v2", "invalid"}, changes)
+}
@@ -1,6 +1,10 @@
 package common
 
-import "github.com/spf13/pflag"
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+)
 
 type Options struct {
 	// Refresh interval in seconds.
@@ -26,6 +30,32 @@ type Options struct {
 
 	// path to the components yaml file
 	ComponentsPath string
+
+	// path to the component mappings yaml file
+	MappingsPath string
+
+	// Backend for persisting the incidents state: "", "file", "configmap" or "secret".
+	StateStore string
+	// path to the state file for the "file" state store
+	StateFile string
+	// namespace and name of the ConfigMap/Secret for the kube state stores
+	StateNamespace string
+	StateName      string
+	// interval between saving the state
+	StateSaveInterval time.Duration
+
+	// Enable using the Kubernetes Warning Events as a signal for incidents
+	EnableEvents bool
+	// minimal number of occurrences of the Warning Event to be used
+	EventsMinCount int
+	// interval between loading the Warning Events from the Kubernetes API
+	EventsInterval time.Duration
+
+	// label of the alerts identifying their cluster, when processing
+	// the alerts of multiple clusters (e.g. from ACM/Thanos)
+	ClusterLabel string
+
+	GroupingOptions
 }
 
 // flags returns supported cli flags for the options.
@@ -51,5 +81,59 @@ func (o *Options) Flags() *pflag.FlagSet {
 		"Flag to disable incident detection and related metrics")
 	fs.StringVar(&o.ComponentsPath, "components", o.ComponentsPath,
 		"The path to the components yaml file - for testing purposes")
+	fs.StringVar(&o.MappingsPath, "component-mappings", o.MappingsPath,
+		"The path to the component mappings yaml file (defaults to the built-in mappings)")
+	fs.StringVar(&o.StateStore, "state-store", o.StateStore,
+		"Backend for persisting the incidents state across restarts: file, configmap or secret (disabled when empty)")
+	fs.StringVar(&o.StateFile, "state-file", o.StateFile,
+		"The path to the state file when using the file state store")
+	fs.StringVar(&o.StateNamespace, "state-namespace", o.StateNamespace,
+		"The namespace of the ConfigMap/Secret when using the configmap or secret state store")
+	fs.StringVar(&o.StateName, "state-name", o.StateName,
+		"The name of the ConfigMap/Secret when using the configmap or secret state store")
+	fs.DurationVar(&o.StateSaveInterval, "state-save-interval", o.StateSaveInterval,
+		"Interval between saving the incidents state")
+	fs.BoolVar(&o.EnableEvents, "enable-events", o.EnableEvents,
+		"Flag to enable using the Kubernetes Warning Events as a signal for incidents")
+	fs.IntVar(&o.EventsMinCount, "events-min-count", o.EventsMinCount,
+		"Minimal number of occurrences of the Warning Event to be used as a signal")
+	fs.DurationVar(&o.EventsInterval, "events-interval", o.EventsInterval,
+		"Interval between loading the Warning Events from the Kubernetes API")
+	fs.StringVar(&o.ClusterLabel, "cluster-label", o.ClusterLabel,
+		"Label of the alerts identifying their cluster (e.g. clusterID), to group the alerts of multiple clusters separately (single cluster when empty)")
+	o.GroupingOptions.AddFlags(fs)
 	return fs
 }
+
+// GroupingOptions holds the options for grouping the alerts into incidents.
+// They are shared between the commands running the incidents grouping.
+type GroupingOptions struct {
+	// path to the incidents grouping config yaml file
+	GroupingConfigPath string
+
+	// Overrides of the grouping config parameters, zero values mean unset.
+	FuzzyMatchWindow      time.Duration
+	TimeMatchWindow       time.Duration
+	DirectMatchWindow     time.Duration
+	DependencyMatchWindow time.Duration
+	SubsetLabels          []string
+	FuzzyLabels           []string
+}
+
+// AddFlags registers the cli flags for the grouping options.
+func (o *GroupingOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.GroupingConfigPath, "grouping-config", o.GroupingConfigPath,
+		"The path to the incidents grouping config yaml file (defaults to the built-in parameters)")
+	fs.DurationVar(&o.FuzzyMatchWindow, "fuzzy-match-window", o.FuzzyMatchWindow,
+		"Max time since the last change of an incident for fuzzy matching of alerts (overrides the grouping config)")
+	fs.DurationVar(&o.TimeMatchWindow, "time-match-window", o.TimeMatchWindow,
+		"Max time since the last change of an incident for time-based matching of alerts (overrides the grouping config)")
+	fs.DurationVar(&o.DirectMatchWindow, "direct-match-window", o.DirectMatchWindow,
+		"Max time since the end of an incident for direct matching of alerts (overrides the grouping config)")
+	fs.DurationVar(&o.DependencyMatchWindow, "dependency-match-window", o.DependencyMatchWindow,
+		"Max time since the last change of an incident for matching alerts of dependent components (overrides the grouping config)")
+	fs.StringSliceVar(&o.SubsetLabels, "subset-labels", o.SubsetLabels,
+		"Labels of alerts used for close (distance 1) matching (overrides the grouping config)")
+	fs.StringSliceVar(&o.FuzzyLabels, "fuzzy-labels", o.FuzzyLabels,
+		"Labels of alerts used for fuzzy (distance 2) matching (overrides the grouping config)")
+}
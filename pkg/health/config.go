@@ -0,0 +1,69 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/stretchr/testify/assert/yaml"
+)
+
+// ParseConfig unmarshals and validates the components config.
+func ParseConfig(data []byte) (*ComponentsConfig, error) {
+	conf := &ComponentsConfig{}
+	if err := yaml.Unmarshal(data, conf); err != nil {
+		return nil, err
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// Validate checks the components config for errors that would prevent
+// correct evaluation of the components tree.
+func (c *ComponentsConfig) Validate() error {
+	if len(c.Components) == 0 {
+		return errors.New("no components defined")
+	}
+	return validateComponents(c.Components, "")
+}
+
+// validateComponents recursively validates the components and their children.
+// The parentPath is used to provide the full component name in the errors.
+func validateComponents(components []Component, parentPath string) error {
+	names := make(map[string]struct{}, len(components))
+	for _, c := range components {
+		if c.Name == "" {
+			return fmt.Errorf("component with missing name under %q", parentPath)
+		}
+		path := c.Name
+		if parentPath != "" {
+			path = fmt.Sprintf("%s.%s", parentPath, c.Name)
+		}
+		// The dot is used as a separator of the full component name.
+		if strings.Contains(c.Name, ".") {
+			return fmt.Errorf("component %q: name must not contain '.'", path)
+		}
+		if _, ok := names[c.Name]; ok {
+			return fmt.Errorf("component %q: defined multiple times", path)
+		}
+		names[c.Name] = struct{}{}
+
+		for _, o := range c.Objects {
+			if o.Resource == "" {
+				return fmt.Errorf("component %q: object with missing resource", path)
+			}
+		}
+		for _, s := range c.AlertsSelectors.Selectors {
+			if len(s.MatchLabels) == 0 {
+				return fmt.Errorf("component %q: alert selector with empty matchLabels", path)
+			}
+		}
+
+		if err := validateComponents(c.ChildComponents, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
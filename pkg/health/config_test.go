@@ -0,0 +1,127 @@
+package health
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "valid config",
+			data: `
+components:
+- name: control-plane
+  children:
+  - name: nodes
+    objects:
+    - resource: nodes
+  - name: etcd
+    alerts:
+      selectors:
+      - matchLabels:
+          namespace: [openshift-etcd]
+`,
+		},
+		{
+			name:    "no components",
+			data:    `components: []`,
+			wantErr: "no components defined",
+		},
+		{
+			name: "missing name",
+			data: `
+components:
+- name: control-plane
+  children:
+  - objects:
+    - resource: nodes
+`,
+			wantErr: `component with missing name under "control-plane"`,
+		},
+		{
+			name: "name with dot",
+			data: `
+components:
+- name: control.plane
+`,
+			wantErr: `component "control.plane": name must not contain '.'`,
+		},
+		{
+			name: "duplicate sibling",
+			data: `
+components:
+- name: control-plane
+  children:
+  - name: nodes
+  - name: nodes
+`,
+			wantErr: `component "control-plane.nodes": defined multiple times`,
+		},
+		{
+			name: "object without resource",
+			data: `
+components:
+- name: control-plane
+  objects:
+  - name: foo
+`,
+			wantErr: `component "control-plane": object with missing resource`,
+		},
+		{
+			name: "empty alert selector",
+			data: `
+components:
+- name: control-plane
+  alerts:
+    selectors:
+    - matchLabels: {}
+`,
+			wantErr: `component "control-plane": alert selector with empty matchLabels`,
+		},
+		{
+			name:    "invalid yaml",
+			data:    `components: [`,
+			wantErr: "yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := ParseConfig([]byte(tt.data))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, conf.Components)
+		})
+	}
+}
+
+func TestParseConfig_TestData(t *testing.T) {
+	data, err := os.ReadFile("test-data/simple-components.yaml")
+	require.NoError(t, err)
+
+	_, err = ParseConfig(data)
+	assert.NoError(t, err)
+}
+
+func TestUpdateConfig(t *testing.T) {
+	p := createTestHealthProcessor(nil, nil, nil)
+	assert.Nil(t, p.pendingConfig.Load())
+
+	conf := &ComponentsConfig{Components: []Component{{Name: "control-plane"}}}
+	p.UpdateConfig(conf)
+
+	// The config is consumed by the next evaluation.
+	assert.Equal(t, conf, p.pendingConfig.Swap(nil))
+	assert.Nil(t, p.pendingConfig.Load())
+}
@@ -0,0 +1,57 @@
+package health
+
+import (
+	"slices"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/processor"
+)
+
+// propagateDependencies degrades the components depending on the unhealthy
+// components, following the dependency graph of the component mappings.
+// The components are identified in the graph by their name, regardless
+// of their position in the tree (e.g. "control-plane.operators.etcd" is
+// the "etcd" component).
+//
+// The degraded components get at least the warning status, their parents
+// are updated accordingly.
+func propagateDependencies(components []*ComponentHealth, deps *processor.DependencyGraph) {
+	byName := make(map[string][]*ComponentHealth)
+	var unhealthy []string
+	var walk func(c *ComponentHealth)
+	walk = func(c *ComponentHealth) {
+		byName[c.name] = append(byName[c.name], c)
+		if c.healthStatus > OK && !slices.Contains(unhealthy, c.name) {
+			unhealthy = append(unhealthy, c.name)
+		}
+		for _, ch := range c.childComponents {
+			walk(ch)
+		}
+	}
+	for _, c := range components {
+		walk(c)
+	}
+
+	for _, upstream := range unhealthy {
+		for _, downstream := range deps.Downstreams(upstream) {
+			for _, c := range byName[downstream] {
+				if !slices.Contains(c.degradedBy, upstream) {
+					c.degradedBy = append(c.degradedBy, upstream)
+				}
+			}
+		}
+	}
+
+	for _, c := range components {
+		c.updateHealthStatus()
+	}
+}
+
+// updateHealthStatus recalculates the health status of the component
+// and its child components.
+func (ch *ComponentHealth) updateHealthStatus() {
+	slices.Sort(ch.degradedBy)
+	for _, child := range ch.childComponents {
+		child.updateHealthStatus()
+	}
+	ch.healthStatus = ch.calculateHealthStatus()
+}
@@ -0,0 +1,59 @@
+package health
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/processor"
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+func TestPropagateDependencies(t *testing.T) {
+	etcd := &ComponentHealth{
+		name:         "etcd",
+		healthStatus: Error,
+		alerts:       []model.LabelSet{{"src_alertname": "etcdNoLeader", "src_severity": "critical"}},
+	}
+	apiserver := newComponentHealth("kube-apiserver", OK)
+	dns := newComponentHealth("dns", OK)
+	nodes := newComponentHealth("nodes", OK)
+	operators := newComponentHealth("operators", Error).AddChild(etcd).AddChild(apiserver).AddChild(dns)
+	workloads := newComponentHealth("workloads", OK).AddChild(newComponentHealth("dns", OK))
+	roots := []*ComponentHealth{
+		newComponentHealth("control-plane", Error).AddChild(nodes).AddChild(operators),
+		workloads,
+	}
+
+	propagateDependencies(roots, processor.ComponentDependencies())
+
+	assert.Equal(t, []nameStatusPair{
+		{name: "control-plane.nodes", status: OK},
+		{name: "control-plane.operators.etcd", status: Error},
+		{name: "control-plane.operators.kube-apiserver", status: Warning},
+		{name: "control-plane.operators.dns", status: Warning},
+		{name: "control-plane.operators", status: Error},
+		{name: "control-plane", status: Error},
+		// the components are matched by their name
+		{name: "workloads.dns", status: Warning},
+		{name: "workloads", status: Warning},
+	}, componentHealthToNameStatusPairs(roots))
+	assert.Equal(t, []string{"etcd"}, apiserver.degradedBy)
+	assert.Equal(t, []string{"etcd"}, dns.degradedBy)
+	assert.Empty(t, nodes.degradedBy)
+
+	// the degraded leaf components are exported with the components
+	_, _, componentMetrics := createHealthMetrics(roots)
+	tree := BuildComponentTree(metricsLabels(componentMetrics), nil, nil)
+	assert.Equal(t, []string{"etcd"}, findSubtree(tree, "control-plane.operators.dns")[0].DegradedBy)
+	assert.Equal(t, "warning", findSubtree(tree, "control-plane.operators.dns")[0].Status)
+}
+
+func metricsLabels(metrics []prom.Metric) []model.LabelSet {
+	ret := make([]model.LabelSet, 0, len(metrics))
+	for _, m := range metrics {
+		ret = append(ret, m.Labels)
+	}
+	return ret
+}
@@ -6,6 +6,8 @@ import (
 	"log/slog"
 	"maps"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/openshift/cluster-health-analyzer/pkg/alertmanager"
@@ -33,6 +35,9 @@ type healthProcessor struct {
 	khChecker               HealthChecker
 	config                  *ComponentsConfig
 	clusterOperatorNames    []string
+
+	// pendingConfig holds the config to be used starting from the next evaluation.
+	pendingConfig atomic.Pointer[ComponentsConfig]
 }
 
 // NewHealthProcessor initializes all the required objects (alert loader, alert matcher and kube-health checker)
@@ -81,18 +86,31 @@ func (p *healthProcessor) Start(ctx context.Context) {
 	go p.Run(ctx)
 }
 
+// UpdateConfig replaces the components config. The new config is used
+// starting from the next evaluation of the components health.
+//
+// The config is expected to be already validated.
+func (p *healthProcessor) UpdateConfig(config *ComponentsConfig) {
+	p.pendingConfig.Store(config)
+}
+
 // Run periodically runs the processor and blocks until the provided context is done.
 func (p *healthProcessor) Run(ctx context.Context) {
 	components := p.finalizeComponentTree(p.config.Components)
 
-	healthStatuses := p.evaluateComponentsHealth(ctx, components)
+	healthStatuses := p.evaluate(ctx, components)
 	p.updateAllMetrics(createHealthMetrics(healthStatuses))
 	ticker := time.NewTicker(p.interval)
 	for {
 		select {
 		case <-ticker.C:
+			if config := p.pendingConfig.Swap(nil); config != nil {
+				slog.Info("Applying updated components config")
+				p.config = config
+				components = p.finalizeComponentTree(config.Components)
+			}
 			slog.Info("Evaluating health of the components")
-			healthStatuses = p.evaluateComponentsHealth(ctx, components)
+			healthStatuses = p.evaluate(ctx, components)
 			p.updateAllMetrics(createHealthMetrics(healthStatuses))
 		case <-ctx.Done():
 			ticker.Stop()
@@ -101,6 +119,16 @@ func (p *healthProcessor) Run(ctx context.Context) {
 	}
 }
 
+// evaluate evaluates the health of the components, including the propagation
+// of the degraded status to the dependent components when enabled.
+func (p *healthProcessor) evaluate(ctx context.Context, components []Component) []*ComponentHealth {
+	componentHealths := p.evaluateComponentsHealth(ctx, components)
+	if p.config.PropagateDependencies {
+		propagateDependencies(componentHealths, processor.ComponentDependencies())
+	}
+	return componentHealths
+}
+
 func (p *healthProcessor) evaluateComponentsHealth(ctx context.Context, components []Component) []*ComponentHealth {
 	var componentHealths []*ComponentHealth
 	for _, c := range components {
@@ -172,11 +200,16 @@ func componentHealthMetrics(cHealth *ComponentHealth) ([]prom.Metric, []prom.Met
 		componentMetrics = append(componentMetrics, childComponentMetrics...)
 	}
 	componentName := fullComponentName(cHealth)
-	// if component has children then only create metric with component name and status
-	if cHealth.HasChildren() {
+	// if component has children then only create metric with component name and status,
+	// the same for the leaf components degraded by their dependencies
+	if cHealth.HasChildren() || len(cHealth.degradedBy) > 0 {
 		m := metricWithNameAndStatus(componentName, cHealth.healthStatus)
+		if len(cHealth.degradedBy) > 0 {
+			m.Labels["degraded_by"] = model.LabelValue(strings.Join(cHealth.degradedBy, ","))
+		}
 		componentMetrics = append(componentMetrics, m)
-	} else {
+	}
+	if !cHealth.HasChildren() {
 		for _, a := range cHealth.alerts {
 			m := metricWithNameAndStatus(componentName, cHealth.healthStatus)
 			maps.Copy(m.Labels, a)
@@ -214,7 +247,16 @@ func (ch *ComponentHealth) calculateHealthStatus() HealthStatus {
 		return Warning
 	}
 
+	// the components the component depends on are unhealthy
+	degraded := OK
+	if len(ch.degradedBy) > 0 {
+		degraded = Warning
+	}
+
 	if ch.alertsErr != nil {
+		if degraded > OK {
+			return degraded
+		}
 		return Unknown
 	}
 
@@ -233,7 +275,7 @@ func (ch *ComponentHealth) calculateHealthStatus() HealthStatus {
 			healthStatus = objStatus.HealthStatus
 		}
 	}
-	return healthStatus
+	return max(healthStatus, degraded)
 }
 
 // metricWithNameAndStatus is a helper function creating a Prometheus metric
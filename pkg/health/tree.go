@@ -0,0 +1,211 @@
+package health
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+const (
+	// ComponentHealthAlertMetric is the name of the metric with the alerts
+	// contributing to the health of the leaf components.
+	ComponentHealthAlertMetric = "component_health_alert"
+	// ComponentHealthObjectMetric is the name of the metric with the health
+	// of the Kubernetes objects of the leaf components.
+	ComponentHealthObjectMetric = "component_health_object"
+	// ComponentHealthMetric is the name of the metric with the health
+	// of the components with child components.
+	ComponentHealthMetric = "component_health"
+)
+
+// ComponentNode is a component in the health tree rebuilt from the
+// component health metrics.
+type ComponentNode struct {
+	Name string `json:"name"`
+	// Path is the full name of the component, e.g. "control-plane.operators.etcd".
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	// DegradedBy are the unhealthy components the component depends on.
+	DegradedBy []string `json:"degraded_by,omitempty"`
+	// Alerts are the firing alerts contributing to the component health.
+	Alerts []model.LabelSet `json:"alerts,omitempty"`
+	// UnhealthyObjects are the Kubernetes objects of the component not being OK.
+	UnhealthyObjects []ObjectNode     `json:"unhealthy_objects,omitempty"`
+	Children         []*ComponentNode `json:"children,omitempty"`
+
+	status HealthStatus
+}
+
+// ObjectNode is the health status of a Kubernetes object of a component.
+type ObjectNode struct {
+	Resource    string `json:"resource"`
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace,omitempty"`
+	Status      string `json:"status"`
+	Progressing bool   `json:"progressing"`
+}
+
+// TreeFilter selects the components from the health tree. The zero values
+// of the fields don't filter.
+type TreeFilter struct {
+	// Path selects the component with the path and its children.
+	Path string
+	// MinStatus filters out the components healthier than the status,
+	// keeping the parents of the matching components.
+	MinStatus HealthStatus
+}
+
+// LoadComponentTree loads the component health metrics via the loader and
+// rebuilds the health tree from them.
+func LoadComponentTree(ctx context.Context, loader prom.Loader, filter TreeFilter) ([]*ComponentNode, error) {
+	t := time.Now()
+	components, err := loader.LoadQuery(ctx, ComponentHealthMetric, t)
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := loader.LoadQuery(ctx, ComponentHealthAlertMetric, t)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := loader.LoadQuery(ctx, ComponentHealthObjectMetric, t)
+	if err != nil {
+		return nil, err
+	}
+	return FilterComponentTree(BuildComponentTree(components, alerts, objects), filter), nil
+}
+
+// BuildComponentTree rebuilds the health tree from the labels of the component,
+// alert and object metrics. The leaf components without alerts use
+// the worst status of their objects.
+func BuildComponentTree(components, alerts, objects []model.LabelSet) []*ComponentNode {
+	var roots []*ComponentNode
+	nodes := make(map[string]*ComponentNode)
+
+	// getNode returns the node for the path, creating it with all its parents
+	// when missing.
+	var getNode func(path string) *ComponentNode
+	getNode = func(path string) *ComponentNode {
+		if n, ok := nodes[path]; ok {
+			return n
+		}
+		n := &ComponentNode{Path: path, Name: path, status: OK}
+		nodes[path] = n
+		if i := strings.LastIndex(path, "."); i >= 0 {
+			n.Name = path[i+1:]
+			parent := getNode(path[:i])
+			parent.Children = append(parent.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+		return n
+	}
+
+	for _, c := range components {
+		n := getNode(string(c["component"]))
+		n.status = parseHealthStatus(string(c["status"]))
+		if d := c["degraded_by"]; d != "" {
+			n.DegradedBy = strings.Split(string(d), ",")
+		}
+	}
+	for _, a := range alerts {
+		n := getNode(string(a["component"]))
+		n.status = parseHealthStatus(string(a["status"]))
+		alert := a.Clone()
+		delete(alert, "component")
+		delete(alert, "status")
+		delete(alert, "__name__")
+		// the metric for failed alerts evaluation has no alert labels
+		if len(alert) > 0 {
+			n.Alerts = append(n.Alerts, alert)
+		}
+	}
+	for _, o := range objects {
+		n := getNode(string(o["component"]))
+		status := parseHealthStatus(string(o["result"]))
+		if len(n.Alerts) == 0 && status > n.status {
+			n.status = status
+		}
+		if status.IsOK() {
+			continue
+		}
+		n.UnhealthyObjects = append(n.UnhealthyObjects, ObjectNode{
+			Resource:    string(o["resource"]),
+			Name:        string(o["name"]),
+			Namespace:   string(o["namespace"]),
+			Status:      status.String(),
+			Progressing: o["progressing"] == "true",
+		})
+	}
+
+	sortNodes(roots)
+	return roots
+}
+
+// sortNodes sorts the nodes by their path and sets their status.
+func sortNodes(nodes []*ComponentNode) {
+	slices.SortFunc(nodes, func(a, b *ComponentNode) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	for _, n := range nodes {
+		n.Status = n.status.String()
+		sortNodes(n.Children)
+	}
+}
+
+// FilterComponentTree returns the subtrees matching the filter.
+func FilterComponentTree(roots []*ComponentNode, filter TreeFilter) []*ComponentNode {
+	if filter.Path != "" {
+		roots = findSubtree(roots, filter.Path)
+	}
+	if filter.MinStatus > OK {
+		roots = filterByStatus(roots, filter.MinStatus)
+	}
+	return roots
+}
+
+func findSubtree(nodes []*ComponentNode, path string) []*ComponentNode {
+	for _, n := range nodes {
+		if n.Path == path {
+			return []*ComponentNode{n}
+		}
+		if strings.HasPrefix(path, n.Path+".") {
+			return findSubtree(n.Children, path)
+		}
+	}
+	return nil
+}
+
+// filterByStatus keeps the nodes with the status at least minStatus
+// or with such a child.
+func filterByStatus(nodes []*ComponentNode, minStatus HealthStatus) []*ComponentNode {
+	var ret []*ComponentNode
+	for _, n := range nodes {
+		children := filterByStatus(n.Children, minStatus)
+		if n.status < minStatus && len(children) == 0 {
+			continue
+		}
+		filtered := *n
+		filtered.Children = children
+		ret = append(ret, &filtered)
+	}
+	return ret
+}
+
+// parseHealthStatus parses the status as formatted by HealthStatus.String.
+func parseHealthStatus(s string) HealthStatus {
+	switch strings.ToLower(s) {
+	case "ok":
+		return OK
+	case "warning":
+		return Warning
+	case "error":
+		return Error
+	default:
+		return Unknown
+	}
+}
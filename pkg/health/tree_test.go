@@ -0,0 +1,114 @@
+package health
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func testComponentTree() []*ComponentNode {
+	components := []model.LabelSet{
+		{"component": "control-plane", "status": "error"},
+		{"component": "control-plane.operators", "status": "error"},
+	}
+	alerts := []model.LabelSet{
+		{
+			"component": "control-plane.operators.etcd", "status": "error",
+			"src_alertname": "etcdNoLeader", "src_namespace": "openshift-etcd", "src_severity": "critical",
+		},
+	}
+	objects := []model.LabelSet{
+		{
+			"component": "control-plane.nodes", "resource": "nodes", "name": "master-0",
+			"result": "OK", "progressing": "false",
+		},
+		{
+			"component": "control-plane.operators.etcd", "resource": "pods", "name": "etcd-0",
+			"namespace": "openshift-etcd", "result": "error", "progressing": "false",
+		},
+		{
+			"component": "control-plane.operators.ingress", "resource": "deployments", "name": "router",
+			"namespace": "openshift-ingress", "result": "warning", "progressing": "true",
+		},
+	}
+	return BuildComponentTree(components, alerts, objects)
+}
+
+func TestBuildComponentTree(t *testing.T) {
+	nodes := &ComponentNode{Name: "nodes", Path: "control-plane.nodes", Status: "OK", status: OK}
+	etcd := &ComponentNode{
+		Name: "etcd", Path: "control-plane.operators.etcd", Status: "error", status: Error,
+		Alerts: []model.LabelSet{
+			{"src_alertname": "etcdNoLeader", "src_namespace": "openshift-etcd", "src_severity": "critical"},
+		},
+		UnhealthyObjects: []ObjectNode{
+			{Resource: "pods", Name: "etcd-0", Namespace: "openshift-etcd", Status: "error"},
+		},
+	}
+	ingress := &ComponentNode{
+		Name: "ingress", Path: "control-plane.operators.ingress", Status: "warning", status: Warning,
+		UnhealthyObjects: []ObjectNode{
+			{Resource: "deployments", Name: "router", Namespace: "openshift-ingress", Status: "warning", Progressing: true},
+		},
+	}
+	operators := &ComponentNode{
+		Name: "operators", Path: "control-plane.operators", Status: "error", status: Error,
+		Children: []*ComponentNode{etcd, ingress},
+	}
+	expected := []*ComponentNode{
+		{
+			Name: "control-plane", Path: "control-plane", Status: "error", status: Error,
+			Children: []*ComponentNode{nodes, operators},
+		},
+	}
+	assert.Equal(t, expected, testComponentTree())
+}
+
+func TestFilterComponentTree(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter TreeFilter
+		want   []string
+	}{
+		{
+			name: "no filter",
+			want: []string{"control-plane", "control-plane.nodes", "control-plane.operators",
+				"control-plane.operators.etcd", "control-plane.operators.ingress"},
+		},
+		{
+			name:   "path",
+			filter: TreeFilter{Path: "control-plane.operators"},
+			want:   []string{"control-plane.operators", "control-plane.operators.etcd", "control-plane.operators.ingress"},
+		},
+		{
+			name:   "unknown path",
+			filter: TreeFilter{Path: "control-plane.foo"},
+		},
+		{
+			name:   "min status",
+			filter: TreeFilter{MinStatus: Warning},
+			want: []string{"control-plane", "control-plane.operators",
+				"control-plane.operators.etcd", "control-plane.operators.ingress"},
+		},
+		{
+			name:   "path and min status",
+			filter: TreeFilter{Path: "control-plane.operators", MinStatus: Error},
+			want:   []string{"control-plane.operators", "control-plane.operators.etcd"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, treePaths(FilterComponentTree(testComponentTree(), tt.filter)))
+		})
+	}
+}
+
+func treePaths(nodes []*ComponentNode) []string {
+	var ret []string
+	for _, n := range nodes {
+		ret = append(ret, n.Path)
+		ret = append(ret, treePaths(n.Children)...)
+	}
+	return ret
+}
@@ -7,6 +7,10 @@ import (
 
 type ComponentsConfig struct {
 	Components []Component `yaml:"components"`
+	// PropagateDependencies enables degrading the components depending
+	// on the unhealthy components, as defined by the dependencies
+	// in the component mappings.
+	PropagateDependencies bool `yaml:"propagateDependencies"`
 }
 
 // Component is a type representing component
@@ -95,6 +99,8 @@ type ComponentHealth struct {
 	objectStatuses  []ObjectStatus
 	// to recognize if the alert evaluation happened with errors or not
 	alertsErr error
+	// degradedBy are the unhealthy components this component depends on
+	degradedBy []string
 }
 
 func (c *ComponentHealth) AddChild(ch *ComponentHealth) *ComponentHealth {
@@ -0,0 +1,169 @@
+package incidents
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/common"
+	"github.com/openshift/cluster-health-analyzer/pkg/processor"
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+// AlertsQuery selects the alerts. The zero values of the fields don't filter.
+type AlertsQuery struct {
+	// TimeRange is the max age of the alerts. DefaultTimeRange is used when zero.
+	TimeRange time.Duration
+	// State is one of "firing", "pending" or "resolved".
+	State     string
+	Severity  string
+	Namespace string
+	// Component is the component the alert is mapped to.
+	Component string
+	Silenced  *bool
+}
+
+func (s *service) Alerts(ctx context.Context, q AlertsQuery) ([]Alert, error) {
+	timeRange := q.TimeRange
+	if timeRange <= 0 {
+		timeRange = DefaultTimeRange
+	}
+	timeNow := time.Now()
+	qRange := v1.Range{
+		Start: timeNow.Add(-timeRange),
+		End:   timeNow,
+		Step:  300 * time.Second,
+	}
+
+	alertData, err := s.promLoader.LoadVectorRange(ctx, "ALERTS", qRange.Start, qRange.End, qRange.Step)
+	if err != nil {
+		slog.Error("Failed to query the alerts", "error", err)
+		return nil, err
+	}
+	healthMap, err := s.promLoader.LoadVectorRange(ctx, processor.ClusterHealthComponentsMap, qRange.Start, qRange.End, qRange.Step)
+	if err != nil {
+		slog.Error("Received error response from Prometheus", "error", err)
+		return nil, err
+	}
+	silences, err := s.amLoader.SilencedAlerts()
+	if err != nil {
+		slog.Error("Failed retrieving silenced alerts from AlertManager", "error", err)
+		return nil, err
+	}
+
+	groupIDs := make(map[string]string, len(healthMap))
+	for _, r := range healthMap {
+		if groupID := string(r.Metric["group_id"]); groupID != "" {
+			groupIDs[alertKey(common.SrcLabels(model.Metric(r.Metric)), r.Metric[clusterIDStr])] = groupID
+		}
+	}
+
+	ret := make([]Alert, 0, len(alertData))
+	for _, r := range mergeAlertSeries(alertData) {
+		labels := r.labels
+		startTime, endTime := processSampleTime(r.first, r.last, qRange)
+		state := string(labels["alertstate"])
+		if !endTime.IsZero() {
+			state = "resolved"
+		}
+		hm := processor.MapAlerts([]model.LabelSet{labels})[0]
+
+		alert := Alert{
+			Name:      string(labels["alertname"]),
+			Namespace: string(labels["namespace"]),
+			Severity:  string(labels["severity"]),
+			State:     state,
+			StartTime: formatToRFC3339(startTime),
+			EndTime:   formatToRFC3339(endTime),
+			Silenced:  isAlertSilenced(labels, silences),
+			Layer:     hm.Layer,
+			Component: hm.Component,
+			GroupId:   groupIDs[alertKey(hm.SrcLabels, labels[clusterIDStr])],
+			ClusterID: string(labels[clusterIDStr]),
+			Labels:    labels,
+		}
+		if matchesAlertsQuery(alert, q) {
+			ret = append(ret, alert)
+		}
+	}
+
+	// the ties are broken by the labels, so that the order is stable
+	// between the calls and the pages don't overlap
+	slices.SortFunc(ret, func(a, b Alert) int {
+		return cmp.Or(
+			strings.Compare(a.StartTime, b.StartTime),
+			strings.Compare(a.Labels.String(), b.Labels.String()),
+		)
+	})
+	return ret, nil
+}
+
+// alertSeries is the history of a single alert, merged from its series
+// in the different states.
+type alertSeries struct {
+	// labels are the labels of the series with the latest sample,
+	// the alertstate label is the current (or last) state of the alert.
+	labels      model.LabelSet
+	first, last model.SamplePair
+}
+
+// mergeAlertSeries merges the ALERTS series of the same alert differing
+// only in the alertstate label: the pending series of an alert that became
+// firing ends, but the alert is not resolved.
+func mergeAlertSeries(data prom.RangeVector) []alertSeries {
+	var ret []alertSeries
+	byKey := make(map[string]int, len(data))
+	for _, r := range data {
+		if len(r.Samples) == 0 {
+			continue
+		}
+		labels := r.Metric.Clone()
+		delete(labels, "__name__")
+		key := model.LabelSet(labels.Clone())
+		delete(key, "alertstate")
+
+		first, last := r.Samples[0], r.Samples[len(r.Samples)-1]
+		i, ok := byKey[key.String()]
+		if !ok {
+			byKey[key.String()] = len(ret)
+			ret = append(ret, alertSeries{labels: labels, first: first, last: last})
+			continue
+		}
+		if first.Timestamp.Before(ret[i].first.Timestamp) {
+			ret[i].first = first
+		}
+		if last.Timestamp.After(ret[i].last.Timestamp) {
+			ret[i].labels = labels
+			ret[i].last = last
+		}
+	}
+	return ret
+}
+
+// alertKey identifies the alert in the health map by its source labels
+// and the cluster.
+func alertKey(srcLabels model.LabelSet, clusterID model.LabelValue) string {
+	return string(clusterID) + srcLabels.String()
+}
+
+func matchesAlertsQuery(a Alert, q AlertsQuery) bool {
+	switch {
+	case q.State != "" && a.State != q.State:
+		return false
+	case q.Severity != "" && !strings.EqualFold(a.Severity, q.Severity):
+		return false
+	case q.Namespace != "" && a.Namespace != q.Namespace:
+		return false
+	case q.Component != "" && a.Component != q.Component:
+		return false
+	case q.Silenced != nil && a.Silenced != *q.Silenced:
+		return false
+	}
+	return true
+}
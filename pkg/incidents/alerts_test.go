@@ -0,0 +1,108 @@
+package incidents
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/processor"
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+	"github.com/openshift/cluster-health-analyzer/pkg/test/mocks"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestService_Alerts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	now := model.Now()
+	promLoader := mocks.NewMockPrometheusLoader(ctrl)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), "ALERTS",
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{
+		{
+			Metric: model.LabelSet{
+				"__name__": "ALERTS", "alertname": "etcdNoLeader", "namespace": "openshift-etcd",
+				"severity": "critical", "alertstate": "firing",
+			},
+			Samples: []model.SamplePair{{Value: 1, Timestamp: now.Add(-10 * time.Minute)}, {Value: 1, Timestamp: now}},
+		},
+		{
+			// the series before the alert became firing
+			Metric: model.LabelSet{
+				"__name__": "ALERTS", "alertname": "etcdNoLeader", "namespace": "openshift-etcd",
+				"severity": "critical", "alertstate": "pending",
+			},
+			Samples: []model.SamplePair{{Value: 1, Timestamp: now.Add(-15 * time.Minute)}, {Value: 1, Timestamp: now.Add(-10 * time.Minute)}},
+		},
+		{
+			Metric: model.LabelSet{
+				"__name__": "ALERTS", "alertname": "CustomAlert", "namespace": "my-app",
+				"severity": "warning", "alertstate": "pending",
+			},
+			Samples: []model.SamplePair{{Value: 1, Timestamp: now}},
+		},
+		{
+			Metric: model.LabelSet{
+				"__name__": "ALERTS", "alertname": "Watchdog", "namespace": "my-monitoring",
+				"severity": "none", "alertstate": "firing",
+			},
+			Samples: []model.SamplePair{{Value: 1, Timestamp: now.Add(-time.Hour)}},
+		},
+	}, nil).Times(3)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), processor.ClusterHealthComponentsMap,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{
+		{
+			Metric: model.LabelSet{
+				"group_id": "1", "component": "etcd", "layer": "core",
+				"src_alertname": "etcdNoLeader", "src_namespace": "openshift-etcd", "src_severity": "critical",
+			},
+			Samples: []model.SamplePair{{Value: 2, Timestamp: now}},
+		},
+	}, nil).Times(3)
+
+	amLoader := mocks.NewMockAlertManagerLoader(ctrl)
+	amLoader.EXPECT().SilencedAlerts().Return([]models.Alert{
+		{Labels: models.LabelSet{"alertname": "CustomAlert", "namespace": "my-app"}},
+	}, nil).Times(3)
+
+	svc := NewService(promLoader, amLoader)
+
+	alerts, err := svc.Alerts(t.Context(), AlertsQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 3)
+
+	assert.Equal(t, Alert{
+		Name:      "Watchdog",
+		Namespace: "my-monitoring",
+		Severity:  "none",
+		State:     "resolved",
+		StartTime: formatToRFC3339(now.Add(-time.Hour).Time()),
+		EndTime:   formatToRFC3339(now.Add(-time.Hour).Time()),
+		Layer:     "Others",
+		Component: "Others",
+		Labels: model.LabelSet{
+			"alertname": "Watchdog", "namespace": "my-monitoring",
+			"severity": "none", "alertstate": "firing",
+		},
+	}, alerts[0])
+	assert.Equal(t, "etcd", alerts[1].Component)
+	assert.Equal(t, "1", alerts[1].GroupId)
+	assert.Equal(t, "firing", alerts[1].State)
+	assert.Equal(t, formatToRFC3339(now.Add(-15*time.Minute).Time()), alerts[1].StartTime)
+	assert.Empty(t, alerts[1].EndTime)
+	assert.Equal(t, "pending", alerts[2].State)
+	assert.True(t, alerts[2].Silenced)
+	assert.Empty(t, alerts[2].GroupId)
+
+	silenced := true
+	alerts, err = svc.Alerts(t.Context(), AlertsQuery{State: "pending", Silenced: &silenced})
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "CustomAlert", alerts[0].Name)
+
+	alerts, err = svc.Alerts(t.Context(), AlertsQuery{Component: "etcd", Severity: "Critical"})
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "etcdNoLeader", alerts[0].Name)
+}
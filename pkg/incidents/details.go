@@ -0,0 +1,222 @@
+package incidents
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+const (
+	clusterHealthComponents = "cluster_health_components"
+	componentHealth         = "component_health"
+)
+
+func (s *service) Details(ctx context.Context, id string) (*IncidentDetails, error) {
+	inc, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	timeNow := time.Now()
+	qRange := v1.Range{
+		Start: timeNow.Add(-DefaultTimeRange),
+		End:   timeNow,
+		Step:  300 * time.Second,
+	}
+
+	details := &IncidentDetails{
+		GroupId:   inc.GroupId,
+		Severity:  inc.Severity,
+		StartTime: inc.StartTime,
+		Status:    inc.Status,
+		EndTime:   inc.EndTime,
+		Cluster:   inc.Cluster,
+		ClusterID: inc.ClusterID,
+		URL:       inc.URL,
+		Signals:   inc.Signals,
+		Timeline:  inc.Timeline,
+		RootCause: inc.RootCause,
+
+		UpstreamComponents: inc.UpstreamComponents,
+	}
+
+	details.Alerts, err = s.loadAlertTimelines(ctx, inc, qRange)
+	if err != nil {
+		slog.Error("Failed to load the alerts timeline", "error", err)
+		return nil, err
+	}
+
+	// The components data are best effort: the components metrics
+	// might not be available, e.g. when the components health is disabled.
+	details.AffectedComponents, err = s.loadAffectedComponents(ctx, inc.AffectedComponents, timeNow)
+	if err != nil {
+		slog.Error("Failed to load the components ranking", "error", err)
+	}
+	details.ComponentHealth, err = s.loadComponentHealth(ctx, inc.AffectedComponents, timeNow)
+	if err != nil {
+		slog.Error("Failed to load the components health", "error", err)
+	}
+	return details, nil
+}
+
+// loadAlertTimelines completes the alerts of the incident with their firing
+// intervals, computed from the ALERTS series sharing the alert name, namespace
+// and severity.
+func (s *service) loadAlertTimelines(ctx context.Context, inc *Incident, qRange v1.Range) ([]AlertDetails, error) {
+	if len(inc.Alerts) == 0 {
+		return []AlertDetails{}, nil
+	}
+
+	names := make([]string, 0, len(inc.Alerts))
+	for _, a := range inc.Alerts {
+		names = append(names, regexpQuote(string(a["name"])))
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+	query := fmt.Sprintf(`ALERTS{alertstate!="pending",alertname=~"%s"}`, strings.Join(names, "|"))
+
+	alertData, err := s.promLoader.LoadVectorRange(ctx, query, qRange.Start, qRange.End, qRange.Step)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]AlertDetails, 0, len(inc.Alerts))
+	for _, a := range inc.Alerts {
+		var timestamps []model.Time
+		for _, r := range alertData {
+			if inc.ClusterID != "" && string(r.Metric[clusterIDStr]) != inc.ClusterID {
+				continue
+			}
+			if r.Metric["alertname"] != a["name"] || r.Metric["namespace"] != a["namespace"] ||
+				r.Metric["severity"] != a["severity"] {
+				continue
+			}
+			for _, sample := range r.Samples {
+				timestamps = append(timestamps, sample.Timestamp)
+			}
+		}
+		silenced, _ := strconv.ParseBool(string(a[silencedStr]))
+
+		ret = append(ret, AlertDetails{
+			Name:      string(a["name"]),
+			Namespace: string(a["namespace"]),
+			Severity:  string(a["severity"]),
+			Status:    string(a["status"]),
+			StartTime: string(a["start_time"]),
+			EndTime:   string(a["end_time"]),
+			Silenced:  silenced,
+			Labels:    a,
+			Intervals: firingIntervals(timestamps, qRange),
+		})
+	}
+	return ret, nil
+}
+
+// firingIntervals splits the sample timestamps into intervals of continuous
+// firing. A gap longer than the range step ends the interval. The last interval
+// has no end time when it reaches the end of the range.
+func firingIntervals(timestamps []model.Time, qRange v1.Range) []FiringInterval {
+	if len(timestamps) == 0 {
+		return []FiringInterval{}
+	}
+	slices.Sort(timestamps)
+	timestamps = slices.Compact(timestamps)
+
+	var ret []FiringInterval
+	start := timestamps[0]
+	for i := 1; i <= len(timestamps); i++ {
+		last := timestamps[i-1]
+		if i < len(timestamps) && timestamps[i].Sub(last) <= qRange.Step {
+			continue
+		}
+		startTime, endTime := processSampleTime(
+			model.SamplePair{Timestamp: start}, model.SamplePair{Timestamp: last}, qRange)
+		ret = append(ret, FiringInterval{
+			StartTime: formatToRFC3339(startTime),
+			EndTime:   formatToRFC3339(endTime),
+		})
+		if i < len(timestamps) {
+			start = timestamps[i]
+		}
+	}
+	return ret
+}
+
+// loadAffectedComponents returns the components with their layer and rank
+// from the cluster_health_components metric.
+func (s *service) loadAffectedComponents(ctx context.Context, components []string, t time.Time) ([]AffectedComponent, error) {
+	ret := make([]AffectedComponent, 0, len(components))
+	for _, c := range components {
+		ret = append(ret, AffectedComponent{Component: c})
+	}
+
+	val, err := s.promLoader.LoadVectorRange(ctx, clusterHealthComponents, t, t, time.Minute)
+	if err != nil {
+		return ret, err
+	}
+	byName := make(map[string]prom.Range, len(val))
+	for _, r := range val {
+		byName[string(r.Metric["component"])] = r
+	}
+	for i := range ret {
+		r, ok := byName[ret[i].Component]
+		if !ok || len(r.Samples) == 0 {
+			continue
+		}
+		healthy, err := strconv.ParseBool(string(r.Metric["healthy"]))
+		if err == nil {
+			ret[i].Healthy = &healthy
+		}
+		ret[i].Layer = string(r.Metric["layer"])
+		ret[i].Rank = int(r.Samples[len(r.Samples)-1].Value)
+	}
+	return ret, nil
+}
+
+// loadComponentHealth returns the status of the components evaluated by
+// the health processor, related to the affected components. The health
+// processor identifies the components by their full path in the components
+// tree (e.g. "control-plane.operators.etcd"), the last segment is matched.
+func (s *service) loadComponentHealth(ctx context.Context, components []string, t time.Time) ([]ComponentHealthStatus, error) {
+	val, err := s.promLoader.LoadQuery(ctx, componentHealth, t)
+	if err != nil {
+		return nil, err
+	}
+	var ret []ComponentHealthStatus
+	for _, v := range val {
+		name := string(v["component"])
+		short := name[strings.LastIndex(name, ".")+1:]
+		if !slices.Contains(components, short) {
+			continue
+		}
+		ret = append(ret, ComponentHealthStatus{
+			Component: name,
+			Status:    string(v["status"]),
+		})
+	}
+	slices.SortFunc(ret, func(a, b ComponentHealthStatus) int {
+		return strings.Compare(a.Component, b.Component)
+	})
+	return ret, nil
+}
+
+// regexpQuote escapes the value to be used in a PromQL regular expression.
+func regexpQuote(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`\.+*?()|[]{}^$`, r) {
+			b.WriteString(`\\`)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
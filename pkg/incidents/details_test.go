@@ -0,0 +1,172 @@
+package incidents
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/processor"
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+	"github.com/openshift/cluster-health-analyzer/pkg/test/mocks"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFiringIntervals(t *testing.T) {
+	end := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	qRange := v1.Range{Start: end.Add(-time.Hour), End: end, Step: 5 * time.Minute}
+	ts := func(d time.Duration) model.Time { return model.TimeFromUnixNano(end.Add(-d).UnixNano()) }
+	rfc := func(d time.Duration) string { return end.Add(-d).Format(time.RFC3339) }
+
+	tests := []struct {
+		name       string
+		timestamps []model.Time
+		want       []FiringInterval
+	}{
+		{
+			name: "no samples",
+			want: []FiringInterval{},
+		},
+		{
+			name:       "still firing",
+			timestamps: []model.Time{ts(10 * time.Minute), ts(5 * time.Minute), ts(0)},
+			want:       []FiringInterval{{StartTime: rfc(10 * time.Minute)}},
+		},
+		{
+			name: "flapping",
+			timestamps: []model.Time{
+				ts(50 * time.Minute), ts(45 * time.Minute),
+				ts(30 * time.Minute),
+				// duplicate timestamps from multiple series
+				ts(10 * time.Minute), ts(10 * time.Minute), ts(5 * time.Minute),
+			},
+			want: []FiringInterval{
+				{StartTime: rfc(50 * time.Minute), EndTime: rfc(45 * time.Minute)},
+				{StartTime: rfc(30 * time.Minute), EndTime: rfc(30 * time.Minute)},
+				{StartTime: rfc(10 * time.Minute)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, firingIntervals(tt.timestamps, qRange))
+		})
+	}
+}
+
+func TestService_Details(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	now := model.Now()
+	promLoader := mocks.NewMockPrometheusLoader(ctrl)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), processor.ClusterHealthComponentsMap,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{
+		{
+			Metric: model.LabelSet{
+				"group_id": "1", "component": "etcd", "layer": "core", "type": "alert",
+				"src_alertname": "etcdNoLeader", "src_namespace": "openshift-etcd", "src_severity": "critical",
+			},
+			Samples: []model.SamplePair{
+				{Value: 2, Timestamp: now.Add(-6 * time.Minute)},
+				{Value: 2, Timestamp: now.Add(-time.Minute)},
+			},
+		},
+	}, nil).Times(2)
+	alertSeries := prom.RangeVector{
+		{
+			Metric: model.LabelSet{
+				"alertname": "etcdNoLeader", "namespace": "openshift-etcd", "severity": "critical",
+				"alertstate": "firing",
+			},
+			Samples: []model.SamplePair{
+				{Value: 1, Timestamp: now.Add(-40 * time.Minute)},
+				{Value: 1, Timestamp: now.Add(-35 * time.Minute)},
+				{Value: 1, Timestamp: now.Add(-5 * time.Minute)},
+				{Value: 1, Timestamp: now},
+			},
+		},
+	}
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), `ALERTS{alertstate!="pending"}`,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(alertSeries, nil).Times(2)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), `ALERTS{alertstate!="pending",alertname=~"etcdNoLeader"}`,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(alertSeries, nil)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), "cluster_health_components",
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{
+		{
+			Metric:  model.LabelSet{"component": "etcd", "layer": "core", "healthy": "false"},
+			Samples: []model.SamplePair{{Value: 1, Timestamp: now}},
+		},
+	}, nil)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), processor.IncidentRootCauseMetric,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{
+		{
+			Metric: model.LabelSet{
+				"group_id": "1", "root_cause": "etcdNoLeader", "type": "alert", "layer": "core", "component": "etcd",
+				"src_alertname": "etcdNoLeader", "src_namespace": "openshift-etcd", "src_severity": "critical",
+			},
+			Samples: []model.SamplePair{{Value: 0.8125, Timestamp: now}},
+		},
+		{
+			// the root cause from before the last change is not used
+			Metric: model.LabelSet{
+				"group_id": "1", "root_cause": "KubeAPIDown", "type": "alert", "layer": "core", "component": "kube-apiserver",
+				"src_alertname": "KubeAPIDown", "src_severity": "critical",
+			},
+			Samples: []model.SamplePair{{Value: 0.6, Timestamp: now.Add(-10 * time.Minute)}},
+		},
+	}, nil).Times(2)
+	promLoader.EXPECT().LoadQuery(gomock.Any(), "console_url", gomock.Any()).Return(nil, nil).Times(2)
+	promLoader.EXPECT().LoadQuery(gomock.Any(), "component_health", gomock.Any()).Return([]model.LabelSet{
+		{"component": "control-plane.operators.etcd", "status": "error"},
+		{"component": "control-plane.operators.ingress", "status": "OK"},
+	}, nil)
+
+	amLoader := mocks.NewMockAlertManagerLoader(ctrl)
+	amLoader.EXPECT().SilencedAlerts().Return(nil, nil).Times(2)
+
+	svc := NewService(promLoader, amLoader)
+
+	details, err := svc.Details(t.Context(), "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "critical", details.Severity)
+	assert.Len(t, details.Alerts, 1)
+	assert.Equal(t, "etcdNoLeader", details.Alerts[0].Name)
+	assert.False(t, details.Alerts[0].Silenced)
+	assert.Equal(t, []FiringInterval{
+		{
+			StartTime: formatToRFC3339(now.Add(-40 * time.Minute).Time()),
+			EndTime:   formatToRFC3339(now.Add(-35 * time.Minute).Time()),
+		},
+		{StartTime: formatToRFC3339(now.Add(-5 * time.Minute).Time())},
+	}, details.Alerts[0].Intervals)
+	healthy := false
+	assert.Equal(t, []AffectedComponent{
+		{Component: "etcd", Layer: "core", Rank: 1, Healthy: &healthy},
+	}, details.AffectedComponents)
+	assert.Equal(t, []ComponentHealthStatus{
+		{Component: "control-plane.operators.etcd", Status: "error"},
+	}, details.ComponentHealth)
+	// the timeline is reconstructed from the health map history
+	added := formatToRFC3339(now.Add(-6 * time.Minute).Time().UTC())
+	assert.Equal(t, []TimelineEvent{
+		{Time: added, Type: "component_added", Layer: "core", Component: "etcd"},
+		{
+			Time: added, Type: "alert_added", Severity: "critical", SignalType: "alert",
+			Layer: "core", Component: "etcd",
+			Alert: model.LabelSet{"alertname": "etcdNoLeader", "namespace": "openshift-etcd", "severity": "critical"},
+		},
+	}, details.Timeline)
+	assert.Equal(t, &RootCause{
+		Name: "etcdNoLeader", Type: "alert", Layer: "core", Component: "etcd", Score: 0.81,
+		Labels: model.LabelSet{"alertname": "etcdNoLeader", "namespace": "openshift-etcd", "severity": "critical"},
+	}, details.RootCause)
+
+	_, err = svc.Details(t.Context(), "2")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestRegexpQuote(t *testing.T) {
+	assert.Equal(t, `KubePodNotReady`, regexpQuote("KubePodNotReady"))
+	assert.Equal(t, `a\\.b\\|c`, regexpQuote("a.b|c"))
+}
@@ -0,0 +1,454 @@
+// Package incidents assembles the incidents from the health map exported
+// by the analyzer, completed with the details about their alerts.
+package incidents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/api/v2/models"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/alertmanager"
+	"github.com/openshift/cluster-health-analyzer/pkg/common"
+	"github.com/openshift/cluster-health-analyzer/pkg/processor"
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+const (
+	// DefaultTimeRange is the default and maximal age of the incidents.
+	DefaultTimeRange = 15 * 24 * time.Hour
+
+	clusterIDStr = "clusterID"
+	defaultStr   = "default"
+	silencedStr  = "silenced"
+)
+
+// ErrNotFound is returned when the requested incident doesn't exist.
+var ErrNotFound = errors.New("incident not found")
+
+// Query selects the incidents. The zero values of the fields don't filter.
+type Query struct {
+	// TimeRange is the max age of the incidents. DefaultTimeRange is used when zero.
+	TimeRange   time.Duration
+	MinSeverity processor.HealthValue
+	// Component must be one of the affected components.
+	Component string
+	// Status is either "firing" or "resolved".
+	Status string
+}
+
+// Service provides the incidents.
+type Service interface {
+	// List returns the incidents matching the query.
+	List(ctx context.Context, q Query) ([]Incident, error)
+	// Get returns the incident by its id within the DefaultTimeRange.
+	// It returns ErrNotFound if there is no such incident.
+	Get(ctx context.Context, id string) (*Incident, error)
+	// Details returns the incident by its id completed with the firing
+	// intervals of its alerts and the status of the affected components.
+	// It returns ErrNotFound if there is no such incident.
+	Details(ctx context.Context, id string) (*IncidentDetails, error)
+	// Alerts returns the alerts matching the query, including the ones
+	// not being part of any incident.
+	Alerts(ctx context.Context, q AlertsQuery) ([]Alert, error)
+}
+
+type service struct {
+	promLoader prom.Loader
+	amLoader   alertmanager.Loader
+}
+
+// NewService creates a new Service loading the data via the given loaders.
+func NewService(promLoader prom.Loader, amLoader alertmanager.Loader) Service {
+	return &service{
+		promLoader: promLoader,
+		amLoader:   amLoader,
+	}
+}
+
+func (s *service) List(ctx context.Context, q Query) ([]Incident, error) {
+	timeRange := q.TimeRange
+	if timeRange <= 0 {
+		timeRange = DefaultTimeRange
+	}
+	incidents, err := s.load(ctx, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	return filterIncidents(incidents, q), nil
+}
+
+func (s *service) Get(ctx context.Context, id string) (*Incident, error) {
+	incidents, err := s.load(ctx, DefaultTimeRange)
+	if err != nil {
+		return nil, err
+	}
+	for _, inc := range incidents {
+		if inc.GroupId == id {
+			return &inc, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// load assembles the incidents active within the time range before now
+// from the health map in Prometheus, completed with the alerts data
+// and their silences from AlertManager.
+func (s *service) load(ctx context.Context, timeRange time.Duration) ([]Incident, error) {
+	timeNow := time.Now()
+	queryTimeRange := v1.Range{
+		Start: timeNow.Add(-timeRange),
+		End:   timeNow,
+		Step:  300 * time.Second,
+	}
+
+	val, err := s.promLoader.LoadVectorRange(ctx, processor.ClusterHealthComponentsMap, queryTimeRange.Start, queryTimeRange.End, queryTimeRange.Step)
+	if err != nil {
+		slog.Error("Received error response from Prometheus", "error", err)
+		return nil, err
+	}
+
+	silences, err := s.amLoader.SilencedAlerts()
+	if err != nil {
+		slog.Error("Failed retrieving silenced alerts from AlertManager", "error", err)
+		return nil, err
+	}
+	clusterIDconsoleURL, err := getConsoleURL(ctx, s.promLoader)
+	if err != nil {
+		slog.Error("Failed retrieving console URL from metrics", "error", err)
+	}
+	incidentsMap, err := transformPromValueToIncident(val, queryTimeRange, clusterIDconsoleURL)
+	if err != nil {
+		slog.Error("Failed to transform metric data", "error", err)
+		return nil, err
+	}
+
+	incidents := getAlertDataForIncidents(ctx, incidentsMap, silences, s.promLoader, queryTimeRange)
+
+	timelines := timelinesFromHealthMap(val)
+	// The root causes are best effort: they are not available
+	// for the incidents recorded by the older versions of the analyzer.
+	rootCauses, err := s.loadRootCauses(ctx, queryTimeRange)
+	if err != nil {
+		slog.Error("Failed to load the incidents root causes", "error", err)
+	}
+	deps := processor.ComponentDependencies()
+	for i := range incidents {
+		incidents[i].Timeline = timelines[incidents[i].GroupId]
+		incidents[i].RootCause = rootCauses[incidents[i].GroupId]
+		incidents[i].UpstreamComponents = deps.UpstreamComponents(incidents[i].AffectedComponents)
+	}
+	return incidents, nil
+}
+
+// formatToRFC3339 formats a time to RFC3339 string, returns empty string for zero time
+func formatToRFC3339(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// processSampleTime calculates the delta between the two samples and if it's greater
+// than the range step then the endTime is set, otherwise it returns zero endTime
+func processSampleTime(firstSample, lastSample model.SamplePair, qRange v1.Range) (time.Time, time.Time) {
+	startTime := firstSample.Timestamp.Time()
+	var endTime time.Time
+
+	if qRange.End.Sub(lastSample.Timestamp.Time()).Seconds() > qRange.Step.Seconds() {
+		endTime = lastSample.Timestamp.Time()
+	}
+	return startTime, endTime
+}
+
+// transformPromValueToIncident transforms the metrics data to map of incidents
+func transformPromValueToIncident(dataVec prom.RangeVector,
+	qRange v1.Range,
+	clusterIDConsoleURL map[string]string) (map[string]Incident, error) {
+
+	incidents := make(map[string]Incident, len(dataVec))
+	for _, v := range dataVec {
+
+		alertSeverity := v.Metric["src_severity"]
+		alertName := v.Metric["src_alertname"]
+
+		if alertSeverity == "none" {
+			slog.Debug("Skipping unknown severity ", "alert", alertName, "severity", alertSeverity)
+			continue
+		}
+
+		lastSample := v.Samples[len(v.Samples)-1]
+		firstSample := v.Samples[0]
+		startTime, endTime := processSampleTime(firstSample, lastSample, qRange)
+
+		labels := common.SrcLabels(model.Metric(v.Metric))
+
+		healthyVal := processor.HealthValue(lastSample.Value)
+		groupId := string(v.Metric["group_id"])
+		component := string(v.Metric["component"])
+		clusterName := string(v.Metric["cluster"])
+		clusterID := string(v.Metric[clusterIDStr])
+		srcType := string(v.Metric["type"])
+
+		if existingInc, ok := incidents[groupId]; ok {
+			existingInc.ComponentsSet[component] = struct{}{}
+			existingInc.AffectedComponents = slices.Collect(maps.Keys(existingInc.ComponentsSet))
+			sort.Strings(existingInc.AffectedComponents)
+
+			existingInc.AddSource(srcType, labels)
+
+			if healthyVal > processor.ParseHealthValue(existingInc.Severity) {
+				existingInc.Severity = healthyVal.String()
+			}
+			err := existingInc.UpdateStartTime(startTime)
+			if err != nil {
+				slog.Error("Failed to parse the start time of an incident ", "error", err)
+				continue
+			}
+			err = existingInc.UpdateEndTime(endTime)
+			if err != nil {
+				slog.Error("Failed to parse the end time of an incident ", "error", err)
+				continue
+			}
+			existingInc.UpdateStatus()
+			incidents[existingInc.GroupId] = existingInc
+		} else {
+			incident := Incident{
+				Cluster:   clusterName,
+				ClusterID: clusterID,
+				GroupId:   groupId,
+				Severity:  healthyVal.String(),
+				StartTime: formatToRFC3339(startTime),
+				EndTime:   formatToRFC3339(endTime),
+				ComponentsSet: map[string]struct{}{
+					component: {},
+				},
+				AffectedComponents: []string{component},
+			}
+			incident.AddSource(srcType, labels)
+			if clusterIDConsoleURL != nil {
+				if clusterID != "" {
+					incident.URL = fmt.Sprintf("%s/monitoring/incidents?groupId=%s", clusterIDConsoleURL[clusterID], groupId)
+				} else {
+					incident.URL = fmt.Sprintf("%s/monitoring/incidents?groupId=%s", clusterIDConsoleURL[defaultStr], groupId)
+				}
+			}
+			incident.UpdateStatus()
+			incidents[groupId] = incident
+		}
+	}
+	return incidents, nil
+}
+
+// getAlertDataForIncidents queries Prometheus for firing alerts from the last 15 days (to have
+// some starting time) and then maps (the alert identifier is composed by name and namespace)
+// the active alerts to the provided map of incidents. It returns slice of the incidents.
+func getAlertDataForIncidents(ctx context.Context, incidents map[string]Incident, silences []models.Alert, promAPI prom.Loader, qRange v1.Range) []Incident {
+	alertData, err := promAPI.LoadVectorRange(ctx, `ALERTS{alertstate!="pending"}`, qRange.Start, qRange.End, qRange.Step)
+	if err != nil {
+		slog.Error("Failed to query firing alerts", "error", err)
+		return nil
+	}
+
+	silencedAlertsMap := make(map[string][]models.Alert, len(silences))
+	for _, silencedAlert := range silences {
+		if alertname, ok := silencedAlert.Labels["alertname"]; ok {
+			silencedAlertsMap[alertname] = append(silencedAlertsMap[alertname], silencedAlert)
+		}
+	}
+
+	var alerts []model.LabelSet
+	for i := range alertData {
+		sample := alertData[i]
+		metric := model.LabelSet(sample.Metric)
+		firstSample := sample.Samples[0]
+		lastSample := sample.Samples[len(sample.Samples)-1]
+		startTime, endTime := processSampleTime(firstSample, lastSample, qRange)
+
+		metric["start_time"] = model.LabelValue(formatToRFC3339(startTime))
+		if !endTime.IsZero() {
+			metric["end_time"] = model.LabelValue(formatToRFC3339(endTime))
+			metric["alertstate"] = "resolved"
+		} else {
+			metric["alertstate"] = "firing"
+		}
+		alerts = append(alerts, metric)
+	}
+
+	var incidentsSlice []Incident
+	for _, inc := range incidents {
+		updatedAlertsMap := make(map[string]model.LabelSet, len(inc.Alerts))
+
+		for _, alertInIncident := range inc.Alerts {
+			subsetMatcher := common.LabelsSubsetMatcher{Labels: alertInIncident}
+			for _, firingAlert := range alerts {
+				// check for multicluster/ACM environment
+				if inc.ClusterID != "" {
+					clusterIDMatch := string(firingAlert[clusterIDStr]) == inc.ClusterID
+					// if the alert cluster ID does not match incident cluster ID, skip
+					if !clusterIDMatch {
+						continue
+					}
+				}
+				match, _ := subsetMatcher.Matches(firingAlert)
+				if match {
+
+					// the silencedAlertsMap is precomputed in order to contain all the silences grouped by alertname
+					// [Alert1] = [{alertname="Alert1", namespace="foo"}, alertname="Alert1", namespace="bar"]
+					alertname := string(firingAlert["alertname"])
+					namespace := string(firingAlert["namespace"])
+					severity := string(firingAlert["severity"])
+
+					key := fmt.Sprintf("%s|%s|%s", alertname, namespace, severity)
+
+					silenced := false
+					if isAlertSilenced(firingAlert, silencedAlertsMap[alertname]) {
+						silenced = true
+					}
+
+					updatedAlert := cleanupLabels(firingAlert)
+
+					// If multiple alerts shares the same triple (alertname, namespace, severity) within
+					// the same incident, these should be collapsed in a unique row. (same logic applied on server command)
+					// The desired behaviour is to attach `silenced="true"` only if all colliding alerts are silenced, otherwise false.
+					if _, f := updatedAlertsMap[key]; f {
+
+						// if an alert, already labels cleaned, was already registered in the map
+						// we should verify if it was marked as silenced
+						lastSilenced, err := strconv.ParseBool(string(updatedAlertsMap[key][silencedStr]))
+						if err != nil {
+							slog.Error("failed to parse bool", "error", err)
+							return nil
+						}
+						// the && operator allow us to get the following behaviour
+						// if all are silenced the ending property will be true
+						// if even just one is not silenced the ending property will be false
+						updatedAlert[silencedStr] = model.LabelValue(fmt.Sprintf("%t", lastSilenced && silenced))
+						updatedAlertsMap[key] = updatedAlert
+					} else {
+						updatedAlert[silencedStr] = model.LabelValue(fmt.Sprintf("%t", silenced))
+						updatedAlertsMap[key] = updatedAlert
+					}
+				}
+			}
+		}
+
+		inc.Alerts = slices.Collect(maps.Values(updatedAlertsMap))
+
+		// sorting introduced to resolve unit tests flakyness
+		slices.SortFunc(inc.Alerts, func(ls1, ls2 model.LabelSet) int {
+			return strings.Compare(string(ls1["start_time"]), string(ls2["start_time"]))
+		})
+
+		incidentsSlice = append(incidentsSlice, inc)
+	}
+	return incidentsSlice
+}
+
+// cleanupLabels removes and renames some of the
+// labels from the set and returns new LabelSet
+func cleanupLabels(m model.LabelSet) model.LabelSet {
+	updatedLS := m.Clone()
+	updatedLS["status"] = updatedLS["alertstate"]
+	updatedLS["name"] = updatedLS["alertname"]
+	if cID := updatedLS[clusterIDStr]; cID != "" {
+		updatedLS["cluster_id"] = cID
+	}
+	delete(updatedLS, "__name__")
+	delete(updatedLS, "prometheus")
+	delete(updatedLS, "alertstate")
+	delete(updatedLS, "alertname")
+	delete(updatedLS, "pod")
+	delete(updatedLS, clusterIDStr)
+	return updatedLS
+}
+
+// getConsoleURL queries the "console_url" metric from the Prometheus.
+// If there is no metric value, it returns nil and error.
+// If the "clusterID" label exists in the metric, it returns a map of cluster ID to
+// console URL mappin.
+// If the "clusterID" label doesn't exist in the metric, it returns a map
+// with one key-value pair with "default" key and
+// the console URL.
+func getConsoleURL(ctx context.Context, prom prom.Loader) (map[string]string, error) {
+	val, err := prom.LoadQuery(ctx, "console_url", time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(val) == 0 {
+		return nil, fmt.Errorf("console_url not found")
+	}
+	if _, ok := val[0][clusterIDStr]; ok {
+		result := make(map[string]string, len(val))
+		for _, v := range val {
+			result[string(v[clusterIDStr])] = string(v["url"])
+		}
+		return result, nil
+	}
+
+	return map[string]string{
+		defaultStr: string(val[0]["url"]),
+	}, nil
+}
+
+func isAlertSilenced(alert model.LabelSet, silences []models.Alert) bool {
+	// The labels defined in an Alertmanager silence are an intersection of the labels on an alert
+	// If all the common labels by alert and the silence matches we can assume that alert is silenced
+
+	for _, silence := range silences {
+		if silence.Labels == nil {
+			continue
+		}
+
+		// Convert silence labels to model.LabelSet
+		silenceLabels := make(model.LabelSet)
+		for silenceLabel, silenceValue := range silence.Labels {
+			silenceLabels[model.LabelName(silenceLabel)] = model.LabelValue(silenceValue)
+		}
+
+		// Use LabelsIntersectionMatcher to check if silence labels match the alert
+		matcher := common.LabelsIntersectionMatcher{Labels: silenceLabels}
+		match, _ := matcher.Matches(alert)
+		if match {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterIncidents returns the incidents matching the query.
+func filterIncidents(incidents []Incident, q Query) []Incident {
+	incidents = filterIncidentsBySeverity(incidents, q.MinSeverity)
+	return slices.DeleteFunc(incidents, func(inc Incident) bool {
+		if q.Component != "" && !slices.Contains(inc.AffectedComponents, q.Component) {
+			return true
+		}
+		return q.Status != "" && inc.Status != q.Status
+	})
+}
+
+func filterIncidentsBySeverity(incidents []Incident, minSeverity processor.HealthValue) []Incident {
+	filteredList := make([]Incident, 0)
+	for _, inc := range incidents {
+		incSeverity := processor.ParseHealthValue(inc.Severity)
+
+		if incSeverity < minSeverity {
+			continue
+		}
+
+		filteredList = append(filteredList, inc)
+	}
+	return filteredList
+}
@@ -0,0 +1,62 @@
+package incidents
+
+import (
+	"context"
+	"math"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/common"
+	"github.com/openshift/cluster-health-analyzer/pkg/processor"
+)
+
+// RootCause is the signal of the incident ranked by the analyzer as its most
+// likely root cause, based on its start, the rank and layer of its component
+// and the known causal relations with the other signals.
+type RootCause struct {
+	// Name is the alert name, the ClusterOperator condition or the event reason.
+	Name string `json:"name"`
+	// Type is the type of the signal, e.g. "alert".
+	Type      string `json:"type"`
+	Layer     string `json:"layer"`
+	Component string `json:"component"`
+	// Score is between 0 and 1, the higher the more likely the root cause.
+	Score float64 `json:"score"`
+	// Labels identify the signal by its source labels.
+	Labels model.LabelSet `json:"labels"`
+}
+
+// loadRootCauses loads the root causes of the incidents by their group_id from
+// the cluster_health_incident_root_cause metric. When the root cause changed
+// over time, the last one is used.
+func (s *service) loadRootCauses(ctx context.Context, qRange v1.Range) (map[string]*RootCause, error) {
+	rv, err := s.promLoader.LoadVectorRange(ctx, processor.IncidentRootCauseMetric, qRange.Start, qRange.End, qRange.Step)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]*RootCause)
+	last := make(map[string]model.Time)
+	for _, r := range rv {
+		if len(r.Samples) == 0 {
+			continue
+		}
+		sample := r.Samples[len(r.Samples)-1]
+		groupID := string(r.Metric["group_id"])
+		if t, ok := last[groupID]; ok && !sample.Timestamp.After(t) {
+			continue
+		}
+		last[groupID] = sample.Timestamp
+
+		ret[groupID] = &RootCause{
+			Name:      string(r.Metric["root_cause"]),
+			Type:      string(r.Metric["type"]),
+			Layer:     string(r.Metric["layer"]),
+			Component: string(r.Metric["component"]),
+			Score:     math.Round(float64(sample.Value)*100) / 100,
+			Labels:    common.SrcLabels(model.Metric(r.Metric)),
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,51 @@
+package incidents
+
+import (
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/processor"
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+// TimelineEvent is a change of the incident: a severity change, an added
+// or removed alert or an added component.
+type TimelineEvent struct {
+	Time string `json:"time"`
+	// Type is one of "severity_changed", "alert_added", "alert_removed"
+	// or "component_added".
+	Type string `json:"type"`
+	// Severity is the new severity of the incident for the severity changes,
+	// or the severity of the added or removed alert.
+	Severity         string `json:"severity,omitempty"`
+	PreviousSeverity string `json:"previous_severity,omitempty"`
+	Layer            string `json:"layer,omitempty"`
+	Component        string `json:"component,omitempty"`
+	// SignalType is the type of the added or removed signal, e.g. "alert".
+	SignalType string `json:"signal_type,omitempty"`
+	// Alert identifies the added or removed signal by its source labels.
+	Alert model.LabelSet `json:"alert,omitempty"`
+}
+
+// timelinesFromHealthMap reconstructs the timelines of the incidents by their
+// group_id from the history of the cluster_health_components_map metric.
+func timelinesFromHealthMap(rv prom.RangeVector) map[string][]TimelineEvent {
+	ret := make(map[string][]TimelineEvent)
+	for groupID, events := range processor.TimelinesFromHealthMap(rv) {
+		for _, e := range events {
+			te := TimelineEvent{
+				Time:             formatToRFC3339(e.Time.UTC()),
+				Type:             string(e.Type),
+				Severity:         e.Severity,
+				PreviousSeverity: e.PreviousSeverity,
+				Layer:            e.Layer,
+				Component:        e.Component,
+				SignalType:       string(e.SrcType),
+			}
+			if len(e.SrcLabels) > 0 {
+				te.Alert = e.SrcLabels
+			}
+			ret[groupID] = append(ret[groupID], te)
+		}
+	}
+	return ret
+}
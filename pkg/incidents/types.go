@@ -0,0 +1,198 @@
+package incidents
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/processor"
+)
+
+// Incident is a group of related signals, assumed to have the same root cause.
+type Incident struct {
+	GroupId   string `json:"id"`
+	Severity  string `json:"severity"`
+	StartTime string `json:"start_time"`
+	Status    string `json:"status"`
+	EndTime   string `json:"end_time"`
+	Cluster   string `json:"cluster,omitempty"`
+	ClusterID string `json:"cluster_id,omitempty"`
+
+	URL                string              `json:"url_details"`
+	Alerts             []model.LabelSet    `json:"alerts"`
+	AlertsSet          map[string]struct{} `json:"-"`
+	AffectedComponents []string            `json:"affected_components"`
+	ComponentsSet      map[string]struct{} `json:"-"`
+	// UpstreamComponents are the affected components the other affected
+	// components depend on, i.e. where the problem likely originates.
+	UpstreamComponents []string `json:"upstream_components,omitempty"`
+
+	// Signals are the non-alert sources of the incident, e.g. ClusterOperator
+	// conditions or Kubernetes Events.
+	Signals    []model.LabelSet    `json:"signals,omitempty"`
+	SignalsSet map[string]struct{} `json:"-"`
+
+	// Timeline are the changes of the incident ordered by their time,
+	// as recorded by the analyzer.
+	Timeline []TimelineEvent `json:"timeline,omitempty"`
+	// RootCause is the most likely root cause of the incident.
+	RootCause *RootCause `json:"root_cause,omitempty"`
+}
+
+// AddSource adds the source signal to the incident. The alerts are later
+// completed with the data from the ALERTS metric, the other signals
+// are kept as they are, together with their type.
+func (i *Incident) AddSource(srcType string, labels model.LabelSet) {
+	if srcType == "" || srcType == string(processor.Alert) {
+		if i.AlertsSet == nil {
+			i.AlertsSet = make(map[string]struct{})
+		}
+		if _, ok := i.AlertsSet[labels.String()]; !ok {
+			i.AlertsSet[labels.String()] = struct{}{}
+			i.Alerts = append(i.Alerts, labels)
+		}
+		return
+	}
+
+	labels = labels.Clone()
+	labels["type"] = model.LabelValue(srcType)
+	if i.SignalsSet == nil {
+		i.SignalsSet = make(map[string]struct{})
+	}
+	if _, ok := i.SignalsSet[labels.String()]; !ok {
+		i.SignalsSet[labels.String()] = struct{}{}
+		i.Signals = append(i.Signals, labels)
+	}
+}
+
+// UpdateEndTime updates the end time of the incident following
+// the following rules:
+// if the new time is zero then set empty string
+// if the existing time is empty (incident is active) then do nothing
+// if the new time is after existing end time then update it with the
+// new time, otherwise do nothing
+func (i *Incident) UpdateEndTime(endTime time.Time) error {
+	if endTime.IsZero() {
+		i.EndTime = ""
+		return nil
+	}
+
+	if i.EndTime == "" {
+		return nil
+	}
+
+	existingEndTime, err := time.Parse(time.RFC3339, i.EndTime)
+	if err != nil {
+		return fmt.Errorf("failed to parse existing end time: %w", err)
+	}
+
+	if endTime.After(existingEndTime) {
+		i.EndTime = formatToRFC3339(endTime)
+	}
+	return nil
+}
+
+func (i *Incident) UpdateStartTime(startTime time.Time) error {
+	existingStartTime, err := time.Parse(time.RFC3339, i.StartTime)
+	if err != nil {
+		return fmt.Errorf("failed to parse existing start time: %w", err)
+	}
+
+	if startTime.Before(existingStartTime) {
+		i.StartTime = formatToRFC3339(startTime)
+	}
+	return nil
+}
+
+func (i *Incident) UpdateStatus() {
+	if i.EndTime == "" {
+		i.Status = "firing"
+	} else {
+		i.Status = "resolved"
+	}
+}
+
+// IncidentDetails is a single incident with the timeline of its alerts
+// and the status of the affected components.
+type IncidentDetails struct {
+	GroupId   string `json:"id"`
+	Severity  string `json:"severity"`
+	StartTime string `json:"start_time"`
+	Status    string `json:"status"`
+	EndTime   string `json:"end_time"`
+	Cluster   string `json:"cluster,omitempty"`
+	ClusterID string `json:"cluster_id,omitempty"`
+	URL       string `json:"url_details"`
+
+	Alerts             []AlertDetails      `json:"alerts"`
+	Signals            []model.LabelSet    `json:"signals,omitempty"`
+	AffectedComponents []AffectedComponent `json:"affected_components"`
+	// UpstreamComponents are the affected components the other affected
+	// components depend on.
+	UpstreamComponents []string `json:"upstream_components,omitempty"`
+	// ComponentHealth is the status of the related components evaluated
+	// by the health processor, when enabled.
+	ComponentHealth []ComponentHealthStatus `json:"component_health,omitempty"`
+	// Timeline are the changes of the incident ordered by their time.
+	Timeline []TimelineEvent `json:"timeline,omitempty"`
+	// RootCause is the most likely root cause of the incident.
+	RootCause *RootCause `json:"root_cause,omitempty"`
+}
+
+// AlertDetails is an alert of the incident with its firing intervals.
+type AlertDetails struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Severity  string `json:"severity"`
+	Status    string `json:"status"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time,omitempty"`
+	Silenced  bool   `json:"silenced"`
+	// Intervals are the periods the alert was firing. More than one interval
+	// means the alert was flapping.
+	Intervals []FiringInterval `json:"intervals"`
+	Labels    model.LabelSet   `json:"labels"`
+}
+
+// FiringInterval is a period of continuous firing of an alert.
+// The end time is empty while the alert is still firing.
+type FiringInterval struct {
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time,omitempty"`
+}
+
+// AffectedComponent is a component affected by the incident, with its
+// layer, rank and health as exported in the cluster_health_components metric.
+type AffectedComponent struct {
+	Component string `json:"component"`
+	Layer     string `json:"layer,omitempty"`
+	Rank      int    `json:"rank,omitempty"`
+	Healthy   *bool  `json:"healthy,omitempty"`
+}
+
+// ComponentHealthStatus is the status of a component from the component_health metric.
+type ComponentHealthStatus struct {
+	Component string `json:"component"`
+	Status    string `json:"status"`
+}
+
+// Alert is an alert from the ALERTS metric, regardless it's part
+// of an incident, annotated with the component it's mapped to.
+type Alert struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Severity  string `json:"severity"`
+	// State is one of "firing", "pending" or "resolved".
+	State     string `json:"state"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time,omitempty"`
+	Silenced  bool   `json:"silenced"`
+	Layer     string `json:"layer"`
+	Component string `json:"component"`
+	// GroupId is the id of the incident of the alert. It's empty when
+	// the alert was not assigned to any incident (yet).
+	GroupId   string         `json:"group_id,omitempty"`
+	ClusterID string         `json:"cluster_id,omitempty"`
+	Labels    model.LabelSet `json:"labels"`
+}
@@ -0,0 +1,177 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/openshift/cluster-health-analyzer/pkg/incidents"
+)
+
+var (
+	// Format response with instructions for better LLM interpretation
+	getAlertsResponseTemplate = `<DATA>
+%s
+</DATA>
+<INSTRUCTIONS>
+- The alerts are listed regardless they are part of an incident. The group_id is the ID of the incident of the alert, when there is one.
+- Alerts without group_id were not (yet) grouped into an incident, e.g. pending alerts or alerts that started firing recently.
+- Alerts of the "Others" component could not be mapped to any known component. Use their labels to identify the affected part of the cluster.
+- Pending alerts are not firing yet: their condition is met, but not for long enough.
+- The total is the number of all the matching alerts. When next_cursor is present, not all of them were returned: call the tool again with the cursor to get the next page.
+</INSTRUCTIONS>`
+)
+
+const (
+	getAlertsToolName = "get_alerts"
+)
+
+type GetAlertsParams struct {
+	TimeRange uint   `json:"time_range"`
+	State     string `json:"state"`
+	Severity  string `json:"severity"`
+	Namespace string `json:"namespace"`
+	Component string `json:"component"`
+	Silenced  *bool  `json:"silenced,omitempty"`
+	Limit     uint   `json:"limit"`
+	Cursor    string `json:"cursor"`
+}
+
+type AlertsResponse struct {
+	Alerts Alerts `json:"alerts"`
+}
+
+type Alerts struct {
+	Total  int               `json:"total"`
+	Alerts []incidents.Alert `json:"items"`
+	// NextCursor is set when there are more alerts than returned,
+	// it's used as the cursor param to get the next page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+var (
+	defaultMcpGetAlertsTool = mcp.Tool{
+		Name: getAlertsToolName,
+		Description: `List the alerts in the cluster, including the pending alerts and the alerts that are not part of any incident.
+		Each alert is annotated with the component it's mapped to and the ID of its incident, if any.
+		Use this tool when the incidents don't explain a problem, or to find alerts of a specific component or namespace.
+		`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:        "Provides information about Alerts in the cluster",
+			ReadOnlyHint: true,
+		},
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"time_range": {
+					Type:        "number",
+					Default:     json.RawMessage([]byte(strconv.Itoa(defaultTimeRangeHours))),
+					Description: "Maximum age of alerts to include in hours (max 360 for 15 days). Default: 360",
+					Minimum:     jsonschema.Ptr(float64(1)),
+					Maximum:     jsonschema.Ptr(float64(defaultTimeRangeHours)),
+				},
+				"state": {
+					Type:        "string",
+					Enum:        []any{"firing", "pending", "resolved"},
+					Description: "State of the alerts: firing, pending or resolved. Default: all the states.",
+				},
+				"severity": {
+					Type:        "string",
+					Description: "Severity of the alerts, e.g. info, warning or critical. Default: all the severities.",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the alerts. Default: all the namespaces.",
+				},
+				"component": {
+					Type:        "string",
+					Description: "Component the alerts are mapped to, e.g. etcd or Others. Default: all the components.",
+				},
+				"silenced": {
+					Type:        "boolean",
+					Description: "Whether to include only silenced (true) or only not silenced (false) alerts. Default: both.",
+				},
+				"limit": {
+					Type:        "number",
+					Default:     json.RawMessage([]byte(strconv.Itoa(defaultAlertsLimit))),
+					Description: "Maximum number of alerts to return. Less alerts are returned when the response would be too large. Default: 100",
+					Minimum:     jsonschema.Ptr(float64(1)),
+				},
+				"cursor": {
+					Type:        "string",
+					Description: "The next_cursor of the previous response, to get the next page of alerts. The other params must be the same as in the previous call.",
+				},
+			},
+		},
+	}
+)
+
+// AlertsHandler lists the alerts from the in-cluster Prometheus, annotated
+// with their component and incident.
+func (i *IncidentTool) AlertsHandler(ctx context.Context, request *mcp.CallToolRequest, params GetAlertsParams) (*mcp.CallToolResult, any, error) {
+	slog.Info("Alerts tool received request with ", "params", params)
+	svc, err := i.newService(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timeRange := defaultTimeRangeHours
+	if params.TimeRange > 0 {
+		timeRange = int(params.TimeRange)
+	}
+
+	offset, err := decodeCursor(params.Cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list, err := svc.Alerts(ctx, incidents.AlertsQuery{
+		TimeRange: time.Duration(timeRange) * time.Hour,
+		State:     strings.ToLower(params.State),
+		Severity:  params.Severity,
+		Namespace: params.Namespace,
+		Component: params.Component,
+		Silenced:  params.Silenced,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limit := defaultAlertsLimit
+	if params.Limit > 0 {
+		limit = int(params.Limit)
+	}
+	maxBytes := defaultMaxResponseBytes
+	if i.cfg.maxResponseBytes > 0 {
+		maxBytes = i.cfg.maxResponseBytes
+	}
+	page, nextCursor, err := paginate(list, offset, limit, maxBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := json.Marshal(AlertsResponse{
+		Alerts: Alerts{
+			Total:      len(list),
+			Alerts:     page,
+			NextCursor: nextCursor,
+		},
+	})
+	if err != nil {
+		slog.Error("Failed to marshal the Alerts data", "error", err)
+		return nil, nil, err
+	}
+
+	response := fmt.Sprintf(getAlertsResponseTemplate, string(data))
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: response},
+		},
+	}, nil, nil
+}
@@ -0,0 +1,214 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	// ErrUnauthenticated is returned for the missing or invalid tokens.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned when the user isn't allowed to use the MCP server.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// defaultAuthCacheTTL is the time the authentication decisions are cached for.
+const defaultAuthCacheTTL = 30 * time.Second
+
+// Authenticator validates the tokens of the MCP callers.
+type Authenticator interface {
+	// Authenticate returns the name of the user of the token. It returns
+	// an error wrapping ErrUnauthenticated when the token isn't valid and
+	// ErrForbidden when the user isn't allowed to use the MCP server.
+	Authenticate(ctx context.Context, token string) (string, error)
+}
+
+// kubeAuthenticator authenticates the token with a TokenReview and authorizes
+// the user with a SubjectAccessReview against the configured resource attributes.
+type kubeAuthenticator struct {
+	client kubernetes.Interface
+	attrs  authorizationv1.ResourceAttributes
+}
+
+// NewKubeAuthenticator creates the Authenticator using the Kubernetes API.
+// The users must be allowed to perform the attrs to use the MCP server.
+func NewKubeAuthenticator(client kubernetes.Interface, attrs authorizationv1.ResourceAttributes) Authenticator {
+	return &kubeAuthenticator{
+		client: client,
+		attrs:  attrs,
+	}
+}
+
+func (a *kubeAuthenticator) Authenticate(ctx context.Context, token string) (string, error) {
+	if token == "" {
+		return "", fmt.Errorf("%w: missing token", ErrUnauthenticated)
+	}
+
+	tr, err := a.client.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to create the TokenReview: %w", err)
+	}
+	if !tr.Status.Authenticated {
+		return "", fmt.Errorf("%w: %s", ErrUnauthenticated, tr.Status.Error)
+	}
+	user := tr.Status.User
+
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	attrs := a.attrs
+	sar, err := a.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &attrs,
+			User:               user.Username,
+			Groups:             user.Groups,
+			UID:                user.UID,
+			Extra:              extra,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to create the SubjectAccessReview: %w", err)
+	}
+	if !sar.Status.Allowed || sar.Status.Denied {
+		return "", fmt.Errorf("%w: user %q cannot %s %s in the namespace %q",
+			ErrForbidden, user.Username, attrs.Verb, resourceString(attrs), attrs.Namespace)
+	}
+	return user.Username, nil
+}
+
+func resourceString(attrs authorizationv1.ResourceAttributes) string {
+	r := attrs.Resource
+	if attrs.Subresource != "" {
+		r += "/" + attrs.Subresource
+	}
+	if attrs.Group != "" {
+		r += "." + attrs.Group
+	}
+	return r
+}
+
+// cachingAuthenticator caches the decisions of the wrapped Authenticator,
+// so that every call of the MCP server doesn't hit the Kubernetes API.
+// The failures to make the decision, e.g. the API being unavailable,
+// aren't cached.
+type cachingAuthenticator struct {
+	next Authenticator
+	ttl  time.Duration
+	now  func() time.Time
+
+	mtx     sync.Mutex
+	entries map[[sha256.Size]byte]authCacheEntry
+}
+
+type authCacheEntry struct {
+	user    string
+	err     error
+	expires time.Time
+}
+
+// NewCachingAuthenticator wraps the Authenticator with a cache of its decisions
+// for the ttl, defaultAuthCacheTTL is used when not set.
+func NewCachingAuthenticator(next Authenticator, ttl time.Duration) Authenticator {
+	if ttl <= 0 {
+		ttl = defaultAuthCacheTTL
+	}
+	return &cachingAuthenticator{
+		next:    next,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[[sha256.Size]byte]authCacheEntry),
+	}
+}
+
+func (c *cachingAuthenticator) Authenticate(ctx context.Context, token string) (string, error) {
+	// the tokens are not kept in the memory in plain text
+	key := sha256.Sum256([]byte(token))
+	now := c.now()
+
+	c.mtx.Lock()
+	entry, ok := c.entries[key]
+	c.mtx.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.user, entry.err
+	}
+
+	user, err := c.next.Authenticate(ctx, token)
+	if err != nil && !errors.Is(err, ErrUnauthenticated) && !errors.Is(err, ErrForbidden) {
+		return "", err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = authCacheEntry{user: user, err: err, expires: now.Add(c.ttl)}
+	return user, err
+}
+
+// FakeAuthenticator authenticates the tokens against a static map of tokens
+// to users, to be used for testing.
+type FakeAuthenticator struct {
+	// Users maps the valid tokens to the user names.
+	Users map[string]string
+	// Forbidden are the users not allowed to use the MCP server.
+	Forbidden map[string]bool
+}
+
+func (f *FakeAuthenticator) Authenticate(_ context.Context, token string) (string, error) {
+	user, ok := f.Users[token]
+	if !ok {
+		return "", fmt.Errorf("%w: invalid token", ErrUnauthenticated)
+	}
+	if f.Forbidden[user] {
+		return "", fmt.Errorf("%w: user %q is not allowed", ErrForbidden, user)
+	}
+	return user, nil
+}
+
+// authMiddleware enriches the request context with the kubernetes-authorization
+// token of the caller. When the authenticator is set, the caller is
+// authenticated first and the request is refused with 401 Unauthorized
+// or 403 Forbidden.
+func authMiddleware(authenticator Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authCtx := authFromRequest(r.Context(), r)
+		if authenticator != nil {
+			token, _ := authCtx.Value(authHeaderStr).(string)
+			user, err := authenticator.Authenticate(r.Context(), token)
+			switch {
+			case errors.Is(err, ErrUnauthenticated):
+				slog.Info("Refused unauthenticated MCP request", "error", err)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="kubernetes-authorization"`)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			case errors.Is(err, ErrForbidden):
+				slog.Info("Refused unauthorized MCP request", "error", err)
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			case err != nil:
+				slog.Error("Failed to authenticate the MCP request", "error", err)
+				http.Error(w, "failed to authenticate the request", http.StatusInternalServerError)
+				return
+			}
+			slog.Debug("Authenticated MCP request", "user", user)
+		}
+		next.ServeHTTP(w, r.WithContext(authCtx))
+	})
+}
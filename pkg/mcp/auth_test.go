@@ -0,0 +1,158 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestKubeAuthenticator(t *testing.T) {
+	attrs := authorizationv1.ResourceAttributes{
+		Verb:        "get",
+		Group:       "monitoring.coreos.com",
+		Resource:    "prometheuses",
+		Subresource: "api",
+		Namespace:   "openshift-monitoring",
+	}
+
+	client := fake.NewClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		tr := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		switch tr.Spec.Token {
+		case "viewer-token":
+			tr.Status.Authenticated = true
+			tr.Status.User = authenticationv1.UserInfo{Username: "viewer", Groups: []string{"monitoring"}}
+		case "other-token":
+			tr.Status.Authenticated = true
+			tr.Status.User = authenticationv1.UserInfo{Username: "other"}
+		default:
+			tr.Status.Error = "invalid token"
+		}
+		return true, tr, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		assert.Equal(t, attrs, *sar.Spec.ResourceAttributes)
+		sar.Status.Allowed = sar.Spec.User == "viewer" && sar.Spec.Groups[0] == "monitoring"
+		return true, sar, nil
+	})
+
+	authenticator := NewKubeAuthenticator(client, attrs)
+
+	user, err := authenticator.Authenticate(t.Context(), "viewer-token")
+	assert.NoError(t, err)
+	assert.Equal(t, "viewer", user)
+
+	_, err = authenticator.Authenticate(t.Context(), "other-token")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = authenticator.Authenticate(t.Context(), "invalid-token")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+
+	_, err = authenticator.Authenticate(t.Context(), "")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+// countingAuthenticator counts the calls of the wrapped authenticator.
+type countingAuthenticator struct {
+	Authenticator
+	calls int
+	err   error
+}
+
+func (c *countingAuthenticator) Authenticate(ctx context.Context, token string) (string, error) {
+	c.calls++
+	if c.err != nil {
+		return "", c.err
+	}
+	return c.Authenticator.Authenticate(ctx, token)
+}
+
+func TestCachingAuthenticator(t *testing.T) {
+	next := &countingAuthenticator{
+		Authenticator: &FakeAuthenticator{
+			Users:     map[string]string{"token": "user", "forbidden-token": "forbidden"},
+			Forbidden: map[string]bool{"forbidden": true},
+		},
+	}
+	now := time.Now()
+	c := NewCachingAuthenticator(next, time.Minute).(*cachingAuthenticator)
+	c.now = func() time.Time { return now }
+
+	for range 2 {
+		user, err := c.Authenticate(t.Context(), "token")
+		assert.NoError(t, err)
+		assert.Equal(t, "user", user)
+
+		_, err = c.Authenticate(t.Context(), "forbidden-token")
+		assert.ErrorIs(t, err, ErrForbidden)
+
+		_, err = c.Authenticate(t.Context(), "invalid-token")
+		assert.ErrorIs(t, err, ErrUnauthenticated)
+	}
+	// the decisions are cached
+	assert.Equal(t, 3, next.calls)
+
+	// the expired decisions are made again
+	now = now.Add(2 * time.Minute)
+	_, err := c.Authenticate(t.Context(), "token")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, next.calls)
+	assert.Len(t, c.entries, 1)
+
+	// the failures to make the decision aren't cached
+	next.err = errors.New("API unavailable")
+	for range 2 {
+		_, err = c.Authenticate(t.Context(), "new-token")
+		assert.EqualError(t, err, "API unavailable")
+	}
+	assert.Equal(t, 6, next.calls)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	authenticator := &FakeAuthenticator{
+		Users:     map[string]string{"token": "user", "forbidden-token": "forbidden"},
+		Forbidden: map[string]bool{"forbidden": true},
+	}
+	var gotToken any
+	handler := authMiddleware(authenticator, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotToken = r.Context().Value(authHeaderStr)
+	}))
+
+	tests := []struct {
+		name           string
+		header         string
+		expectedStatus int
+		expectedToken  any
+	}{
+		{name: "valid token", header: "Bearer token", expectedStatus: http.StatusOK, expectedToken: "token"},
+		{name: "missing token", expectedStatus: http.StatusUnauthorized},
+		{name: "missing bearer prefix", header: "token", expectedStatus: http.StatusUnauthorized},
+		{name: "invalid token", header: "Bearer invalid", expectedStatus: http.StatusUnauthorized},
+		{name: "forbidden user", header: "Bearer forbidden-token", expectedStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotToken = nil
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(string(authHeaderStr), tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedToken, gotToken)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/openshift/cluster-health-analyzer/pkg/health"
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+var (
+	// Format response with instructions for better LLM interpretation
+	getComponentHealthResponseTemplate = `<DATA>
+%s
+</DATA>
+<INSTRUCTIONS>
+- The data is a tree of the cluster components. The status of a component is the worst status of its children, alerts and objects.
+- To explain why a component is not healthy, follow the children with the same status down to the alerts and unhealthy objects causing it.
+- The unknown status means the health of the component could not be evaluated.
+- If the tree is empty, the components health evaluation is not enabled in the cluster or no component matches the filter.
+</INSTRUCTIONS>`
+)
+
+const (
+	getComponentHealthToolName = "get_component_health"
+)
+
+type ComponentHealthTool struct {
+	Tool mcp.Tool
+	cfg  componentHealthToolCfg
+	// allows to use mocked instance of the Prometheus client for testing
+	getPrometheusLoaderFn func(string, string) (prom.Loader, error)
+}
+
+type componentHealthToolCfg struct {
+	promURL string
+}
+
+type GetComponentHealthParams struct {
+	Component string `json:"component"`
+	MinStatus string `json:"min_status"`
+}
+
+type ComponentHealthResponse struct {
+	Components []*health.ComponentNode `json:"components"`
+}
+
+var (
+	defaultMcpGetComponentHealthTool = mcp.Tool{
+		Name: getComponentHealthToolName,
+		Description: `Get the health tree of the cluster components (e.g. control-plane.operators.etcd).
+		Each component has its health status, the alerts contributing to it and the unhealthy Kubernetes objects.
+		Use this tool to find which part of the cluster is degraded and why.
+		`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:        "Provides the health of the cluster components",
+			ReadOnlyHint: true,
+		},
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"component": {
+					Type:        "string",
+					Description: "Path of the component to return together with its children, e.g. control-plane.operators. Default: all the components.",
+				},
+				"min_status": {
+					Type:        "string",
+					Pattern:     "(?i)(ok|warning|error)$",
+					Description: "Minimum health status of the components to include, together with their parents. Allowed values, from healthier to less healthy, are: ok, warning and error. Default: ok.",
+				},
+			},
+		},
+	}
+)
+
+// NewComponentHealthTool creates a new MCP tool for the components health
+func NewComponentHealthTool(promURL string) ComponentHealthTool {
+	return ComponentHealthTool{
+		Tool: defaultMcpGetComponentHealthTool,
+		cfg: componentHealthToolCfg{
+			promURL: promURL,
+		},
+		getPrometheusLoaderFn: defaultPrometheusLoader,
+	}
+}
+
+// ComponentHealthHandler rebuilds the components health tree from
+// the component health metrics in the in-cluster Prometheus.
+func (c *ComponentHealthTool) ComponentHealthHandler(ctx context.Context, request *mcp.CallToolRequest, params GetComponentHealthParams) (*mcp.CallToolResult, any, error) {
+	slog.Info("Component health tool received request with ", "params", params)
+	filter := health.TreeFilter{Path: strings.TrimSpace(params.Component)}
+	switch strings.ToLower(params.MinStatus) {
+	case "warning":
+		filter.MinStatus = health.Warning
+	case "error":
+		filter.MinStatus = health.Error
+	}
+
+	tree, err := c.loadTree(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := json.Marshal(ComponentHealthResponse{Components: tree})
+	if err != nil {
+		slog.Error("Failed to marshal the components health", "error", err)
+		return nil, nil, err
+	}
+
+	response := fmt.Sprintf(getComponentHealthResponseTemplate, string(data))
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: response},
+		},
+	}, nil, nil
+}
+
+// loadTree loads the components health tree using the Prometheus loader
+// authenticated with the token of the caller.
+func (c *ComponentHealthTool) loadTree(ctx context.Context, filter health.TreeFilter) ([]*health.ComponentNode, error) {
+	token, err := getTokenFromCtx(ctx)
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
+	}
+
+	promLoader, err := c.getPrometheusLoaderFn(c.cfg.promURL, token)
+	if err != nil {
+		slog.Error("Failed to initialize Prometheus client", "error", err)
+		return nil, err
+	}
+
+	tree, err := health.LoadComponentTree(ctx, promLoader, filter)
+	if err != nil {
+		slog.Error("Failed to load the components health", "error", err)
+		return nil, err
+	}
+	if tree == nil {
+		tree = []*health.ComponentNode{}
+	}
+	return tree, nil
+}
@@ -0,0 +1,67 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/openshift/cluster-health-analyzer/pkg/health"
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+	"github.com/openshift/cluster-health-analyzer/pkg/test/mocks"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestComponentHealthTool_ComponentHealthHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	promLoader := mocks.NewMockPrometheusLoader(ctrl)
+	promLoader.EXPECT().LoadQuery(gomock.Any(), health.ComponentHealthMetric, gomock.Any()).Return([]model.LabelSet{
+		{"component": "control-plane", "status": "warning"},
+	}, nil)
+	promLoader.EXPECT().LoadQuery(gomock.Any(), health.ComponentHealthAlertMetric, gomock.Any()).Return([]model.LabelSet{
+		{
+			"component": "control-plane.etcd", "status": "warning",
+			"src_alertname": "etcdMembersDown", "src_namespace": "openshift-etcd", "src_severity": "warning",
+		},
+	}, nil)
+	promLoader.EXPECT().LoadQuery(gomock.Any(), health.ComponentHealthObjectMetric, gomock.Any()).Return([]model.LabelSet{
+		{"component": "control-plane.nodes", "resource": "nodes", "name": "master-0", "result": "OK"},
+	}, nil)
+
+	tool := ComponentHealthTool{
+		Tool: defaultMcpGetComponentHealthTool,
+		getPrometheusLoaderFn: func(url, _ string) (prom.Loader, error) {
+			return promLoader, nil
+		},
+	}
+	ctx := context.WithValue(t.Context(), authHeaderStr, "test")
+	got, _, err := tool.ComponentHealthHandler(ctx, &mcp.CallToolRequest{},
+		GetComponentHealthParams{MinStatus: "Warning"})
+	assert.NoError(t, err)
+
+	expected := ComponentHealthResponse{
+		Components: []*health.ComponentNode{
+			{
+				Name: "control-plane", Path: "control-plane", Status: "warning",
+				Children: []*health.ComponentNode{
+					{
+						Name: "etcd", Path: "control-plane.etcd", Status: "warning",
+						Alerts: []model.LabelSet{
+							{"src_alertname": "etcdMembersDown", "src_namespace": "openshift-etcd", "src_severity": "warning"},
+						},
+					},
+				},
+			},
+		},
+	}
+	data, _ := json.Marshal(expected)
+	assert.Equal(t, &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: fmt.Sprintf(getComponentHealthResponseTemplate, string(data))},
+		},
+	}, got)
+}
@@ -0,0 +1,49 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// LoadToken returns the token used to query Prometheus and Alertmanager
+// when there's no caller providing it, e.g. with the stdio transport.
+//
+// The token is read from the token file, when provided. Otherwise it's taken
+// from the current context of the kubeconfig, following the usual kubectl
+// rules when the path is empty (the KUBECONFIG env variable or ~/.kube/config).
+func LoadToken(tokenFile, kubeconfig string) (string, error) {
+	if tokenFile != "" {
+		return readTokenFile(tokenFile)
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+	// the client-go reads the tokenFile of the kubeconfig into the BearerToken as it is
+	if token := strings.TrimSpace(config.BearerToken); token != "" {
+		return token, nil
+	}
+	if config.BearerTokenFile != "" {
+		return readTokenFile(config.BearerTokenFile)
+	}
+	return "", errors.New("no bearer token found in the current context of the kubeconfig")
+}
+
+func readTokenFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the token file %q: %w", path, err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("the token file %q is empty", path)
+	}
+	return token, nil
+}
@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadToken(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	kubeconfig := func(user string) string {
+		return `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://api.test:6443
+users:
+- name: test
+  user:
+` + user + `
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+`
+	}
+
+	tokenFile := write("token", "file-token\n")
+	emptyFile := write("empty", "\n")
+
+	tests := []struct {
+		name          string
+		tokenFile     string
+		kubeconfig    string
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:      "token file",
+			tokenFile: tokenFile,
+			// the token file takes precedence
+			kubeconfig: write("kubeconfig-token", kubeconfig("    token: kubeconfig-token")),
+			expected:   "file-token",
+		},
+		{
+			name:          "empty token file",
+			tokenFile:     emptyFile,
+			expectedError: true,
+		},
+		{
+			name:       "kubeconfig token",
+			kubeconfig: write("kubeconfig-token", kubeconfig("    token: kubeconfig-token")),
+			expected:   "kubeconfig-token",
+		},
+		{
+			name:       "kubeconfig token file",
+			kubeconfig: write("kubeconfig-token-file", kubeconfig("    tokenFile: "+tokenFile)),
+			expected:   "file-token",
+		},
+		{
+			name:          "kubeconfig without token",
+			kubeconfig:    write("kubeconfig-user", kubeconfig("    username: admin")),
+			expectedError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := LoadToken(tt.tokenFile, tt.kubeconfig)
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, token)
+		})
+	}
+}
+
+func TestMCPHealthServer_StartErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  MCPHealthServerCfg
+	}{
+		{name: "unsupported transport", cfg: MCPHealthServerCfg{Url: ":0", Transport: "sse"}},
+		{name: "empty address", cfg: MCPHealthServerCfg{}},
+		{name: "missing TLS key", cfg: MCPHealthServerCfg{Url: ":0", CertFile: "tls.crt"}},
+		{name: "stdio without token", cfg: MCPHealthServerCfg{Transport: TransportStdio}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, NewMCPHealthServer(tt.cfg).Start(t.Context()))
+		})
+	}
+}
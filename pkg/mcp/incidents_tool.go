@@ -3,52 +3,67 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"maps"
-	"slices"
-	"sort"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/openshift/cluster-health-analyzer/pkg/alertmanager"
-	"github.com/openshift/cluster-health-analyzer/pkg/common"
+	"github.com/openshift/cluster-health-analyzer/pkg/incidents"
 	"github.com/openshift/cluster-health-analyzer/pkg/processor"
 	"github.com/openshift/cluster-health-analyzer/pkg/prom"
-	"github.com/prometheus/alertmanager/api/v2/models"
-	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
-	"github.com/prometheus/common/model"
 )
 
 var (
+	// incidentsInstructions explain the incidents data to the LLM. They're shared
+	// by the get_incidents tool response and the prompts.
+	incidentsInstructions = `- An incident is a group of related alerts. Base your analysis on the alerts to understand the incident. 
+- Don't confuse or mix the concepts of incident and alert during your explanation.
+- For each incident, analyze its alerts to identify the affected components and the core problem. 
+- Besides the alerts, an incident can contain other signals (e.g. ClusterOperator conditions or Kubernetes Warning Events), distinguished by their type. Use them as additional evidence.
+- The timeline of an incident shows how it evolved: severity changes, added and removed alerts and newly affected components, ordered by time. Use it to explain how the incident escalated and which alerts came first.
+- The root_cause of an incident is the signal ranked by the analyzer as its most likely root cause, with a score from 0 to 1. Treat it as a hint and verify it against the alerts and the timeline.
+- The upstream_components of an incident are the affected components the other affected components depend on (e.g. the kube-apiserver depends on etcd). The problem likely originates there.
+- Whenever you print an incident ID, add also a short one-sentence summary of the incident (e.g. "etcd degradation", "ingress failure")
+- If the user asks about a problem you cannot find in the data, do not guess. State that you cannot find the cause and simply list the incidents.
+- The total is the number of all the matching incidents. When next_cursor is present, not all of them were returned: call the tool again with the cursor to get the next page.`
+
 	// Format response with instructions for better LLM interpretation
 	getIncidentsResponseTemplate = `<DATA>
 %s
 </DATA>
 <INSTRUCTIONS>
-- An incident is a group of related alerts. Base your analysis on the alerts to understand the incident. 
-- Don't confuse or mix the concepts of incident and alert during your explanation.
-- For each incident, analyze its alerts to identify the affected components and the core problem. 
-- Whenever you print an incident ID, add also a short one-sentence summary of the incident (e.g. "etcd degradation", "ingress failure")
-- If the user asks about a problem you cannot find in the data, do not guess. State that you cannot find the cause and simply list the incidents.
+` + incidentsInstructions + `
+</INSTRUCTIONS>`
+
+	incidentDetailsInstructions = `- The data describe a single incident, a group of related alerts likely triggered by the same root cause.
+- Each alert has its firing intervals. More than one interval means the alert was flapping.
+- Use the start times of the alerts to reconstruct the timeline of the incident: the earliest alerts are closer to the root cause.
+- Affected components with a lower rank are more fundamental for the cluster. The component_health shows the current status of the related components, when available.
+- Silenced alerts were acknowledged by the cluster administrators, mention them separately.`
+
+	getIncidentDetailsResponseTemplate = `<DATA>
+%s
+</DATA>
+<INSTRUCTIONS>
+` + incidentDetailsInstructions + `
 </INSTRUCTIONS>`
 )
 
 const (
-	getIncidentsToolName  = "get_incidents"
-	defaultTimeRangeHours = 360
-
-	clusterIDStr = "clusterID"
-	defaultStr   = "default"
-	silencedStr  = "silenced"
+	getIncidentsToolName       = "get_incidents"
+	getIncidentDetailsToolName = "get_incident_details"
+	defaultTimeRangeHours      = 360
 )
 
 type IncidentTool struct {
-	Tool mcp.Tool
-	cfg  incidentToolCfg
+	Tool        mcp.Tool
+	DetailsTool mcp.Tool
+	AlertsTool  mcp.Tool
+	cfg         incidentToolCfg
 	// the followings allow to use mocked instance of needed clients for testing
 	getPrometheusLoaderFn   func(string, string) (prom.Loader, error)
 	getAlertManagerLoaderFn func(string, string) (alertmanager.Loader, error)
@@ -57,11 +72,22 @@ type IncidentTool struct {
 type incidentToolCfg struct {
 	promURL         string
 	alertManagerURL string
+	// maxResponseBytes caps the size of the get_incidents and get_alerts responses,
+	// defaultMaxResponseBytes is used when not set
+	maxResponseBytes int
 }
 
 type GetIncidentsParams struct {
-	TimeRange   uint   `json:"time_range"`
-	MinSeverity string `json:"min_severity"`
+	TimeRange     uint   `json:"time_range"`
+	MinSeverity   string `json:"min_severity"`
+	Limit         uint   `json:"limit"`
+	Cursor        string `json:"cursor"`
+	SortBy        string `json:"sort_by"`
+	IncludeAlerts string `json:"include_alerts"`
+}
+
+type GetIncidentDetailsParams struct {
+	ID string `json:"id"`
 }
 
 var (
@@ -80,6 +106,35 @@ var (
 				Pattern:     fmt.Sprintf("(?i)(%s|%s|%s)$", processor.Healthy.String(), processor.Warning.String(), processor.Critical.String()),
 				Description: "Minimum severity level to be applied as filter for incidents. Allowed values, from lower severity to higher severity, can be: info, warning and critical. Default: warning.",
 			},
+			"limit": {
+				Type:        "number",
+				Default:     json.RawMessage([]byte(strconv.Itoa(defaultIncidentsLimit))),
+				Description: "Maximum number of incidents to return. Less incidents are returned when the response would be too large. Default: 50",
+				Minimum:     jsonschema.Ptr(float64(1)),
+			},
+			"cursor": {
+				Type:        "string",
+				Description: "The next_cursor of the previous response, to get the next page of incidents. The other params must be the same as in the previous call.",
+			},
+			"sort_by": {
+				Type:        "string",
+				Enum:        []any{sortBySeverity, sortByStartTime, sortByAlertCount},
+				Default:     json.RawMessage([]byte(strconv.Quote(sortBySeverity))),
+				Description: "Order of the incidents: severity (most severe first), start_time (newest first) or alert_count (most alerts first). Default: severity.",
+			},
+			"include_alerts": {
+				Type:        "string",
+				Enum:        []any{includeAlertsNone, includeAlertsSummary, includeAlertsFull},
+				Default:     json.RawMessage([]byte(strconv.Quote(includeAlertsFull))),
+				Description: "How to include the alerts of the incidents: none, summary (counts by status and severity, alert names) or full. Use summary for an overview of many incidents. Default: full.",
+			},
+		},
+		getIncidentDetailsToolName: {
+			"id": {
+				Type:        "string",
+				Description: "ID of the incident, as returned by the get_incidents tool.",
+				MinLength:   jsonschema.Ptr(1),
+			},
 		},
 	}
 
@@ -97,13 +152,34 @@ var (
 			Type:       "object",
 			Properties: paramsByTool[getIncidentsToolName],
 		},
+		OutputSchema: outputSchemaFor[Response](),
+	}
+
+	defaultMcpGetIncidentDetailsTool = mcp.Tool{
+		Name: getIncidentDetailsToolName,
+		Description: `Get the details of a single incident by its ID.
+		Provides the full timeline of the incident alerts, including their firing (flapping) intervals and silences,
+		and the affected components with their layer, rank and current health status.
+		Use this tool to analyze a specific incident returned by the get_incidents tool.
+		`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:        "Provides details about a single Incident in the cluster",
+			ReadOnlyHint: true,
+		},
+		InputSchema: &jsonschema.Schema{
+			Type:       "object",
+			Properties: paramsByTool[getIncidentDetailsToolName],
+			Required:   []string{"id"},
+		},
 	}
 )
 
 // NewIncidentsTool creates a new MCP tool for the incidents
 func NewIncidentsTool(promURL, alertmanagerURL string) IncidentTool {
 	return IncidentTool{
-		Tool: defaultMcpGetIncidentsTool,
+		Tool:        defaultMcpGetIncidentsTool,
+		DetailsTool: defaultMcpGetIncidentDetailsTool,
+		AlertsTool:  defaultMcpGetAlertsTool,
 		cfg: incidentToolCfg{
 			promURL:         promURL,
 			alertManagerURL: alertmanagerURL,
@@ -115,23 +191,13 @@ func NewIncidentsTool(promURL, alertmanagerURL string) IncidentTool {
 
 // IncidentsHandler is the main handler for the Incidents. It connects to the
 // in-cluster Prometheus and queries the Incidents metrics.
-func (i *IncidentTool) IncidentsHandler(ctx context.Context, request *mcp.CallToolRequest, params GetIncidentsParams) (*mcp.CallToolResult, any, error) {
+//
+// The Response is returned as the structured output of the tool, the text
+// content renders it together with the instructions for the LLM.
+func (i *IncidentTool) IncidentsHandler(ctx context.Context, request *mcp.CallToolRequest, params GetIncidentsParams) (*mcp.CallToolResult, *Response, error) {
 	slog.Info("Incidents tool received request with ", "params", params)
-	token, err := getTokenFromCtx(ctx)
+	svc, err := i.newService(ctx)
 	if err != nil {
-		slog.Error(err.Error())
-		return nil, nil, err
-	}
-
-	amLoader, err := i.getAlertManagerLoaderFn(i.cfg.alertManagerURL, token)
-	if err != nil {
-		slog.Error("Failed to initialize AlertManager client", "error", err)
-		return nil, nil, err
-	}
-
-	promLoader, err := i.getPrometheusLoaderFn(i.cfg.promURL, token)
-	if err != nil {
-		slog.Error("Failed to initialize Prometheus client", "error", err)
 		return nil, nil, err
 	}
 
@@ -143,43 +209,43 @@ func (i *IncidentTool) IncidentsHandler(ctx context.Context, request *mcp.CallTo
 	// the method ParseHealthValue will default to warning in the case of not recognized severity
 	minSeverity := processor.ParseHealthValue(params.MinSeverity)
 
-	timeNow := time.Now()
-	queryTimeRange := v1.Range{
-		Start: timeNow.Add(-time.Duration(timeRange) * time.Hour),
-		End:   timeNow,
-		Step:  300 * time.Second,
-	}
-
-	val, err := promLoader.LoadVectorRange(ctx, processor.ClusterHealthComponentsMap, queryTimeRange.Start, queryTimeRange.End, queryTimeRange.Step)
+	offset, err := decodeCursor(params.Cursor)
 	if err != nil {
-		slog.Error("Received error response from Prometheus", "error", err)
 		return nil, nil, err
 	}
 
-	silences, err := amLoader.SilencedAlerts()
+	list, err := svc.List(ctx, incidents.Query{
+		TimeRange:   time.Duration(timeRange) * time.Hour,
+		MinSeverity: minSeverity,
+	})
 	if err != nil {
-		slog.Error("Failed retrieving silenced alerts from AlertManager", "error", err)
 		return nil, nil, err
 	}
-	clusterIDconsoleURL, err := getConsoleURL(ctx, promLoader)
-	if err != nil {
-		slog.Error("Failed retrieving console URL from metrics", "error", err)
+
+	sortIncidents(list, params.SortBy)
+	items := make([]IncidentItem, 0, len(list))
+	for _, inc := range list {
+		items = append(items, newIncidentItem(inc, params.IncludeAlerts))
+	}
+
+	limit := defaultIncidentsLimit
+	if params.Limit > 0 {
+		limit = int(params.Limit)
 	}
-	incidentsMap, err := i.transformPromValueToIncident(val, queryTimeRange, clusterIDconsoleURL)
+	maxBytes := defaultMaxResponseBytes
+	if i.cfg.maxResponseBytes > 0 {
+		maxBytes = i.cfg.maxResponseBytes
+	}
+	page, nextCursor, err := paginate(items, offset, limit, maxBytes)
 	if err != nil {
-		slog.Error("Failed to transform metric data", "error", err)
 		return nil, nil, err
 	}
 
-	incidents := filterIncidentsBySeverity(
-		getAlertDataForIncidents(ctx, incidentsMap, silences, promLoader, queryTimeRange),
-		minSeverity,
-	)
-
 	r := Response{
 		Incidents: Incidents{
-			Total:     len(incidents),
-			Incidents: incidents,
+			Total:      len(list),
+			Incidents:  page,
+			NextCursor: nextCursor,
 		},
 	}
 
@@ -194,274 +260,76 @@ func (i *IncidentTool) IncidentsHandler(ctx context.Context, request *mcp.CallTo
 		Content: []mcp.Content{
 			&mcp.TextContent{Text: response},
 		},
-	}, nil, nil
+	}, &r, nil
 }
 
-// formatToRFC3339 formats a time to RFC3339 string, returns empty string for zero time
-func formatToRFC3339(t time.Time) string {
-	if t.IsZero() {
-		return ""
-	}
-	return t.Format(time.RFC3339)
-}
-
-// processSampleTime calculates the delta between the two samples and if it's greater
-// than the range step then the endTime is set, otherwise it returns zero endTime
-func processSampleTime(firstSample, lastSample model.SamplePair, qRange v1.Range) (time.Time, time.Time) {
-	startTime := firstSample.Timestamp.Time()
-	var endTime time.Time
-
-	if qRange.End.Sub(lastSample.Timestamp.Time()).Seconds() > qRange.Step.Seconds() {
-		endTime = lastSample.Timestamp.Time()
-	}
-	return startTime, endTime
-}
-
-// transformPromValueToIncident transforms the metrics data to map of incidents
-func (i *IncidentTool) transformPromValueToIncident(dataVec prom.RangeVector,
-	qRange v1.Range,
-	clusterIDConsoleURL map[string]string) (map[string]Incident, error) {
-
-	incidents := make(map[string]Incident, len(dataVec))
-	for _, v := range dataVec {
-
-		alertSeverity := v.Metric["src_severity"]
-		alertName := v.Metric["src_alertname"]
-
-		if alertSeverity == "none" {
-			slog.Debug("Skipping unknown severity ", "alert", alertName, "severity", alertSeverity)
-			continue
-		}
-
-		lastSample := v.Samples[len(v.Samples)-1]
-		firstSample := v.Samples[0]
-		startTime, endTime := processSampleTime(firstSample, lastSample, qRange)
-
-		labels := common.SrcLabels(model.Metric(v.Metric))
-
-		healthyVal := processor.HealthValue(lastSample.Value)
-		groupId := string(v.Metric["group_id"])
-		component := string(v.Metric["component"])
-		clusterName := string(v.Metric["cluster"])
-		clusterID := string(v.Metric[clusterIDStr])
-
-		if existingInc, ok := incidents[groupId]; ok {
-			existingInc.ComponentsSet[component] = struct{}{}
-			existingInc.AffectedComponents = slices.Collect(maps.Keys(existingInc.ComponentsSet))
-			sort.Strings(existingInc.AffectedComponents)
-
-			if _, ok := existingInc.AlertsSet[labels.String()]; !ok {
-				existingInc.AlertsSet[labels.String()] = struct{}{}
-				existingInc.Alerts = append(existingInc.Alerts, labels)
-			}
-
-			if healthyVal > processor.ParseHealthValue(existingInc.Severity) {
-				existingInc.Severity = healthyVal.String()
-			}
-			err := existingInc.UpdateStartTime(startTime)
-			if err != nil {
-				slog.Error("Failed to parse the start time of an incident ", "error", err)
-				continue
-			}
-			err = existingInc.UpdateEndTime(endTime)
-			if err != nil {
-				slog.Error("Failed to parse the end time of an incident ", "error", err)
-				continue
-			}
-			existingInc.UpdateStatus()
-			incidents[existingInc.GroupId] = existingInc
-		} else {
-			incident := Incident{
-				Cluster:   clusterName,
-				ClusterID: clusterID,
-				GroupId:   groupId,
-				Severity:  healthyVal.String(),
-				StartTime: formatToRFC3339(startTime),
-				EndTime:   formatToRFC3339(endTime),
-				ComponentsSet: map[string]struct{}{
-					component: {},
-				},
-				AffectedComponents: []string{component},
-				Alerts:             []model.LabelSet{labels},
-				AlertsSet: map[string]struct{}{
-					labels.String(): {},
-				},
-			}
-			if clusterIDConsoleURL != nil {
-				if clusterID != "" {
-					incident.URL = fmt.Sprintf("%s/monitoring/incidents?groupId=%s", clusterIDConsoleURL[clusterID], groupId)
-				} else {
-					incident.URL = fmt.Sprintf("%s/monitoring/incidents?groupId=%s", clusterIDConsoleURL[defaultStr], groupId)
-				}
-			}
-			incident.UpdateStatus()
-			incidents[groupId] = incident
-		}
+// IncidentDetailsHandler returns the details of a single incident.
+func (i *IncidentTool) IncidentDetailsHandler(ctx context.Context, request *mcp.CallToolRequest, params GetIncidentDetailsParams) (*mcp.CallToolResult, any, error) {
+	slog.Info("Incident details tool received request with ", "params", params)
+	svc, err := i.newService(ctx)
+	if err != nil {
+		return nil, nil, err
 	}
-	return incidents, nil
-}
 
-// getTokenFromCtx gets the authorization header from the
-// provided context
-func getTokenFromCtx(ctx context.Context) (string, error) {
-	k8sToken := ctx.Value(authHeaderStr)
-	k8TokenStr, ok := k8sToken.(string)
-	if !ok {
-		return "", fmt.Errorf("failed to convert the authorization token to string")
+	details, err := svc.Details(ctx, params.ID)
+	if errors.Is(err, incidents.ErrNotFound) {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Incident %q was not found within the last %d hours.", params.ID, defaultTimeRangeHours)},
+			},
+		}, nil, nil
 	}
-	return k8TokenStr, nil
-}
-
-// getAlertDataForIncidents queries Prometheus for firing alerts from the last 15 days (to have
-// some starting time) and then maps (the alert identifier is composed by name and namespace)
-// the active alerts to the provided map of incidents. It returns slice of the incidents.
-func getAlertDataForIncidents(ctx context.Context, incidents map[string]Incident, silences []models.Alert, promAPI prom.Loader, qRange v1.Range) []Incident {
-	alertData, err := promAPI.LoadVectorRange(ctx, `ALERTS{alertstate!="pending"}`, qRange.Start, qRange.End, qRange.Step)
 	if err != nil {
-		slog.Error("Failed to query firing alerts", "error", err)
-		return nil
-	}
-
-	silencedAlertsMap := make(map[string][]models.Alert, len(silences))
-	for _, silencedAlert := range silences {
-		if alertname, ok := silencedAlert.Labels["alertname"]; ok {
-			silencedAlertsMap[alertname] = append(silencedAlertsMap[alertname], silencedAlert)
-		}
+		return nil, nil, err
 	}
 
-	var alerts []model.LabelSet
-	for i := range alertData {
-		sample := alertData[i]
-		metric := model.LabelSet(sample.Metric)
-		firstSample := sample.Samples[0]
-		lastSample := sample.Samples[len(sample.Samples)-1]
-		startTime, endTime := processSampleTime(firstSample, lastSample, qRange)
-
-		metric["start_time"] = model.LabelValue(formatToRFC3339(startTime))
-		if !endTime.IsZero() {
-			metric["end_time"] = model.LabelValue(formatToRFC3339(endTime))
-			metric["alertstate"] = "resolved"
-		} else {
-			metric["alertstate"] = "firing"
-		}
-		alerts = append(alerts, metric)
+	data, err := json.Marshal(details)
+	if err != nil {
+		slog.Error("Failed to marshal the Incident details", "error", err)
+		return nil, nil, err
 	}
 
-	var incidentsSlice []Incident
-	for _, inc := range incidents {
-		updatedAlertsMap := make(map[string]model.LabelSet, len(inc.Alerts))
-
-		for _, alertInIncident := range inc.Alerts {
-			subsetMatcher := common.LabelsSubsetMatcher{Labels: alertInIncident}
-			for _, firingAlert := range alerts {
-				// check for multicluster/ACM environment
-				if inc.ClusterID != "" {
-					clusterIDMatch := string(firingAlert[clusterIDStr]) == inc.ClusterID
-					// if the alert cluster ID does not match incident cluster ID, skip
-					if !clusterIDMatch {
-						continue
-					}
-				}
-				match, _ := subsetMatcher.Matches(firingAlert)
-				if match {
-
-					// the silencedAlertsMap is precomputed in order to contain all the silences grouped by alertname
-					// [Alert1] = [{alertname="Alert1", namespace="foo"}, alertname="Alert1", namespace="bar"]
-					alertname := string(firingAlert["alertname"])
-					namespace := string(firingAlert["namespace"])
-					severity := string(firingAlert["severity"])
-
-					key := fmt.Sprintf("%s|%s|%s", alertname, namespace, severity)
-
-					silenced := false
-					if isAlertSilenced(firingAlert, silencedAlertsMap[alertname]) {
-						silenced = true
-					}
-
-					updatedAlert := cleanupLabels(firingAlert)
-
-					// If multiple alerts shares the same triple (alertname, namespace, severity) within
-					// the same incident, these should be collapsed in a unique row. (same logic applied on server command)
-					// The desired behaviour is to attach `silenced="true"` only if all colliding alerts are silenced, otherwise false.
-					if _, f := updatedAlertsMap[key]; f {
-
-						// if an alert, already labels cleaned, was already registered in the map
-						// we should verify if it was marked as silenced
-						lastSilenced, err := strconv.ParseBool(string(updatedAlertsMap[key][silencedStr]))
-						if err != nil {
-							slog.Error("failed to parse bool", "error", err)
-							return nil
-						}
-						// the && operator allow us to get the following behaviour
-						// if all are silenced the ending property will be true
-						// if even just one is not silenced the ending property will be false
-						updatedAlert[silencedStr] = model.LabelValue(fmt.Sprintf("%t", lastSilenced && silenced))
-						updatedAlertsMap[key] = updatedAlert
-					} else {
-						updatedAlert[silencedStr] = model.LabelValue(fmt.Sprintf("%t", silenced))
-						updatedAlertsMap[key] = updatedAlert
-					}
-				}
-			}
-		}
-
-		inc.Alerts = slices.Collect(maps.Values(updatedAlertsMap))
-
-		// sorting introduced to resolve unit tests flakyness
-		slices.SortFunc(inc.Alerts, func(ls1, ls2 model.LabelSet) int {
-			return strings.Compare(string(ls1["start_time"]), string(ls2["start_time"]))
-		})
-
-		incidentsSlice = append(incidentsSlice, inc)
-	}
-	return incidentsSlice
+	response := fmt.Sprintf(getIncidentDetailsResponseTemplate, string(data))
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: response},
+		},
+	}, nil, nil
 }
 
-// cleanupLabels removes and renames some of the
-// labels from the set and returns new LabelSet
-func cleanupLabels(m model.LabelSet) model.LabelSet {
-	updatedLS := m.Clone()
-	updatedLS["status"] = updatedLS["alertstate"]
-	updatedLS["name"] = updatedLS["alertname"]
-	if cID := updatedLS[clusterIDStr]; cID != "" {
-		updatedLS["cluster_id"] = cID
+// newService creates the incidents service using the loaders authenticated
+// with the token of the caller.
+func (i *IncidentTool) newService(ctx context.Context) (incidents.Service, error) {
+	token, err := getTokenFromCtx(ctx)
+	if err != nil {
+		slog.Error(err.Error())
+		return nil, err
 	}
-	delete(updatedLS, "__name__")
-	delete(updatedLS, "prometheus")
-	delete(updatedLS, "alertstate")
-	delete(updatedLS, "alertname")
-	delete(updatedLS, "pod")
-	delete(updatedLS, clusterIDStr)
-	return updatedLS
-}
 
-// getConsoleURL queries the "console_url" metric from the Prometheus.
-// If there is no metric value, it returns nil and error.
-// If the "clusterID" label exists in the metric, it returns a map of cluster ID to
-// console URL mappin.
-// If the "clusterID" label doesn't exist in the metric, it returns a map
-// with one key-value pair with "default" key and
-// the console URL.
-func getConsoleURL(ctx context.Context, prom prom.Loader) (map[string]string, error) {
-	val, err := prom.LoadQuery(ctx, "console_url", time.Now())
+	amLoader, err := i.getAlertManagerLoaderFn(i.cfg.alertManagerURL, token)
 	if err != nil {
+		slog.Error("Failed to initialize AlertManager client", "error", err)
 		return nil, err
 	}
 
-	if len(val) == 0 {
-		return nil, fmt.Errorf("console_url not found")
-	}
-	if _, ok := val[0][clusterIDStr]; ok {
-		result := make(map[string]string, len(val))
-		for _, v := range val {
-			result[string(v[clusterIDStr])] = string(v["url"])
-		}
-		return result, nil
+	promLoader, err := i.getPrometheusLoaderFn(i.cfg.promURL, token)
+	if err != nil {
+		slog.Error("Failed to initialize Prometheus client", "error", err)
+		return nil, err
 	}
+	return incidents.NewService(promLoader, amLoader), nil
+}
 
-	return map[string]string{
-		defaultStr: string(val[0]["url"]),
-	}, nil
+// getTokenFromCtx gets the authorization header from the
+// provided context
+func getTokenFromCtx(ctx context.Context) (string, error) {
+	k8sToken := ctx.Value(authHeaderStr)
+	k8TokenStr, ok := k8sToken.(string)
+	if !ok {
+		return "", fmt.Errorf("failed to convert the authorization token to string")
+	}
+	return k8TokenStr, nil
 }
 
 func defaultPrometheusLoader(promURL, token string) (prom.Loader, error) {
@@ -474,43 +342,3 @@ func defaultAlertManagerLoader(alertManagerURL, token string) (alertmanager.Load
 		Token:           token,
 	})
 }
-
-func isAlertSilenced(alert model.LabelSet, silences []models.Alert) bool {
-	// The labels defined in an Alertmanager silence are an intersection of the labels on an alert
-	// If all the common labels by alert and the silence matches we can assume that alert is silenced
-
-	for _, silence := range silences {
-		if silence.Labels == nil {
-			continue
-		}
-
-		// Convert silence labels to model.LabelSet
-		silenceLabels := make(model.LabelSet)
-		for silenceLabel, silenceValue := range silence.Labels {
-			silenceLabels[model.LabelName(silenceLabel)] = model.LabelValue(silenceValue)
-		}
-
-		// Use LabelsIntersectionMatcher to check if silence labels match the alert
-		matcher := common.LabelsIntersectionMatcher{Labels: silenceLabels}
-		match, _ := matcher.Matches(alert)
-		if match {
-			return true
-		}
-	}
-
-	return false
-}
-
-func filterIncidentsBySeverity(incidents []Incident, minSeverity processor.HealthValue) []Incident {
-	filteredList := make([]Incident, 0)
-	for _, inc := range incidents {
-		incSeverity := processor.ParseHealthValue(inc.Severity)
-
-		if incSeverity < minSeverity {
-			continue
-		}
-
-		filteredList = append(filteredList, inc)
-	}
-	return filteredList
-}
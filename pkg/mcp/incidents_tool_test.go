@@ -4,17 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/openshift/cluster-health-analyzer/pkg/alertmanager"
+	"github.com/openshift/cluster-health-analyzer/pkg/incidents"
 	"github.com/openshift/cluster-health-analyzer/pkg/processor"
 	"github.com/openshift/cluster-health-analyzer/pkg/prom"
 	"github.com/openshift/cluster-health-analyzer/pkg/test/mocks"
 	"github.com/prometheus/alertmanager/api/v2/models"
-	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
@@ -120,6 +120,8 @@ func TestIncidentTool_IncidentsHandler(t *testing.T) {
 					},
 				}, nil)
 
+				mocked.EXPECT().LoadVectorRange(gomock.Any(), processor.IncidentRootCauseMetric,
+					gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
 				mocked.EXPECT().LoadQuery(gomock.Any(), `console_url`, gomock.Any()).Return(
 					[]model.LabelSet{
 						{model.LabelName("url"): model.LabelValue("test.url")},
@@ -161,14 +163,17 @@ func TestIncidentTool_IncidentsHandler(t *testing.T) {
 				r := Response{
 					Incidents: Incidents{
 						Total: 1,
-						Incidents: []Incident{
-							{
-								GroupId:            "123",
-								Severity:           "warning",
-								Status:             "firing",
-								StartTime:          baseTime.Add(-1 * time.Minute).Time().Format(time.RFC3339),
-								AffectedComponents: []string{""},
-								URL:                "test.url/monitoring/incidents?groupId=123",
+						Incidents: []IncidentItem{
+							{
+								Incident: incidents.Incident{
+									GroupId:            "123",
+									Severity:           "warning",
+									Status:             "firing",
+									StartTime:          baseTime.Add(-1 * time.Minute).Time().Format(time.RFC3339),
+									AffectedComponents: []string{""},
+									URL:                "test.url/monitoring/incidents?groupId=123",
+									Timeline:           healthMapTimeline(baseTime.Add(-1 * time.Minute)),
+								},
 								Alerts: []model.LabelSet{
 									{
 										"name":       "UpdateAvailable",
@@ -298,6 +303,8 @@ func TestIncidentTool_IncidentsHandler(t *testing.T) {
 					},
 				}, nil)
 
+				mocked.EXPECT().LoadVectorRange(gomock.Any(), processor.IncidentRootCauseMetric,
+					gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
 				mocked.EXPECT().LoadQuery(gomock.Any(), `console_url`, gomock.Any()).Return(
 					[]model.LabelSet{
 						{model.LabelName("url"): model.LabelValue("test.url")},
@@ -339,14 +346,17 @@ func TestIncidentTool_IncidentsHandler(t *testing.T) {
 				r := Response{
 					Incidents: Incidents{
 						Total: 1,
-						Incidents: []Incident{
-							{
-								GroupId:            "123",
-								Severity:           "warning",
-								Status:             "firing",
-								StartTime:          baseTime.Add(-1 * time.Minute).Time().Format(time.RFC3339),
-								AffectedComponents: []string{""},
-								URL:                "test.url/monitoring/incidents?groupId=123",
+						Incidents: []IncidentItem{
+							{
+								Incident: incidents.Incident{
+									GroupId:            "123",
+									Severity:           "warning",
+									Status:             "firing",
+									StartTime:          baseTime.Add(-1 * time.Minute).Time().Format(time.RFC3339),
+									AffectedComponents: []string{""},
+									URL:                "test.url/monitoring/incidents?groupId=123",
+									Timeline:           healthMapTimeline(baseTime.Add(-1 * time.Minute)),
+								},
 								Alerts: []model.LabelSet{
 									{
 										"name":       "UpdateAvailable",
@@ -402,858 +412,247 @@ func TestIncidentTool_IncidentsHandler(t *testing.T) {
 
 }
 
-func TestTransformPromValueToIncident(t *testing.T) {
-	tests := []struct {
-		name              string
-		testInput         prom.RangeVector
-		expectedIncidents map[string]Incident
-	}{
-		{
-			name: "Two alerts with same group_id are one incident",
-			testInput: prom.RangeVector{
-				{
-					Metric: model.LabelSet{
-						"src_alertname": "Alert1",
-						"group_id":      "1",
-						"src_severity":  "warning",
-						"component":     "monitoring",
-						"src_namespace": "openshift-monitoring",
-					},
-					Samples: []model.SamplePair{
-						{
-							Value:     0,
-							Timestamp: model.Now().Add(-1 * time.Minute),
-						},
-					},
-				},
-				{
-					Metric: model.LabelSet{
-						"src_alertname": "Alert2",
-						"group_id":      "1",
-						"src_severity":  "warning",
-						"component":     "console",
-						"src_namespace": "openshift-console",
-					},
-					Samples: []model.SamplePair{
-						{
-							Value:     0,
-							Timestamp: model.Now().Add(-1 * time.Minute),
-						},
-					},
-				},
-			},
-			expectedIncidents: map[string]Incident{
-				"1": {
-					GroupId:            "1",
-					Severity:           processor.Healthy.String(),
-					Status:             "firing",
-					StartTime:          time.Now().Add(-1 * time.Minute).Format(time.RFC3339),
-					AffectedComponents: []string{"console", "monitoring"},
-					ComponentsSet:      map[string]struct{}{"monitoring": {}, "console": {}},
-					Alerts: []model.LabelSet{
-						{"alertname": "Alert1", "namespace": "openshift-monitoring", "severity": "warning"},
-						{"alertname": "Alert2", "namespace": "openshift-console", "severity": "warning"},
-					},
-					AlertsSet: map[string]struct{}{
-						"{alertname=\"Alert2\", namespace=\"openshift-console\", severity=\"warning\"}":    {},
-						"{alertname=\"Alert1\", namespace=\"openshift-monitoring\", severity=\"warning\"}": {},
-					},
-				},
-			},
+// healthMapTimeline is the timeline of the incident reconstructed from
+// the health map of the IncidentsHandler tests.
+func healthMapTimeline(at model.Time) []incidents.TimelineEvent {
+	t := at.Time().UTC().Format(time.RFC3339)
+	return []incidents.TimelineEvent{
+		{Time: t, Type: "component_added"},
+		{Time: t, Type: "alert_added", Severity: "warning",
+			Alert: model.LabelSet{"alertname": "ClusterOperatorDown", "namespace": "openshift-monitoring", "severity": "warning"}},
+		{Time: t, Type: "alert_added", Severity: "warning",
+			Alert: model.LabelSet{"alertname": "UpdateAvailable", "namespace": "openshift-monitoring", "severity": "info"}},
+	}
+}
+
+func TestIncidentTool_IncidentDetailsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	promLoader := mocks.NewMockPrometheusLoader(ctrl)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), processor.ClusterHealthComponentsMap,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{}, nil)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), `ALERTS{alertstate!="pending"}`,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{}, nil)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), processor.IncidentRootCauseMetric,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+	promLoader.EXPECT().LoadQuery(gomock.Any(), "console_url", gomock.Any()).Return(nil, nil)
+
+	amLoader := mocks.NewMockAlertManagerLoader(ctrl)
+	amLoader.EXPECT().SilencedAlerts().Return(nil, nil)
+
+	tool := IncidentTool{
+		DetailsTool: defaultMcpGetIncidentDetailsTool,
+		getPrometheusLoaderFn: func(url, _ string) (prom.Loader, error) {
+			return promLoader, nil
 		},
-		{
-			name: "Two alerts with same group_id and same component are one incident",
-			testInput: prom.RangeVector{
-				{
-					Metric: model.LabelSet{
-						"src_alertname": "Alert1",
-						"group_id":      "1",
-						"src_severity":  "warning",
-						"component":     "monitoring",
-						"src_namespace": "openshift-monitoring",
-					},
-					Samples: []model.SamplePair{
-						{
-							Value:     1,
-							Timestamp: model.Now().Add(-1 * time.Minute),
-						},
-					},
-				},
-				{
-					Metric: model.LabelSet{
-						"src_alertname": "Alert2",
-						"group_id":      "1",
-						"src_severity":  "warning",
-						"component":     "monitoring",
-						"src_namespace": "openshift-monitoring",
-					},
-					Samples: []model.SamplePair{
-						{
-							Value:     0,
-							Timestamp: model.Now().Add(-1 * time.Minute),
-						},
-					},
-				},
-			},
-			expectedIncidents: map[string]Incident{
-				"1": {
-					GroupId:            "1",
-					Severity:           processor.Warning.String(),
-					Status:             "firing",
-					StartTime:          time.Now().Add(-1 * time.Minute).Format(time.RFC3339),
-					AffectedComponents: []string{"monitoring"},
-					ComponentsSet:      map[string]struct{}{"monitoring": {}},
-					Alerts: []model.LabelSet{
-						{"alertname": "Alert1", "namespace": "openshift-monitoring", "severity": "warning"},
-						{"alertname": "Alert2", "namespace": "openshift-monitoring", "severity": "warning"},
-					},
-					AlertsSet: map[string]struct{}{
-						"{alertname=\"Alert1\", namespace=\"openshift-monitoring\", severity=\"warning\"}": {},
-						"{alertname=\"Alert2\", namespace=\"openshift-monitoring\", severity=\"warning\"}": {},
-					},
-				},
-			},
-		},
-		{
-			name: "Two different incidents and alert with severity=None is ignored",
-			testInput: prom.RangeVector{
-				{
-					Metric: model.LabelSet{
-						"src_alertname": "Alert2",
-						"group_id":      "1",
-						"src_severity":  "warning",
-						"component":     "console",
-						"src_namespace": "openshift-console",
-					},
-					Samples: []model.SamplePair{
-						{
-							Value:     1,
-							Timestamp: model.Now().Add(-25 * time.Minute),
-						},
-					},
-				},
-				{
-					Metric: model.LabelSet{
-						"src_alertname": "Alert3",
-						"group_id":      "2",
-						"src_severity":  "none",
-						"component":     "none",
-					},
-					Samples: []model.SamplePair{
-						{
-							Value:     0,
-							Timestamp: model.Now().Add(-1 * time.Minute),
-						},
-					},
-				},
-				{
-					Metric: model.LabelSet{
-						"src_alertname": "Alert1",
-						"group_id":      "1",
-						"src_severity":  "critical",
-						"component":     "monitoring",
-						"src_namespace": "openshift-monitoring",
-					},
-					Samples: []model.SamplePair{
-						{
-							Value:     2,
-							Timestamp: model.Now().Add(-25 * time.Minute),
-						},
-						{
-							Value:     2,
-							Timestamp: model.Now().Add(-11 * time.Minute),
-						},
-					},
-				},
-				{
-					Metric: model.LabelSet{
-						"src_alertname": "Alert4",
-						"group_id":      "2",
-						"src_severity":  "warning",
-						"component":     "console",
-						"src_namespace": "openshift-console",
-					},
-					Samples: []model.SamplePair{
-						{
-							Value:     1,
-							Timestamp: model.Now().Add(-15 * time.Minute),
-						},
-					},
-				},
-			},
-			expectedIncidents: map[string]Incident{
-				"1": {
-					GroupId:            "1",
-					Severity:           "critical",
-					Status:             "resolved",
-					StartTime:          time.Now().Add(-25 * time.Minute).Format(time.RFC3339),
-					EndTime:            time.Now().Add(-11 * time.Minute).Format(time.RFC3339),
-					AffectedComponents: []string{"console", "monitoring"},
-					ComponentsSet:      map[string]struct{}{"monitoring": {}, "console": {}},
-					Alerts: []model.LabelSet{
-						{"alertname": "Alert2", "namespace": "openshift-console", "severity": "warning"},
-						{"alertname": "Alert1", "namespace": "openshift-monitoring", "severity": "critical"},
-					},
-					AlertsSet: map[string]struct{}{
-						"{alertname=\"Alert2\", namespace=\"openshift-console\", severity=\"warning\"}":     {},
-						"{alertname=\"Alert1\", namespace=\"openshift-monitoring\", severity=\"critical\"}": {},
-					},
-				},
-				"2": {
-					GroupId:            "2",
-					Severity:           "warning",
-					Status:             "resolved",
-					StartTime:          time.Now().Add(-15 * time.Minute).Format(time.RFC3339),
-					EndTime:            time.Now().Add(-15 * time.Minute).Format(time.RFC3339),
-					AffectedComponents: []string{"console"},
-					ComponentsSet:      map[string]struct{}{"console": {}},
-					Alerts: []model.LabelSet{
-						{"alertname": "Alert4", "namespace": "openshift-console", "severity": "warning"},
-					},
-					AlertsSet: map[string]struct{}{
-						"{alertname=\"Alert4\", namespace=\"openshift-console\", severity=\"warning\"}": {},
-					},
-				},
-			},
+		getAlertManagerLoaderFn: func(url, token string) (alertmanager.Loader, error) {
+			return amLoader, nil
 		},
 	}
+	ctx := context.WithValue(t.Context(), authHeaderStr, "test")
+	got, _, err := tool.IncidentDetailsHandler(ctx, &mcp.CallToolRequest{}, GetIncidentDetailsParams{ID: "missing"})
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			testTool := IncidentTool{}
-			incidents, err := testTool.transformPromValueToIncident(tt.testInput, v1.Range{
-				Start: time.Now().Add(-30 * time.Minute),
-				End:   time.Now(),
-				Step:  300 * time.Second,
-			}, nil)
-			assert.NoError(t, err)
-			assert.Equal(t, tt.expectedIncidents, incidents)
-		})
-	}
+	assert.NoError(t, err)
+	assert.True(t, got.IsError)
+	assert.Equal(t, []mcp.Content{
+		&mcp.TextContent{Text: `Incident "missing" was not found within the last 360 hours.`},
+	}, got.Content)
 }
 
-func TestGetAlertDataForIncidents(t *testing.T) {
+func TestIncidentTool_IncidentsHandler_StructuredOutput(t *testing.T) {
 	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
 
-	tests := []struct {
-		name              string
-		promLoader        prom.Loader
-		incidentsMap      map[string]Incident
-		silencedAlerts    []models.Alert
-		expectedIncidents []Incident
-	}{
-		{
-			name: "Same alerts in different namespace are matched correctly",
-			promLoader: func() prom.Loader {
-				mocked := mocks.NewMockPrometheusLoader(ctrl)
-				mocked.EXPECT().LoadVectorRange(gomock.Any(), `ALERTS{alertstate!="pending"}`, gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{
-					{
-						Metric: model.LabelSet{
-							"alertname":  "Alert1",
-							"namespace":  "foo",
-							"alertstate": "firing",
-						},
-						Samples: []model.SamplePair{
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-25 * time.Minute),
-							},
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-1 * time.Minute),
-							},
-						},
-					},
-					{
-						Metric: model.LabelSet{
-							"alertname":  "Alert1",
-							"namespace":  "bar",
-							"alertstate": "firing",
-						},
-						Samples: []model.SamplePair{
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-24 * time.Minute),
-							},
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-1 * time.Minute),
-							},
-						},
-					},
-				}, nil)
-				return mocked
-			}(),
-			silencedAlerts: []models.Alert{
-				{
-					Labels: map[string]string{
-						"alertname": "Alert1",
-						"namespace": "foo",
-					},
-				},
-			},
-			incidentsMap: map[string]Incident{
-				"1": {
-					GroupId: "1",
-					Alerts: []model.LabelSet{
-						{"alertname": "Alert1", "namespace": "foo"},
-						{"alertname": "Alert1", "namespace": "bar"},
-					},
-				},
-			},
-			expectedIncidents: []Incident{
-				{
-					GroupId: "1",
-					Alerts: []model.LabelSet{
-						{
-							"name":       "Alert1",
-							"namespace":  "foo",
-							"status":     "firing",
-							"silenced":   "true",
-							"start_time": model.LabelValue(model.Now().Add(-25 * time.Minute).Time().Format(time.RFC3339)),
-						},
-						{
-							"name":       "Alert1",
-							"namespace":  "bar",
-							"status":     "firing",
-							"silenced":   "false",
-							"start_time": model.LabelValue(model.Now().Add(-24 * time.Minute).Time().Format(time.RFC3339)),
-						},
-					},
-				},
-			},
-		},
+	promLoader := mocks.NewMockPrometheusLoader(ctrl)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), processor.ClusterHealthComponentsMap,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{
 		{
-			name: "Same alert in more incidents",
-			promLoader: func() prom.Loader {
-				mocked := mocks.NewMockPrometheusLoader(ctrl)
-				mocked.EXPECT().LoadVectorRange(gomock.Any(), `ALERTS{alertstate!="pending"}`, gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{
-					{
-						Metric: model.LabelSet{
-							"alertname":  "Alert1",
-							"namespace":  "foo",
-							"alertstate": "resolved",
-						},
-						Samples: []model.SamplePair{
-							{
-								Timestamp: model.Now().Add(-20 * time.Minute),
-							},
-						},
-					},
-					{
-						Metric: model.LabelSet{
-							"alertname":  "Alert1",
-							"namespace":  "bar",
-							"alertstate": "resolved",
-						},
-						Samples: []model.SamplePair{
-							{
-								Timestamp: model.Now().Add(-19 * time.Minute),
-							},
-						},
-					},
-					{
-						Metric: model.LabelSet{
-							"alertname":  "Alert2",
-							"namespace":  "bar",
-							"alertstate": "resolved",
-						},
-						Samples: []model.SamplePair{
-							{
-								Timestamp: model.Now().Add(-19 * time.Minute),
-							},
-						},
-					},
-				}, nil)
-				return mocked
-			}(),
-			incidentsMap: map[string]Incident{
-				"1": {
-					GroupId: "1",
-					Alerts: []model.LabelSet{
-						{"alertname": "Alert1", "namespace": "foo"},
-						{"alertname": "Alert1", "namespace": "bar"},
-					},
-				},
-				"2": {
-					GroupId: "2",
-					Alerts: []model.LabelSet{
-						{"alertname": "Alert1", "namespace": "foo"},
-						{"alertname": "Alert2", "namespace": "bar"},
-					},
-				},
-			},
-			silencedAlerts: []models.Alert{
-				{
-					Labels: map[string]string{
-						"alertname": "Alert1",
-						"namespace": "foo",
-					},
-				},
-			},
-			expectedIncidents: []Incident{
-				{
-					GroupId: "1",
-					Alerts: []model.LabelSet{
-						{
-							"name":       "Alert1",
-							"namespace":  "foo",
-							"status":     "resolved",
-							"silenced":   "true",
-							"start_time": model.LabelValue(model.Now().Add(-20 * time.Minute).Time().Format(time.RFC3339)),
-							"end_time":   model.LabelValue(model.Now().Add(-20 * time.Minute).Time().Format(time.RFC3339)),
-						},
-						{
-							"name":       "Alert1",
-							"namespace":  "bar",
-							"status":     "resolved",
-							"silenced":   "false",
-							"start_time": model.LabelValue(model.Now().Add(-19 * time.Minute).Time().Format(time.RFC3339)),
-							"end_time":   model.LabelValue(model.Now().Add(-19 * time.Minute).Time().Format(time.RFC3339)),
-						},
-					},
-				},
-				{
-					GroupId: "2",
-					Alerts: []model.LabelSet{
-						{
-							"name":       "Alert1",
-							"namespace":  "foo",
-							"status":     "resolved",
-							"silenced":   "true",
-							"start_time": model.LabelValue(model.Now().Add(-20 * time.Minute).Time().Format(time.RFC3339)),
-							"end_time":   model.LabelValue(model.Now().Add(-20 * time.Minute).Time().Format(time.RFC3339)),
-						},
-						{
-							"name":       "Alert2",
-							"namespace":  "bar",
-							"status":     "resolved",
-							"silenced":   "false",
-							"start_time": model.LabelValue(model.Now().Add(-19 * time.Minute).Time().Format(time.RFC3339)),
-							"end_time":   model.LabelValue(model.Now().Add(-19 * time.Minute).Time().Format(time.RFC3339)),
-						},
-					},
-				},
+			Metric: model.LabelSet{
+				"group_id":      "123",
+				"component":     "monitoring",
+				"src_alertname": "ClusterOperatorDown",
+				"src_namespace": "openshift-monitoring",
+				"src_severity":  "warning",
 			},
+			Samples: []model.SamplePair{{Value: 1, Timestamp: model.Now().Add(-1 * time.Minute)}},
 		},
-		{
-			name: "Alerts are correctly marked as silenced",
-			// three alerts with the same name
-			// A. Alert1, namespace=foo, pod=red
-			// B. Alert1, namespace=foo, pod=blue (same alertname and namespace with A. but differend pod name)
-			// C. Alert1, namespace=bar, pod=red (same alertname and pod name with A. but different namespace)
-			promLoader: func() prom.Loader {
-				mocked := mocks.NewMockPrometheusLoader(ctrl)
-				mocked.EXPECT().LoadVectorRange(gomock.Any(), `ALERTS{alertstate!="pending"}`, gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{
-					{
-						Metric: model.LabelSet{
-							"alertname":  "Alert1",
-							"namespace":  "foo",
-							"pod":        "red",
-							"alertstate": "firing",
-							"severity":   "warning",
-						},
-						Samples: []model.SamplePair{
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-20 * time.Minute),
-							},
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-1 * time.Minute),
-							},
-						},
-					},
-					{
-						Metric: model.LabelSet{
-							"alertname":  "Alert1",
-							"namespace":  "foo",
-							"pod":        "blue",
-							"alertstate": "firing",
-							"severity":   "warning",
-						},
-						Samples: []model.SamplePair{
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-20 * time.Minute),
-							},
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-1 * time.Minute),
-							},
-						},
-					},
-					{
-						Metric: model.LabelSet{
-							"alertname":  "Alert1",
-							"namespace":  "bar",
-							"pod":        "red",
-							"alertstate": "firing",
-							"severity":   "warning",
-						},
-						Samples: []model.SamplePair{
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-20 * time.Minute),
-							},
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-1 * time.Minute),
-							},
-						},
-					},
-					{
-						Metric: model.LabelSet{
-							"alertname":  "Alert1",
-							"namespace":  "bar",
-							"pod":        "green",
-							"alertstate": "firing",
-							"severity":   "warning",
-						},
-						Samples: []model.SamplePair{
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-20 * time.Minute),
-							},
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-1 * time.Minute),
-							},
-						},
-					},
-				}, nil)
-				return mocked
-			}(),
-			incidentsMap: map[string]Incident{
-				"1": {
-					GroupId: "1",
-					Alerts: []model.LabelSet{
-						{"alertname": "Alert1", "namespace": "foo", "severity": "warning"},
-						{"alertname": "Alert1", "namespace": "bar", "severity": "warning"},
-					},
-				},
-			},
-			silencedAlerts: []models.Alert{
-				{
-					Labels: map[string]string{
-						"alertname": "Alert1",
-						"namespace": "foo",
-						"severity":  "warning",
-						"pod":       "red",
-					},
-				},
-				{
-					Labels: map[string]string{
-						"alertname": "Alert1",
-						"namespace": "bar",
-						"severity":  "warning",
-						"pod":       "red",
-					},
-				},
-				{
-					Labels: map[string]string{
-						"alertname": "Alert1",
-						"namespace": "bar",
-						"severity":  "warning",
-						"pod":       "green",
-					},
-				},
-			},
-			expectedIncidents: []Incident{
-				{
-					GroupId: "1",
-					Alerts: []model.LabelSet{
-						{
-							"name":       "Alert1",
-							"namespace":  "foo",
-							"status":     "firing",
-							"silenced":   "false",
-							"severity":   "warning",
-							"start_time": model.LabelValue(model.Now().Add(-20 * time.Minute).Time().Format(time.RFC3339)),
-						},
-						{
-							"name":       "Alert1",
-							"namespace":  "bar",
-							"status":     "firing",
-							"silenced":   "true",
-							"severity":   "warning",
-							"start_time": model.LabelValue(model.Now().Add(-20 * time.Minute).Time().Format(time.RFC3339)),
-						},
-					},
-				},
-			},
+	}, nil)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), `ALERTS{alertstate!="pending"}`,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{}, nil)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), processor.IncidentRootCauseMetric,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+	promLoader.EXPECT().LoadQuery(gomock.Any(), "console_url", gomock.Any()).Return(nil, nil)
+
+	amLoader := mocks.NewMockAlertManagerLoader(ctrl)
+	amLoader.EXPECT().SilencedAlerts().Return(nil, nil)
+
+	tool := IncidentTool{
+		Tool: defaultMcpGetIncidentsTool,
+		getPrometheusLoaderFn: func(url, _ string) (prom.Loader, error) {
+			return promLoader, nil
 		},
-		{
-			name: "Alerts are correctly matched in multicluster environment",
-			promLoader: func() prom.Loader {
-				mocked := mocks.NewMockPrometheusLoader(ctrl)
-				mocked.EXPECT().LoadVectorRange(gomock.Any(), `ALERTS{alertstate!="pending"}`, gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{
-					{
-						Metric: model.LabelSet{
-							"alertname":  "Alert1",
-							"namespace":  "foo",
-							"pod":        "red",
-							"alertstate": "firing",
-							"severity":   "warning",
-							"clusterID":  "1111",
-						},
-						Samples: []model.SamplePair{
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-20 * time.Minute),
-							},
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-1 * time.Minute),
-							},
-						},
-					},
-					{
-						Metric: model.LabelSet{
-							"alertname":  "Alert1",
-							"namespace":  "foo",
-							"pod":        "red",
-							"alertstate": "firing",
-							"severity":   "warning",
-							"clusterID":  "2222",
-						},
-						Samples: []model.SamplePair{
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-20 * time.Minute),
-							},
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-1 * time.Minute),
-							},
-						},
-					},
-					{
-						Metric: model.LabelSet{
-							"alertname":  "Alert1",
-							"namespace":  "bar",
-							"pod":        "blue",
-							"alertstate": "firing",
-							"severity":   "critical",
-							"clusterID":  "1111",
-						},
-						Samples: []model.SamplePair{
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-20 * time.Minute),
-							},
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-1 * time.Minute),
-							},
-						},
-					},
-					{
-						Metric: model.LabelSet{
-							"alertname":  "Alert1",
-							"namespace":  "bar",
-							"pod":        "blue",
-							"alertstate": "firing",
-							"severity":   "critical",
-							"clusterID":  "2222",
-						},
-						Samples: []model.SamplePair{
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-20 * time.Minute),
-							},
-							{
-								Value:     1,
-								Timestamp: model.Now().Add(-1 * time.Minute),
-							},
-						},
-					},
-				}, nil)
-				return mocked
-			}(),
-			incidentsMap: map[string]Incident{
-				"1": {
-					GroupId:   "1",
-					ClusterID: "1111",
-					Alerts: []model.LabelSet{
-						{"alertname": "Alert1", "namespace": "foo", "severity": "warning"},
-						{"alertname": "Alert1", "namespace": "bar", "severity": "critical"},
-					},
-				},
-				"2": {
-					GroupId:   "2",
-					ClusterID: "2222",
-					Alerts: []model.LabelSet{
-						{"alertname": "Alert1", "namespace": "foo", "severity": "warning"},
-						{"alertname": "Alert1", "namespace": "bar", "severity": "critical"},
-					},
-				},
-			},
-			silencedAlerts: []models.Alert{},
-			expectedIncidents: []Incident{
-				{
-					GroupId:   "1",
-					ClusterID: "1111",
-					Alerts: []model.LabelSet{
-						{
-							"name":       "Alert1",
-							"namespace":  "foo",
-							"status":     "firing",
-							"silenced":   "false",
-							"severity":   "warning",
-							"cluster_id": "1111",
-							"start_time": model.LabelValue(model.Now().Add(-20 * time.Minute).Time().Format(time.RFC3339)),
-						},
-						{
-							"name":       "Alert1",
-							"namespace":  "bar",
-							"status":     "firing",
-							"silenced":   "false",
-							"severity":   "critical",
-							"cluster_id": "1111",
-							"start_time": model.LabelValue(model.Now().Add(-20 * time.Minute).Time().Format(time.RFC3339)),
-						},
-					},
-				},
-				{
-					GroupId:   "2",
-					ClusterID: "2222",
-					Alerts: []model.LabelSet{
-						{
-							"name":       "Alert1",
-							"namespace":  "foo",
-							"status":     "firing",
-							"silenced":   "false",
-							"severity":   "warning",
-							"cluster_id": "2222",
-							"start_time": model.LabelValue(model.Now().Add(-20 * time.Minute).Time().Format(time.RFC3339)),
-						},
-						{
-							"name":       "Alert1",
-							"namespace":  "bar",
-							"status":     "firing",
-							"silenced":   "false",
-							"severity":   "critical",
-							"cluster_id": "2222",
-							"start_time": model.LabelValue(model.Now().Add(-20 * time.Minute).Time().Format(time.RFC3339)),
-						},
-					},
-				},
-			},
+		getAlertManagerLoaderFn: func(url, token string) (alertmanager.Loader, error) {
+			return amLoader, nil
 		},
 	}
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	mcp.AddTool(server, &tool.Tool, mcp.ToolHandlerFor[GetIncidentsParams, *Response](tool.IncidentsHandler))
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			ctx := context.Background()
-			incidents := getAlertDataForIncidents(ctx, tt.incidentsMap, tt.silencedAlerts, tt.promLoader, v1.Range{
-				Start: time.Now().Add(-30 * time.Minute),
-				End:   time.Now(),
-				Step:  300 * time.Second,
-			})
+	ctx := context.WithValue(t.Context(), authHeaderStr, "test")
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	_, err := server.Connect(ctx, serverTransport, nil)
+	assert.NoError(t, err)
+	client := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	assert.NoError(t, err)
+	defer session.Close()
 
-			// Sort the actual and expected alerts slices before comparing to avoid test flakyness
-			for i := range incidents {
-				sortAlerts(incidents[i].Alerts)
-			}
+	tools, err := session.ListTools(ctx, nil)
+	assert.NoError(t, err)
+	assert.Len(t, tools.Tools, 1)
+	assert.NotNil(t, tools.Tools[0].OutputSchema)
 
-			for i := range tt.expectedIncidents {
-				sortAlerts(tt.expectedIncidents[i].Alerts)
-			}
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{Name: getIncidentsToolName})
+	assert.NoError(t, err)
+	assert.False(t, res.IsError)
 
-			assert.ElementsMatch(t, tt.expectedIncidents, incidents)
-		})
-	}
+	data, err := json.Marshal(res.StructuredContent)
+	assert.NoError(t, err)
+	var got Response
+	assert.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, 1, got.Incidents.Total)
+	assert.Equal(t, "123", got.Incidents.Incidents[0].GroupId)
+	assert.Equal(t, []string{"monitoring"}, got.Incidents.Incidents[0].AffectedComponents)
+
+	// the text rendering for the LLM is kept as the fallback
+	assert.Len(t, res.Content, 1)
+	text, ok := res.Content[0].(*mcp.TextContent)
+	assert.True(t, ok)
+	assert.Contains(t, text.Text, "<INSTRUCTIONS>")
 }
 
-func TestGetConsoleURL(t *testing.T) {
+func TestIncidentTool_IncidentsHandler_SummaryWithoutAlerts(t *testing.T) {
 	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-	tests := []struct {
-		name           string
-		promLoader     prom.Loader
-		expectedResult map[string]string
-		expectedErr    error
-	}{
-		{
-			name: "console url not found in metrics",
-			promLoader: func() prom.Loader {
-				mockPromLoader := mocks.NewMockPrometheusLoader(ctrl)
-				mockPromLoader.EXPECT().LoadQuery(t.Context(), "console_url", gomock.Any()).Return(
-					[]model.LabelSet{}, nil)
-				return mockPromLoader
-			}(),
-			expectedErr:    fmt.Errorf("console_url not found"),
-			expectedResult: nil,
-		},
+
+	promLoader := mocks.NewMockPrometheusLoader(ctrl)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), processor.ClusterHealthComponentsMap,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{
 		{
-			name: "console url metric has clusterID label",
-			promLoader: func() prom.Loader {
-				mockPromLoader := mocks.NewMockPrometheusLoader(ctrl)
-				mockPromLoader.EXPECT().LoadQuery(t.Context(), "console_url", gomock.Any()).Return(
-					[]model.LabelSet{
-						{
-							model.LabelName("url"):        model.LabelValue("test-a.url"),
-							model.LabelName(clusterIDStr): model.LabelValue("A"),
-						},
-						{
-							model.LabelName("url"):        model.LabelValue("test-b.url"),
-							model.LabelName(clusterIDStr): model.LabelValue("B"),
-						},
-					}, nil)
-				return mockPromLoader
-			}(),
-			expectedResult: map[string]string{
-				"A": "test-a.url",
-				"B": "test-b.url",
+			Metric: model.LabelSet{
+				"group_id":      "123",
+				"component":     "monitoring",
+				"type":          model.LabelValue(processor.ClusterOperatorCondition),
+				"src_name":      "monitoring",
+				"src_condition": "Degraded",
+				"src_severity":  "warning",
 			},
-			expectedErr: nil,
+			Samples: []model.SamplePair{{Value: 1, Timestamp: model.Now().Add(-1 * time.Minute)}},
 		},
-		{
-			name: "console url metric has no clusterID label",
-			promLoader: func() prom.Loader {
-				mockPromLoader := mocks.NewMockPrometheusLoader(ctrl)
-				mockPromLoader.EXPECT().LoadQuery(t.Context(), "console_url", gomock.Any()).Return(
-					[]model.LabelSet{
-						{
-							model.LabelName("url"): model.LabelValue("test.url"),
-						},
-					}, nil)
-				return mockPromLoader
-			}(),
-			expectedResult: map[string]string{
-				defaultStr: "test.url",
-			},
-			expectedErr: nil,
+	}, nil)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), `ALERTS{alertstate!="pending"}`,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{}, nil)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), processor.IncidentRootCauseMetric,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil)
+	promLoader.EXPECT().LoadQuery(gomock.Any(), "console_url", gomock.Any()).Return(nil, nil)
+
+	amLoader := mocks.NewMockAlertManagerLoader(ctrl)
+	amLoader.EXPECT().SilencedAlerts().Return(nil, nil)
+
+	tool := IncidentTool{
+		Tool: defaultMcpGetIncidentsTool,
+		getPrometheusLoaderFn: func(url, _ string) (prom.Loader, error) {
+			return promLoader, nil
+		},
+		getAlertManagerLoaderFn: func(url, token string) (alertmanager.Loader, error) {
+			return amLoader, nil
 		},
 	}
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	mcp.AddTool(server, &tool.Tool, mcp.ToolHandlerFor[GetIncidentsParams, *Response](tool.IncidentsHandler))
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			r, err := getConsoleURL(t.Context(), tt.promLoader)
-			assert.Equal(t, tt.expectedErr, err)
-			assert.Equal(t, tt.expectedResult, r)
-		})
+	ctx := context.WithValue(t.Context(), authHeaderStr, "test")
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	_, err := server.Connect(ctx, serverTransport, nil)
+	assert.NoError(t, err)
+	client := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	assert.NoError(t, err)
+	defer session.Close()
+
+	// the output is validated against the output schema by the server
+	res, err := session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      getIncidentsToolName,
+		Arguments: map[string]any{"include_alerts": includeAlertsSummary},
+	})
+	assert.NoError(t, err)
+	assert.False(t, res.IsError)
+
+	data, err := json.Marshal(res.StructuredContent)
+	assert.NoError(t, err)
+	var got Response
+	assert.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, 1, got.Incidents.Total)
+	if !assert.Len(t, got.Incidents.Incidents, 1) {
+		return
 	}
+	assert.Len(t, got.Incidents.Incidents[0].Signals, 1)
+	assert.Equal(t, &AlertsSummary{
+		BySeverity: map[string]int{},
+		Names:      []string{},
+	}, got.Incidents.Incidents[0].AlertsSummary)
 }
 
-func sortAlerts(alerts []model.LabelSet) {
-	sort.Slice(alerts, func(i, j int) bool {
-		a := alerts[i]
-		b := alerts[j]
+func TestIncidentTool_AlertsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
 
-		// First, sort by 'name'
-		if a["name"] != b["name"] {
-			return a["name"] < b["name"]
-		}
+	now := model.Now()
+	var alerts prom.RangeVector
+	for i, name := range []string{"AlertA", "AlertB", "AlertC"} {
+		alerts = append(alerts, prom.Range{
+			Metric: model.LabelSet{
+				"alertname": model.LabelValue(name), "namespace": "my-app",
+				"severity": "warning", "alertstate": "firing",
+			},
+			Samples: []model.SamplePair{{Value: 1, Timestamp: now.Add(time.Duration(i-3) * time.Minute)}, {Value: 1, Timestamp: now}},
+		})
+	}
+	promLoader := mocks.NewMockPrometheusLoader(ctrl)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), "ALERTS",
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(alerts, nil).Times(2)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), processor.ClusterHealthComponentsMap,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{}, nil).Times(2)
 
-		// Then, sort by 'namespace' if names are the same
-		if a["namespace"] != b["namespace"] {
-			return a["namespace"] < b["namespace"]
-		}
+	amLoader := mocks.NewMockAlertManagerLoader(ctrl)
+	amLoader.EXPECT().SilencedAlerts().Return(nil, nil).Times(2)
 
-		// Finally, sort by 'pod' or another unique label to guarantee stability
-		return a["pod"] < b["pod"]
-	})
+	tool := IncidentTool{
+		AlertsTool: defaultMcpGetAlertsTool,
+		getPrometheusLoaderFn: func(url, _ string) (prom.Loader, error) {
+			return promLoader, nil
+		},
+		getAlertManagerLoaderFn: func(url, token string) (alertmanager.Loader, error) {
+			return amLoader, nil
+		},
+	}
+	ctx := context.WithValue(t.Context(), authHeaderStr, "test")
+	call := func(params GetAlertsParams) Alerts {
+		res, _, err := tool.AlertsHandler(ctx, &mcp.CallToolRequest{}, params)
+		assert.NoError(t, err)
+		text := res.Content[0].(*mcp.TextContent).Text
+		data := text[len("<DATA>\n"):strings.Index(text, "\n</DATA>")]
+		var got AlertsResponse
+		assert.NoError(t, json.Unmarshal([]byte(data), &got))
+		return got.Alerts
+	}
+
+	page := call(GetAlertsParams{Limit: 2})
+	assert.Equal(t, 3, page.Total)
+	assert.Len(t, page.Alerts, 2)
+	assert.Equal(t, "AlertA", page.Alerts[0].Name)
+	assert.NotEmpty(t, page.NextCursor)
+
+	page = call(GetAlertsParams{Limit: 2, Cursor: page.NextCursor})
+	assert.Equal(t, 3, page.Total)
+	assert.Len(t, page.Alerts, 1)
+	assert.Equal(t, "AlertC", page.Alerts[0].Name)
+	assert.Empty(t, page.NextCursor)
 }
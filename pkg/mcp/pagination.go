@@ -0,0 +1,176 @@
+package mcp
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/incidents"
+	"github.com/openshift/cluster-health-analyzer/pkg/processor"
+)
+
+const (
+	sortBySeverity   = "severity"
+	sortByStartTime  = "start_time"
+	sortByAlertCount = "alert_count"
+
+	includeAlertsNone    = "none"
+	includeAlertsSummary = "summary"
+	includeAlertsFull    = "full"
+
+	defaultIncidentsLimit = 50
+	defaultAlertsLimit    = 100
+
+	// defaultMaxResponseBytes caps the size of the incidents in a single
+	// response, roughly 25k tokens, so that it fits the context of the model.
+	defaultMaxResponseBytes = 100 * 1024
+
+	cursorPrefix = "offset:"
+)
+
+// sortIncidents sorts the incidents by the given field, in descending order.
+// The ties are broken by the incident ID, so that the order is stable
+// between the calls and the cursors remain valid.
+func sortIncidents(list []incidents.Incident, sortBy string) {
+	bySeverity := func(a, b incidents.Incident) int {
+		return cmp.Compare(processor.ParseHealthValue(b.Severity), processor.ParseHealthValue(a.Severity))
+	}
+	byStartTime := func(a, b incidents.Incident) int {
+		return parseTime(b.StartTime).Compare(parseTime(a.StartTime))
+	}
+	byAlertCount := func(a, b incidents.Incident) int {
+		return cmp.Compare(len(b.Alerts), len(a.Alerts))
+	}
+
+	var order []func(a, b incidents.Incident) int
+	switch sortBy {
+	case sortByStartTime:
+		order = append(order, byStartTime, bySeverity)
+	case sortByAlertCount:
+		order = append(order, byAlertCount, bySeverity)
+	default:
+		order = append(order, bySeverity, byStartTime)
+	}
+
+	slices.SortStableFunc(list, func(a, b incidents.Incident) int {
+		for _, f := range order {
+			if c := f(a, b); c != 0 {
+				return c
+			}
+		}
+		return strings.Compare(a.GroupId, b.GroupId)
+	})
+}
+
+// newIncidentItem converts the incident to the response item, with the alerts
+// included according to the include_alerts mode.
+func newIncidentItem(inc incidents.Incident, includeAlerts string) IncidentItem {
+	// the nil slices are marshaled as null, which the output schema rejects
+	if inc.AffectedComponents == nil {
+		inc.AffectedComponents = []string{}
+	}
+	item := IncidentItem{Incident: inc}
+	switch includeAlerts {
+	case includeAlertsNone:
+	case includeAlertsSummary:
+		item.AlertsSummary = summarizeAlerts(inc)
+	default:
+		item.Alerts = inc.Alerts
+	}
+	return item
+}
+
+// summarizeAlerts counts the alerts of the incident by their status and severity.
+// The incidents without alerts, e.g. with only the non-alert signals, have
+// the empty summary.
+func summarizeAlerts(inc incidents.Incident) *AlertsSummary {
+	s := &AlertsSummary{
+		Total:      len(inc.Alerts),
+		BySeverity: make(map[string]int),
+		Names:      []string{},
+	}
+	names := make(map[string]struct{})
+	for _, a := range inc.Alerts {
+		if a["status"] == "firing" {
+			s.Firing++
+		}
+		if a["silenced"] == "true" {
+			s.Silenced++
+		}
+		if sev := string(a["severity"]); sev != "" {
+			s.BySeverity[sev]++
+		}
+		names[string(a["name"])] = struct{}{}
+	}
+	s.Names = slices.AppendSeq(s.Names, maps.Keys(names))
+	slices.Sort(s.Names)
+	return s
+}
+
+// paginate returns the page of the items starting at the offset with at most
+// limit items and maxBytes of marshaled data. At least one item is returned
+// when available, regardless its size. The returned cursor points to the
+// next page and is empty when there are no more items.
+func paginate[T any](items []T, offset, limit, maxBytes int) ([]T, string, error) {
+	if offset >= len(items) {
+		return []T{}, "", nil
+	}
+
+	end := min(offset+limit, len(items))
+	size := 0
+	for i := offset; i < end; i++ {
+		data, err := json.Marshal(items[i])
+		if err != nil {
+			return nil, "", err
+		}
+		size += len(data)
+		if size > maxBytes && i > offset {
+			end = i
+			break
+		}
+	}
+
+	cursor := ""
+	if end < len(items) {
+		cursor = encodeCursor(end)
+	}
+	return items[offset:end], cursor, nil
+}
+
+// parseTime parses the RFC3339 time of the incident, the zero time is
+// returned for the invalid values.
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+// decodeCursor returns the offset encoded in the cursor. The empty cursor
+// is the first page.
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	v, ok := strings.CutPrefix(string(data), cursorPrefix)
+	if !ok {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	offset, err := strconv.Atoi(v)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return offset, nil
+}
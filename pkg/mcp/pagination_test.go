@@ -0,0 +1,169 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/incidents"
+)
+
+func TestSortIncidents(t *testing.T) {
+	list := func() []incidents.Incident {
+		return []incidents.Incident{
+			{GroupId: "a", Severity: "warning", StartTime: "2025-01-01T10:00:00Z", Alerts: make([]model.LabelSet, 3)},
+			{GroupId: "b", Severity: "critical", StartTime: "2025-01-01T08:00:00Z", Alerts: make([]model.LabelSet, 1)},
+			{GroupId: "c", Severity: "info", StartTime: "2025-01-01T12:00:00Z", Alerts: make([]model.LabelSet, 2)},
+			{GroupId: "d", Severity: "warning", StartTime: "2025-01-01T10:00:00Z", Alerts: make([]model.LabelSet, 3)},
+		}
+	}
+
+	tests := []struct {
+		sortBy   string
+		expected []string
+	}{
+		{sortBy: "", expected: []string{"b", "a", "d", "c"}},
+		{sortBy: sortBySeverity, expected: []string{"b", "a", "d", "c"}},
+		{sortBy: sortByStartTime, expected: []string{"c", "a", "d", "b"}},
+		{sortBy: sortByAlertCount, expected: []string{"a", "d", "c", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			l := list()
+			sortIncidents(l, tt.sortBy)
+			var ids []string
+			for _, inc := range l {
+				ids = append(ids, inc.GroupId)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestNewIncidentItem(t *testing.T) {
+	inc := incidents.Incident{
+		GroupId: "123",
+		Alerts: []model.LabelSet{
+			{"name": "KubePodCrashLooping", "severity": "warning", "status": "firing", "silenced": "false"},
+			{"name": "KubePodCrashLooping", "severity": "warning", "status": "resolved", "silenced": "true"},
+			{"name": "KubeNodeNotReady", "severity": "critical", "status": "firing", "silenced": "false"},
+		},
+	}
+
+	full := newIncidentItem(inc, includeAlertsFull)
+	assert.Equal(t, inc.Alerts, full.Alerts)
+	assert.Nil(t, full.AlertsSummary)
+
+	none := newIncidentItem(inc, includeAlertsNone)
+	assert.Nil(t, none.Alerts)
+	assert.Nil(t, none.AlertsSummary)
+	data, err := json.Marshal(none)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), `"alerts"`)
+
+	summary := newIncidentItem(inc, includeAlertsSummary)
+	assert.Nil(t, summary.Alerts)
+	assert.Equal(t, &AlertsSummary{
+		Total:      3,
+		Firing:     2,
+		Silenced:   1,
+		BySeverity: map[string]int{"warning": 2, "critical": 1},
+		Names:      []string{"KubeNodeNotReady", "KubePodCrashLooping"},
+	}, summary.AlertsSummary)
+}
+
+func TestPaginate(t *testing.T) {
+	var items []IncidentItem
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		items = append(items, IncidentItem{Incident: incidents.Incident{GroupId: id}})
+	}
+	itemSize := func() int {
+		data, _ := json.Marshal(items[0])
+		return len(data)
+	}()
+
+	ids := func(page []IncidentItem) []string {
+		ret := []string{}
+		for _, i := range page {
+			ret = append(ret, i.GroupId)
+		}
+		return ret
+	}
+
+	tests := []struct {
+		name           string
+		offset         int
+		limit          int
+		maxBytes       int
+		expected       []string
+		expectedCursor string
+	}{
+		{
+			name:     "all items",
+			limit:    10,
+			maxBytes: defaultMaxResponseBytes,
+			expected: []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			name:           "limit",
+			limit:          2,
+			maxBytes:       defaultMaxResponseBytes,
+			expected:       []string{"a", "b"},
+			expectedCursor: encodeCursor(2),
+		},
+		{
+			name:     "last page",
+			offset:   4,
+			limit:    2,
+			maxBytes: defaultMaxResponseBytes,
+			expected: []string{"e"},
+		},
+		{
+			name:           "truncated by size",
+			offset:         1,
+			limit:          10,
+			maxBytes:       3 * itemSize,
+			expected:       []string{"b", "c", "d"},
+			expectedCursor: encodeCursor(4),
+		},
+		{
+			name:           "at least one item",
+			limit:          10,
+			maxBytes:       1,
+			expected:       []string{"a"},
+			expectedCursor: encodeCursor(1),
+		},
+		{
+			name:     "offset out of range",
+			offset:   10,
+			limit:    10,
+			maxBytes: defaultMaxResponseBytes,
+			expected: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, cursor, err := paginate(items, tt.offset, tt.limit, tt.maxBytes)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, ids(page))
+			assert.Equal(t, tt.expectedCursor, cursor)
+		})
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	offset, err := decodeCursor("")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, offset)
+
+	offset, err = decodeCursor(encodeCursor(42))
+	assert.NoError(t, err)
+	assert.Equal(t, 42, offset)
+
+	for _, c := range []string{"not-base64!", encodeCursor(-1), "b2Zmc2V0OmZvbw"} {
+		_, err = decodeCursor(c)
+		assert.Error(t, err, c)
+	}
+}
@@ -0,0 +1,96 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	summarizeClusterHealthPromptName = "summarize_cluster_health"
+	rootCauseIncidentPromptName      = "root_cause_incident"
+)
+
+var (
+	summarizeClusterHealthPromptTemplate = `Summarize the current health of the cluster.
+Use the ` + getIncidentsToolName + ` tool to list the incidents%s and the ` + getComponentHealthToolName + ` tool
+with the min_status "warning" to find the degraded components.
+Start with the most severe firing incidents, then mention the resolved ones briefly.
+<INSTRUCTIONS>
+` + incidentsInstructions + `
+</INSTRUCTIONS>`
+
+	rootCauseIncidentPromptTemplate = `Find the root cause of the incident %s.
+Use the ` + getIncidentDetailsToolName + ` tool to get the details of the incident. When the incident
+alerts are not conclusive, use the ` + getAlertsToolName + ` tool to look for related alerts in the same
+namespaces that are not part of any incident.
+Explain the most likely root cause, the evidence supporting it and suggest the next steps to verify it.
+<INSTRUCTIONS>
+` + incidentDetailsInstructions + `
+</INSTRUCTIONS>`
+
+	summarizeClusterHealthPrompt = mcp.Prompt{
+		Name:        summarizeClusterHealthPromptName,
+		Title:       "Summarize cluster health",
+		Description: "Summarize the cluster health based on the incidents and the components health.",
+		Arguments: []*mcp.PromptArgument{
+			{
+				Name:        "time_range",
+				Description: "Maximum age of incidents to include in hours (max 360 for 15 days). Default: 360",
+			},
+		},
+	}
+
+	rootCauseIncidentPrompt = mcp.Prompt{
+		Name:        rootCauseIncidentPromptName,
+		Title:       "Root-cause incident",
+		Description: "Analyze a single incident to find its root cause.",
+		Arguments: []*mcp.PromptArgument{
+			{
+				Name:        "id",
+				Description: "ID of the incident, as returned by the get_incidents tool.",
+				Required:    true,
+			},
+		},
+	}
+)
+
+// SummarizeClusterHealthHandler returns the prompt to summarize the cluster health.
+// The time_range is validated the same way as the get_incidents param.
+func SummarizeClusterHealthHandler(ctx context.Context, request *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	timeRange := ""
+	if v := strings.TrimSpace(request.Params.Arguments["time_range"]); v != "" {
+		hours, err := strconv.Atoi(v)
+		if err != nil || hours < 1 || hours > defaultTimeRangeHours {
+			return nil, fmt.Errorf("invalid argument time_range: must be a number of hours between 1 and %d", defaultTimeRangeHours)
+		}
+		timeRange = fmt.Sprintf(" from the last %d hours", hours)
+	}
+	return promptResult(summarizeClusterHealthPrompt.Description,
+		fmt.Sprintf(summarizeClusterHealthPromptTemplate, timeRange)), nil
+}
+
+// RootCauseIncidentHandler returns the prompt to find the root cause of the incident.
+func RootCauseIncidentHandler(ctx context.Context, request *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	id := strings.TrimSpace(request.Params.Arguments["id"])
+	if id == "" {
+		return nil, fmt.Errorf("missing required argument: id")
+	}
+	return promptResult(rootCauseIncidentPrompt.Description,
+		fmt.Sprintf(rootCauseIncidentPromptTemplate, id)), nil
+}
+
+func promptResult(description, text string) *mcp.GetPromptResult {
+	return &mcp.GetPromptResult{
+		Description: description,
+		Messages: []*mcp.PromptMessage{
+			{
+				Role:    "user",
+				Content: &mcp.TextContent{Text: text},
+			},
+		},
+	}
+}
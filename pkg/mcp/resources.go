@@ -0,0 +1,332 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/openshift/cluster-health-analyzer/pkg/health"
+	"github.com/openshift/cluster-health-analyzer/pkg/incidents"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+const (
+	incidentURIPrefix  = "incident://"
+	componentURIPrefix = "component://"
+
+	jsonMIMEType = "application/json"
+
+	// defaultResourcesPollInterval is the interval between checking
+	// the subscribed resources for changes.
+	defaultResourcesPollInterval = time.Minute
+)
+
+var (
+	incidentResourceTemplate = mcp.ResourceTemplate{
+		Name:        "incident",
+		Title:       "Incident",
+		URITemplate: incidentURIPrefix + "{id}",
+		Description: "Details of a single incident, with the timeline of its alerts and the affected components.",
+		MIMEType:    jsonMIMEType,
+	}
+
+	componentResourceTemplate = mcp.ResourceTemplate{
+		Name:        "component",
+		Title:       "Component health",
+		URITemplate: componentURIPrefix + "{path}",
+		Description: "Health of a component and its children, e.g. component://control-plane.operators.",
+		MIMEType:    jsonMIMEType,
+	}
+)
+
+// healthResources provides the incidents and components as MCP resources.
+//
+// The resources are listed dynamically with the credentials of the caller.
+// The subscribed resources are checked periodically for each session, using
+// the credentials of the session, and the subscribers are notified when their
+// status changes. The subscriptions are dropped when the session closes
+// or when its credentials are refused, e.g. when the token expired.
+type healthResources struct {
+	server     *mcp.Server
+	incidents  *IncidentTool
+	components *ComponentHealthTool
+	interval   time.Duration
+
+	mtx sync.Mutex
+	// subscriptions are the subscribed resources by their URI, per session
+	subscriptions map[*mcp.ServerSession]map[string]*subscription
+}
+
+// subscription holds the last seen state of a subscribed resource.
+type subscription struct {
+	token string
+	state string
+}
+
+func newHealthResources(server *mcp.Server, incTool *IncidentTool, compTool *ComponentHealthTool) *healthResources {
+	return &healthResources{
+		server:        server,
+		incidents:     incTool,
+		components:    compTool,
+		interval:      defaultResourcesPollInterval,
+		subscriptions: make(map[*mcp.ServerSession]map[string]*subscription),
+	}
+}
+
+// register adds the resource templates and the listing of the resources to the server.
+func (r *healthResources) register() {
+	r.server.AddResourceTemplate(&incidentResourceTemplate, r.readResource)
+	r.server.AddResourceTemplate(&componentResourceTemplate, r.readResource)
+	r.server.AddReceivingMiddleware(r.listMiddleware)
+}
+
+// listMiddleware completes the "resources/list" result with the current
+// incidents and components, as the server registry only holds static resources.
+func (r *healthResources) listMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		res, err := next(ctx, method, req)
+		if err != nil || method != "resources/list" {
+			return res, err
+		}
+		listRes, ok := res.(*mcp.ListResourcesResult)
+		if !ok {
+			return res, nil
+		}
+		resources, err := r.listResources(ctx)
+		if err != nil {
+			return nil, err
+		}
+		listRes.Resources = append(listRes.Resources, resources...)
+		return listRes, nil
+	}
+}
+
+// listResources returns the firing incidents and all the components as resources.
+func (r *healthResources) listResources(ctx context.Context) ([]*mcp.Resource, error) {
+	svc, err := r.incidents.newService(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := svc.List(ctx, incidents.Query{Status: "firing"})
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*mcp.Resource
+	for _, inc := range list {
+		ret = append(ret, &mcp.Resource{
+			URI:         incidentURIPrefix + inc.GroupId,
+			Name:        inc.GroupId,
+			Title:       fmt.Sprintf("Incident %s", inc.GroupId),
+			Description: fmt.Sprintf("%s incident affecting %s", inc.Severity, strings.Join(inc.AffectedComponents, ", ")),
+			MIMEType:    jsonMIMEType,
+		})
+	}
+
+	tree, err := r.components.loadTree(ctx, health.TreeFilter{})
+	if err != nil {
+		return nil, err
+	}
+	var addComponents func(nodes []*health.ComponentNode)
+	addComponents = func(nodes []*health.ComponentNode) {
+		for _, n := range nodes {
+			ret = append(ret, &mcp.Resource{
+				URI:         componentURIPrefix + n.Path,
+				Name:        n.Path,
+				Description: fmt.Sprintf("Health of the %s component, currently %s", n.Path, n.Status),
+				MIMEType:    jsonMIMEType,
+			})
+			addComponents(n.Children)
+		}
+	}
+	addComponents(tree)
+	return ret, nil
+}
+
+// readResource reads an incident or a component resource.
+func (r *healthResources) readResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	data, _, err := r.load(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	text, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{
+			{URI: uri, MIMEType: jsonMIMEType, Text: string(text)},
+		},
+	}, nil
+}
+
+// load returns the data of the resource and its state, used for detecting
+// the changes of the subscribed resources.
+func (r *healthResources) load(ctx context.Context, uri string) (any, string, error) {
+	if id, ok := strings.CutPrefix(uri, incidentURIPrefix); ok {
+		svc, err := r.incidents.newService(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		details, err := svc.Details(ctx, id)
+		if errors.Is(err, incidents.ErrNotFound) {
+			return nil, "", mcp.ResourceNotFoundError(uri)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		return details, details.Status + "/" + details.Severity, nil
+	}
+
+	if path, ok := strings.CutPrefix(uri, componentURIPrefix); ok {
+		tree, err := r.components.loadTree(ctx, health.TreeFilter{Path: path})
+		if err != nil {
+			return nil, "", err
+		}
+		if len(tree) == 0 {
+			return nil, "", mcp.ResourceNotFoundError(uri)
+		}
+		return tree[0], tree[0].Status, nil
+	}
+	return nil, "", mcp.ResourceNotFoundError(uri)
+}
+
+// subscribe starts watching the resource for changes for the session.
+func (r *healthResources) subscribe(ctx context.Context, req *mcp.SubscribeRequest) error {
+	uri := req.Params.URI
+	token, err := getTokenFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	_, state, err := r.load(ctx, uri)
+	if err != nil {
+		return err
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	subs, ok := r.subscriptions[req.Session]
+	if !ok {
+		subs = make(map[string]*subscription)
+		r.subscriptions[req.Session] = subs
+		if req.Session != nil {
+			go r.dropOnClose(req.Session)
+		}
+	}
+	subs[uri] = &subscription{token: token, state: state}
+	return nil
+}
+
+// unsubscribe stops watching the resource for the session.
+func (r *healthResources) unsubscribe(ctx context.Context, req *mcp.UnsubscribeRequest) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	delete(r.subscriptions[req.Session], req.Params.URI)
+	return nil
+}
+
+// dropOnClose removes the subscriptions of the session once it's closed.
+func (r *healthResources) dropOnClose(session *mcp.ServerSession) {
+	_ = session.Wait()
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	delete(r.subscriptions, session)
+}
+
+// Run checks the subscribed resources periodically until the context is done.
+func (r *healthResources) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.checkSubscriptions(ctx)
+		}
+	}
+}
+
+// checkSubscriptions loads the subscribed resources with the credentials
+// of each session and notifies the subscribers of the resources with
+// a changed state.
+//
+// The notifications are sent by the server to all the sessions subscribed
+// to the resource, they read the updated resource with their own credentials.
+func (r *healthResources) checkSubscriptions(ctx context.Context) {
+	type sessionSubscription struct {
+		session *mcp.ServerSession
+		uri     string
+		subscription
+	}
+	r.mtx.Lock()
+	var subs []sessionSubscription
+	for session, sessionSubs := range r.subscriptions {
+		for uri, sub := range sessionSubs {
+			subs = append(subs, sessionSubscription{session, uri, *sub})
+		}
+	}
+	r.mtx.Unlock()
+
+	changed := make(map[string]string)
+	for _, sub := range subs {
+		authCtx := context.WithValue(ctx, authHeaderStr, sub.token)
+		_, state, err := r.load(authCtx, sub.uri)
+		if isAuthError(err) {
+			slog.Warn("Dropping the subscribed resource, the credentials of the session were refused",
+				"uri", sub.uri, "error", err)
+			r.mtx.Lock()
+			delete(r.subscriptions[sub.session], sub.uri)
+			r.mtx.Unlock()
+			continue
+		}
+		if err != nil {
+			slog.Error("Failed to check the subscribed resource", "uri", sub.uri, "error", err)
+			continue
+		}
+		if state == sub.state {
+			continue
+		}
+
+		r.mtx.Lock()
+		if current, ok := r.subscriptions[sub.session][sub.uri]; ok {
+			current.state = state
+		}
+		r.mtx.Unlock()
+		changed[sub.uri] = state
+	}
+
+	for uri, state := range changed {
+		slog.Info("Subscribed resource changed", "uri", uri, "state", state)
+		if err := r.server.ResourceUpdated(ctx, &mcp.ResourceUpdatedNotificationParams{URI: uri}); err != nil {
+			slog.Error("Failed to notify about the resource update", "uri", uri, "error", err)
+		}
+	}
+}
+
+// isAuthError returns true if the error is caused by the credentials refused
+// by Prometheus or Alertmanager, i.e. the 401 Unauthorized and 403 Forbidden
+// responses. The Prometheus client exposes the status code only in the message.
+func isAuthError(err error) bool {
+	var promErr *promv1.Error
+	if errors.As(err, &promErr) {
+		return promErr.Type == promv1.ErrClient &&
+			(strings.HasSuffix(promErr.Msg, strconv.Itoa(http.StatusUnauthorized)) ||
+				strings.HasSuffix(promErr.Msg, strconv.Itoa(http.StatusForbidden)))
+	}
+	var apiErr *runtime.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.IsCode(http.StatusUnauthorized) || apiErr.IsCode(http.StatusForbidden)
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/runtime"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/openshift/cluster-health-analyzer/pkg/health"
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+	"github.com/openshift/cluster-health-analyzer/pkg/test/mocks"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// expectComponentHealth sets the loader to return the component health
+// metrics with the etcd component in the provided status.
+func expectComponentHealth(loader *mocks.MockPrometheusLoader, etcdStatus string) {
+	loader.EXPECT().LoadQuery(gomock.Any(), health.ComponentHealthMetric, gomock.Any()).Return([]model.LabelSet{
+		{"component": "control-plane", "status": model.LabelValue(etcdStatus)},
+	}, nil)
+	loader.EXPECT().LoadQuery(gomock.Any(), health.ComponentHealthAlertMetric, gomock.Any()).Return(nil, nil)
+	loader.EXPECT().LoadQuery(gomock.Any(), health.ComponentHealthObjectMetric, gomock.Any()).Return([]model.LabelSet{
+		{"component": "control-plane.etcd", "resource": "pods", "name": "etcd-0", "result": model.LabelValue(etcdStatus)},
+	}, nil)
+}
+
+func TestHealthResources_Components(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	promLoader := mocks.NewMockPrometheusLoader(ctrl)
+
+	var tokens []string
+	compTool := ComponentHealthTool{
+		getPrometheusLoaderFn: func(url, token string) (prom.Loader, error) {
+			tokens = append(tokens, token)
+			return promLoader, nil
+		},
+	}
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	resources := newHealthResources(server, &IncidentTool{}, &compTool)
+	ctx := context.WithValue(t.Context(), authHeaderStr, "test")
+
+	expectComponentHealth(promLoader, "OK")
+	res, err := resources.readResource(ctx, &mcp.ReadResourceRequest{
+		Params: &mcp.ReadResourceParams{URI: "component://control-plane.etcd"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*mcp.ResourceContents{
+		{
+			URI:      "component://control-plane.etcd",
+			MIMEType: jsonMIMEType,
+			Text:     `{"name":"etcd","path":"control-plane.etcd","status":"OK"}`,
+		},
+	}, res.Contents)
+
+	expectComponentHealth(promLoader, "OK")
+	_, err = resources.readResource(ctx, &mcp.ReadResourceRequest{
+		Params: &mcp.ReadResourceParams{URI: "component://control-plane.foo"},
+	})
+	assert.Error(t, err)
+
+	// the subscriptions are checked with the credentials of each session
+	connect := func() *mcp.ServerSession {
+		serverTransport, _ := mcp.NewInMemoryTransports()
+		session, err := server.Connect(t.Context(), serverTransport, nil)
+		assert.NoError(t, err)
+		return session
+	}
+	subscription := func(session *mcp.ServerSession, uri string) *subscription {
+		resources.mtx.Lock()
+		defer resources.mtx.Unlock()
+		return resources.subscriptions[session][uri]
+	}
+	sessionA, sessionB := connect(), connect()
+	const uri = "component://control-plane.etcd"
+
+	expectComponentHealth(promLoader, "OK")
+	err = resources.subscribe(context.WithValue(t.Context(), authHeaderStr, "token-a"), &mcp.SubscribeRequest{
+		Session: sessionA,
+		Params:  &mcp.SubscribeParams{URI: uri},
+	})
+	assert.NoError(t, err)
+	expectComponentHealth(promLoader, "OK")
+	err = resources.subscribe(context.WithValue(t.Context(), authHeaderStr, "token-b"), &mcp.SubscribeRequest{
+		Session: sessionB,
+		Params:  &mcp.SubscribeParams{URI: uri},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", subscription(sessionA, uri).state)
+	assert.Equal(t, "token-a", subscription(sessionA, uri).token)
+	assert.Equal(t, "token-b", subscription(sessionB, uri).token)
+
+	expectComponentHealth(promLoader, "error")
+	expectComponentHealth(promLoader, "error")
+	tokens = nil
+	resources.checkSubscriptions(t.Context())
+	assert.ElementsMatch(t, []string{"token-a", "token-b"}, tokens)
+	assert.Equal(t, "error", subscription(sessionA, uri).state)
+	assert.Equal(t, "error", subscription(sessionB, uri).state)
+
+	err = resources.unsubscribe(ctx, &mcp.UnsubscribeRequest{
+		Session: sessionA,
+		Params:  &mcp.UnsubscribeParams{URI: uri},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, subscription(sessionA, uri))
+	assert.NotNil(t, subscription(sessionB, uri))
+
+	// the subscriptions are dropped with the closed sessions
+	assert.NoError(t, sessionA.Close())
+	assert.NoError(t, sessionB.Close())
+	assert.Eventually(t, func() bool {
+		resources.mtx.Lock()
+		defer resources.mtx.Unlock()
+		return len(resources.subscriptions) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHealthResources_ExpiredToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	promLoader := mocks.NewMockPrometheusLoader(ctrl)
+	compTool := ComponentHealthTool{
+		getPrometheusLoaderFn: func(url, token string) (prom.Loader, error) {
+			return promLoader, nil
+		},
+	}
+	server := mcp.NewServer(&mcp.Implementation{Name: "test"}, nil)
+	resources := newHealthResources(server, &IncidentTool{}, &compTool)
+
+	serverTransport, _ := mcp.NewInMemoryTransports()
+	session, err := server.Connect(t.Context(), serverTransport, nil)
+	assert.NoError(t, err)
+	defer session.Close()
+	const uri = "component://control-plane.etcd"
+
+	expectComponentHealth(promLoader, "OK")
+	err = resources.subscribe(context.WithValue(t.Context(), authHeaderStr, "token"), &mcp.SubscribeRequest{
+		Session: session,
+		Params:  &mcp.SubscribeParams{URI: uri},
+	})
+	assert.NoError(t, err)
+
+	// the other errors keep the subscription
+	promLoader.EXPECT().LoadQuery(gomock.Any(), health.ComponentHealthMetric, gomock.Any()).
+		Return(nil, &promv1.Error{Type: promv1.ErrServer, Msg: "server error: 503"})
+	resources.checkSubscriptions(t.Context())
+	resources.mtx.Lock()
+	assert.Contains(t, resources.subscriptions[session], uri)
+	resources.mtx.Unlock()
+
+	// the expired token is refused, the subscription is dropped and not checked anymore
+	promLoader.EXPECT().LoadQuery(gomock.Any(), health.ComponentHealthMetric, gomock.Any()).
+		Return(nil, &promv1.Error{Type: promv1.ErrClient, Msg: "client error: 401"})
+	resources.checkSubscriptions(t.Context())
+	resources.checkSubscriptions(t.Context())
+	resources.mtx.Lock()
+	assert.NotContains(t, resources.subscriptions[session], uri)
+	resources.mtx.Unlock()
+}
+
+func TestIsAuthError(t *testing.T) {
+	assert.True(t, isAuthError(&promv1.Error{Type: promv1.ErrClient, Msg: "client error: 401"}))
+	assert.True(t, isAuthError(&promv1.Error{Type: promv1.ErrClient, Msg: "client error: 403"}))
+	assert.True(t, isAuthError(runtime.NewAPIError("unknown error", nil, 401)))
+	assert.False(t, isAuthError(&promv1.Error{Type: promv1.ErrClient, Msg: "client error: 400"}))
+	assert.False(t, isAuthError(runtime.NewAPIError("unknown error", nil, 500)))
+	assert.False(t, isAuthError(errors.New("connection refused")))
+	assert.False(t, isAuthError(nil))
+}
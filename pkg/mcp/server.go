@@ -3,9 +3,11 @@ package mcp
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -14,18 +16,52 @@ type authHeader string
 
 const authHeaderStr authHeader = "kubernetes-authorization"
 
+const (
+	// TransportHTTP serves the MCP server over the streamable HTTP,
+	// the token is provided by the callers in the kubernetes-authorization header.
+	TransportHTTP = "http"
+	// TransportStdio serves the MCP server over the stdin/stdout,
+	// for running locally with the desktop MCP clients.
+	TransportStdio = "stdio"
+
+	// shutdownTimeout is the time given to the pending requests
+	// when the HTTP server shuts down.
+	shutdownTimeout = 10 * time.Second
+)
+
 // MCPHealthServer is a helper and wrapper type
 // providing basic methods to run the underlying SSE server
 // and to register tools
 type MCPHealthServer struct {
-	server *mcp.Server
-	addr   string
+	server    *mcp.Server
+	resources *healthResources
+	addr      string
+	transport string
+	certFile  string
+	certKey   string
+	token     string
+
+	authenticator Authenticator
 }
 
 type MCPHealthServerCfg struct {
 	Name    string
 	Version string
-	Url     string
+	// Url is the listen address of the HTTP transport
+	Url string
+	// Transport is either "http" (default) or "stdio"
+	Transport string
+
+	// CertFile and CertKey enable TLS for the HTTP transport
+	CertFile string
+	CertKey  string
+
+	// Token is used to query Prometheus and AlertManager with the stdio transport
+	Token string
+
+	// Authenticator validates the callers of the HTTP transport,
+	// the tokens are passed as they are when not set
+	Authenticator Authenticator
 
 	PrometheusURL   string
 	AlertManagerURL string
@@ -38,41 +74,124 @@ func NewMCPHealthServer(cfg MCPHealthServerCfg) *MCPHealthServer {
 		Version: cfg.Version,
 	}
 
-	server := mcp.NewServer(&impl, &mcp.ServerOptions{HasTools: true})
+	// the resources are created after the server, the subscription handlers
+	// are bound to them through the closures
+	var resources *healthResources
+	server := mcp.NewServer(&impl, &mcp.ServerOptions{
+		HasTools:     true,
+		HasResources: true,
+		HasPrompts:   true,
+		SubscribeHandler: func(ctx context.Context, req *mcp.SubscribeRequest) error {
+			return resources.subscribe(ctx, req)
+		},
+		UnsubscribeHandler: func(ctx context.Context, req *mcp.UnsubscribeRequest) error {
+			return resources.unsubscribe(ctx, req)
+		},
+	})
 
 	incTool := NewIncidentsTool(cfg.PrometheusURL, cfg.AlertManagerURL)
 	// get_incidents
-	mcp.AddTool(server, &incTool.Tool, mcp.ToolHandlerFor[GetIncidentsParams, any](incTool.IncidentsHandler))
+	mcp.AddTool(server, &incTool.Tool, mcp.ToolHandlerFor[GetIncidentsParams, *Response](incTool.IncidentsHandler))
+	// get_incident_details
+	mcp.AddTool(server, &incTool.DetailsTool, mcp.ToolHandlerFor[GetIncidentDetailsParams, any](incTool.IncidentDetailsHandler))
+	// get_alerts
+	mcp.AddTool(server, &incTool.AlertsTool, mcp.ToolHandlerFor[GetAlertsParams, any](incTool.AlertsHandler))
+
+	componentsTool := NewComponentHealthTool(cfg.PrometheusURL)
+	// get_component_health
+	mcp.AddTool(server, &componentsTool.Tool, mcp.ToolHandlerFor[GetComponentHealthParams, any](componentsTool.ComponentHealthHandler))
+
+	// incident://{id} and component://{path}
+	resources = newHealthResources(server, &incTool, &componentsTool)
+	resources.register()
+
+	server.AddPrompt(&summarizeClusterHealthPrompt, SummarizeClusterHealthHandler)
+	server.AddPrompt(&rootCauseIncidentPrompt, RootCauseIncidentHandler)
 
 	return &MCPHealthServer{
-		server: server,
-		addr:   cfg.Url,
+		server:    server,
+		resources: resources,
+		addr:      cfg.Url,
+		transport: cfg.Transport,
+		certFile:  cfg.CertFile,
+		certKey:   cfg.CertKey,
+		token:     cfg.Token,
+
+		authenticator: cfg.Authenticator,
+	}
+}
+
+// Start runs the MCPHealthServer with the configured transport until
+// the context is cancelled.
+func (m *MCPHealthServer) Start(ctx context.Context) error {
+	switch m.transport {
+	case "", TransportHTTP:
+		return m.startHTTP(ctx)
+	case TransportStdio:
+		return m.startStdio(ctx)
+	default:
+		return fmt.Errorf("unsupported transport %q", m.transport)
 	}
 }
 
-// Start runs the MCPHealthServer
-func (m *MCPHealthServer) Start() error {
+// startStdio runs the MCPHealthServer over the stdin/stdout until the client
+// disconnects. All the requests use the configured token.
+func (m *MCPHealthServer) startStdio(ctx context.Context) error {
+	if m.token == "" {
+		return errors.New("empty token for the stdio transport")
+	}
+	// the resources are checked only while the client is connected
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	slog.Info("Starting MCP server on stdio")
+	go m.resources.Run(ctx)
+
+	authCtx := context.WithValue(ctx, authHeaderStr, m.token)
+	return m.server.Run(authCtx, &mcp.StdioTransport{})
+}
+
+// startHTTP runs the MCPHealthServer over the streamable HTTP, with TLS
+// when the certificate is configured.
+func (m *MCPHealthServer) startHTTP(ctx context.Context) error {
 	if m.addr == "" {
 		return errors.New("empty http address")
 	}
+	if (m.certFile == "") != (m.certKey == "") {
+		return errors.New("both the TLS certificate and key must be provided")
+	}
 	handler := mcp.NewStreamableHTTPHandler(func(r *http.Request) *mcp.Server {
 		return m.server
 	}, nil)
 
 	slog.Info("Starting MCP server on ", "address", m.addr)
+	go m.resources.Run(ctx)
 
-	// the following middleware is needed to enrich the context that will be
-	// forwarded until the mcp server with the kubernetes-authorization token
-	mdw := func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authCtx := authFromRequest(r.Context(), r)
-			r = r.WithContext(authCtx)
-			next.ServeHTTP(w, r)
-		})
+	// the following middleware authenticates the callers and enriches the context
+	// that will be forwarded until the mcp server with the kubernetes-authorization token
+	srv := &http.Server{
+		Addr:    m.addr,
+		Handler: authMiddleware(m.authenticator, handler),
 	}
-
-	handlerWithAuthCtx := mdw(handler)
-	return http.ListenAndServe(m.addr, handlerWithAuthCtx)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Failed to shut down the MCP server", "error", err)
+		}
+	}()
+
+	var err error
+	if m.certFile != "" {
+		err = srv.ListenAndServeTLS(m.certFile, m.certKey)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
 
 // RegisterTool registers a new tool on the MCPHealthServer
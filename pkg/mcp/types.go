@@ -2,79 +2,49 @@ package mcp
 
 import (
 	"fmt"
-	"time"
 
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/incidents"
 )
 
+// Response is the output of the get_incidents tool.
 type Response struct {
 	Incidents Incidents `json:"incidents"`
 }
 
 type Incidents struct {
-	Total     int        `json:"total"`
-	Incidents []Incident `json:"items"`
+	Total     int            `json:"total"`
+	Incidents []IncidentItem `json:"items"`
+	// NextCursor is set when there are more incidents than returned,
+	// it's used as the cursor param to get the next page.
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
-type Incident struct {
-	GroupId   string `json:"id"`
-	Severity  string `json:"severity"`
-	StartTime string `json:"start_time"`
-	Status    string `json:"status"`
-	EndTime   string `json:"end_time"`
-	Cluster   string `json:"cluster,omitempty"`
-	ClusterID string `json:"cluster_id,omitempty"`
-
-	URL                string              `json:"url_details"`
-	Alerts             []model.LabelSet    `json:"alerts"`
-	AlertsSet          map[string]struct{} `json:"-"`
-	AffectedComponents []string            `json:"affected_components"`
-	ComponentsSet      map[string]struct{} `json:"-"`
+// IncidentItem is the incident in the get_incidents response. The alerts
+// are included in full, as a summary or not at all, as requested.
+type IncidentItem struct {
+	incidents.Incident
+	Alerts        []model.LabelSet `json:"alerts,omitempty"`
+	AlertsSummary *AlertsSummary   `json:"alerts_summary,omitempty"`
 }
 
-// UpdateEndTime updates the end time of the incident following
-// the following rules:
-// if the new time is zero then set empty string
-// if the existing time is empty (incident is active) then do nothing
-// if the new time is after existing end time then update it with the
-// new time, otherwise do nothing
-func (i *Incident) UpdateEndTime(endTime time.Time) error {
-	if endTime.IsZero() {
-		i.EndTime = ""
-		return nil
-	}
-
-	if i.EndTime == "" {
-		return nil
-	}
-
-	existingEndTime, err := time.Parse(time.RFC3339, i.EndTime)
-	if err != nil {
-		return fmt.Errorf("failed to parse existing end time: %w", err)
-	}
-
-	if endTime.After(existingEndTime) {
-		i.EndTime = formatToRFC3339(endTime)
-	}
-	return nil
+// AlertsSummary summarizes the alerts of the incident.
+type AlertsSummary struct {
+	Total      int            `json:"total"`
+	Firing     int            `json:"firing"`
+	Silenced   int            `json:"silenced"`
+	BySeverity map[string]int `json:"by_severity"`
+	Names      []string       `json:"names"`
 }
 
-func (i *Incident) UpdateStartTime(startTime time.Time) error {
-	existingStartTime, err := time.Parse(time.RFC3339, i.StartTime)
+// outputSchemaFor generates the JSON schema of the tool output from the type T.
+// The types are static, so the failure is a programming error.
+func outputSchemaFor[T any]() *jsonschema.Schema {
+	schema, err := jsonschema.For[T](nil)
 	if err != nil {
-		return fmt.Errorf("failed to parse existing start time: %w", err)
-	}
-
-	if startTime.Before(existingStartTime) {
-		i.StartTime = formatToRFC3339(startTime)
-	}
-	return nil
-}
-
-func (i *Incident) UpdateStatus() {
-	if i.EndTime == "" {
-		i.Status = "firing"
-	} else {
-		i.Status = "resolved"
+		panic(fmt.Sprintf("failed to generate the output schema: %v", err))
 	}
+	return schema
 }
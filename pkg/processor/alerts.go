@@ -3,6 +3,7 @@ package processor
 // This file contains logic for mapping prometheus alerts to component health maps.
 
 import (
+	"maps"
 	"slices"
 
 	"github.com/prometheus/common/model"
@@ -26,17 +27,35 @@ func getAlertHealthMap(a model.LabelSet) ComponentHealthMap {
 	healthMap := ComponentHealthMap{
 		Layer:     layer,
 		Component: component,
-		SrcType:   Alert,
+		SrcType:   srcType(a),
 		SrcLabels: labels,
 	}
+	if healthMap.SrcType == Event {
+		// The events are identified by the reason and kind, regardless of the component.
+		maps.Copy(healthMap.SrcLabels, getMapSubset(a, eventLabels...))
+	}
 
 	healthMap.GroupId = string(a["group_id"])
 	healthMap.Health = ParseHealthValue(string(a["severity"]))
 	healthMap.Silenced = string(a["silenced"])
+	healthMap.Cluster = string(a[ClusterLabel])
+	healthMap.ClusterID = string(a[ClusterIDLabel])
 
 	return healthMap
 }
 
+// srcType determines the type of the signal.
+func srcType(a model.LabelSet) SrcType {
+	switch {
+	case isConditionSignal(a):
+		return ClusterOperatorCondition
+	case isEventSignal(a):
+		return Event
+	default:
+		return Alert
+	}
+}
+
 // determineComponent determines the component of a prometheus alert.
 //
 // It uses various strategies to determine the component.
@@ -44,9 +63,8 @@ func determineComponent(a model.LabelSet) (layer, component string, labels model
 	// Check if alert is a node alert.
 	return evalMatcherFns([]componentMatcherFn{
 		cvoAlertsMatcher,
-		computeMatcher,
-		coreMatcher,
-		workloadMatcher,
+		clusterOperatorConditionMatcher,
+		mappingsMatcher,
 	}, a)
 }
 
@@ -63,30 +81,7 @@ func cvoAlertsMatcher(labels model.LabelSet) (layer, comp model.LabelValue, keys
 	return "", "", nil
 }
 
-func computeMatcher(labels model.LabelSet) (layer, comp model.LabelValue, keys []model.LabelName) {
-	for _, nodeAlert := range nodeAlerts {
-		if labels["alertname"] == nodeAlert {
-			// TODO: determine node identifier as a component
-			// TODO: split nodes to controlplane and worker nodes
-			component := "compute"
-			return "compute", model.LabelValue(component), nil
-		}
-	}
-	return "", "", nil
-}
-
-func coreMatcher(labels model.LabelSet) (layer, comp model.LabelValue, keys []model.LabelName) {
-	// Try matching against core components.
-	if component, keys := findComponent(coreMatchers, labels); component != "" {
-		return "core", model.LabelValue(component), keys
-	}
-	return "", "", nil
-}
-
-func workloadMatcher(labels model.LabelSet) (layer, comp model.LabelValue, keys []model.LabelName) {
-	// Try matching against workload components.
-	if component, keys := findComponent(workloadMatchers, labels); component != "" {
-		return "workload", model.LabelValue(component), keys
-	}
-	return "", "", nil
+// mappingsMatcher tries matching the labels against the configured component mappings.
+func mappingsMatcher(labels model.LabelSet) (layer, comp model.LabelValue, keys []model.LabelName) {
+	return mappings.Load().matchComponent(labels)
 }
@@ -0,0 +1,222 @@
+package processor
+
+// This file contains logic for grouping the alerts of multiple clusters,
+// e.g. when processing the alerts from ACM/Thanos.
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+const (
+	// ClusterLabel is the exported label with the name of the cluster of the signal.
+	ClusterLabel = "cluster"
+	// ClusterIDLabel is the exported label identifying the cluster of the signal.
+	ClusterIDLabel = "clusterID"
+)
+
+// ClusterGroupsCollection keeps a separate GroupsCollection per cluster,
+// so that the alerts from different clusters are never grouped into
+// the same incident.
+type ClusterGroupsCollection struct {
+	// ClusterLabel is the label of the alerts identifying their cluster.
+	// When empty, all the alerts are considered to come from a single cluster.
+	ClusterLabel model.LabelName
+
+	// Config holds the grouping parameters. DefaultGroupingConfig is used when nil.
+	Config *GroupingConfig
+
+	// Clusters holds the groups collections by the cluster ID,
+	// the single cluster is stored under the empty ID.
+	Clusters map[string]*GroupsCollection
+}
+
+// NewClusterGroupsCollection creates an empty collection, partitioning
+// the alerts by the cluster label.
+func NewClusterGroupsCollection(clusterLabel model.LabelName, config *GroupingConfig) *ClusterGroupsCollection {
+	return &ClusterGroupsCollection{
+		ClusterLabel: clusterLabel,
+		Config:       config,
+		Clusters:     make(map[string]*GroupsCollection),
+	}
+}
+
+// NewClusterGroupsCollectionFromSnapshot restores the collection from the snapshot.
+func NewClusterGroupsCollectionFromSnapshot(snapshot *GroupsSnapshot, clusterLabel model.LabelName,
+	config *GroupingConfig) *ClusterGroupsCollection {
+	c := NewClusterGroupsCollection(clusterLabel, config)
+	if len(snapshot.Groups) > 0 {
+		gc := newGroupsCollectionFromStates(snapshot.Groups, snapshot.Timelines)
+		c.cluster("").Groups, c.cluster("").Timelines = gc.Groups, gc.Timelines
+	}
+	for id, states := range snapshot.Clusters {
+		gc := newGroupsCollectionFromStates(states, snapshot.Timelines)
+		c.cluster(id).Groups, c.cluster(id).Timelines = gc.Groups, gc.Timelines
+	}
+	return c
+}
+
+// cluster returns the groups collection of the cluster, creating it when missing.
+func (c *ClusterGroupsCollection) cluster(id string) *GroupsCollection {
+	gc, ok := c.Clusters[id]
+	if !ok {
+		gc = &GroupsCollection{Config: c.Config}
+		c.Clusters[id] = gc
+	}
+	return gc
+}
+
+// clusterID returns the ID of the cluster of the labels.
+func (c *ClusterGroupsCollection) clusterID(labels model.LabelSet) string {
+	if c.ClusterLabel == "" {
+		return ""
+	}
+	return string(labels[c.ClusterLabel])
+}
+
+// ProcessAlertsBatch assigns the group_id to the alerts, grouping them
+// only with the alerts of the same cluster.
+func (c *ClusterGroupsCollection) ProcessAlertsBatch(alerts []model.LabelSet, timestamp time.Time) []model.LabelSet {
+	byCluster := make(map[string][]model.LabelSet)
+	for _, a := range alerts {
+		id := c.clusterID(a)
+		byCluster[id] = append(byCluster[id], a)
+	}
+
+	ret := make([]model.LabelSet, 0, len(alerts))
+	for _, id := range slices.Sorted(maps.Keys(byCluster)) {
+		ret = append(ret, c.cluster(id).ProcessAlertsBatch(byCluster[id], timestamp)...)
+	}
+	return ret
+}
+
+// ProcessIntervalsBatch assigns the intervals to the groups of their clusters.
+func (c *ClusterGroupsCollection) ProcessIntervalsBatch(intervals []Interval) []GroupedInterval {
+	byCluster := make(map[string][]Interval)
+	for _, i := range intervals {
+		id := c.clusterID(i.Metric)
+		byCluster[id] = append(byCluster[id], i)
+	}
+
+	ret := make([]GroupedInterval, 0, len(intervals))
+	for _, id := range slices.Sorted(maps.Keys(byCluster)) {
+		ret = append(ret, c.cluster(id).ProcessIntervalsBatch(byCluster[id])...)
+	}
+	return ret
+}
+
+// ResolveAlertsBatch extends the groups of the resolved alerts within their
+// clusters, see GroupsCollection.ResolveAlertsBatch. The clusters without
+// any groups are not created.
+func (c *ClusterGroupsCollection) ResolveAlertsBatch(alerts []model.LabelSet, timestamp time.Time) int {
+	matched := 0
+	for _, a := range alerts {
+		if gc, ok := c.Clusters[c.clusterID(a)]; ok {
+			matched += gc.ResolveAlertsBatch([]model.LabelSet{a}, timestamp)
+		}
+	}
+	return matched
+}
+
+func (c *ClusterGroupsCollection) processHistoricalAlerts(alertsRange prom.RangeVector) {
+	for id, rv := range partitionRangeVector(alertsRange, c.clusterID) {
+		c.cluster(id).processHistoricalAlerts(rv)
+	}
+}
+
+// UpdateGroupUUIDs recovers the group-ids of the clusters from the health map,
+// where the cluster is identified by the exported clusterID label.
+func (c *ClusterGroupsCollection) UpdateGroupUUIDs(healthMapRV prom.RangeVector) {
+	byCluster := partitionRangeVector(healthMapRV, func(labels model.LabelSet) string {
+		if c.ClusterLabel == "" {
+			return ""
+		}
+		return string(labels[ClusterIDLabel])
+	})
+	for id, gc := range c.Clusters {
+		gc.UpdateGroupUUIDs(byCluster[id])
+	}
+}
+
+// PruneGroups removes the groups that can't be matched anymore from all the clusters.
+func (c *ClusterGroupsCollection) PruneGroups(t time.Time) {
+	for _, gc := range c.Clusters {
+		gc.PruneGroups(t)
+	}
+}
+
+// Snapshot returns the snapshot of the groups of all the clusters taken at time t.
+func (c *ClusterGroupsCollection) Snapshot(t time.Time) *GroupsSnapshot {
+	snapshot := &GroupsSnapshot{Timestamp: t, Groups: []groupState{}}
+	for id, gc := range c.Clusters {
+		for groupID, tl := range cloneTimelines(gc.Timelines) {
+			if snapshot.Timelines == nil {
+				snapshot.Timelines = make(map[string]*incidentTimeline)
+			}
+			snapshot.Timelines[groupID] = tl
+		}
+		if id == "" {
+			snapshot.Groups = gc.groupStates()
+			continue
+		}
+		if snapshot.Clusters == nil {
+			snapshot.Clusters = make(map[string][]groupState)
+		}
+		snapshot.Clusters[id] = gc.groupStates()
+	}
+	return snapshot
+}
+
+// groupStarts returns the earliest start time of the groups by their root group ID,
+// i.e. by the group_id of the exported incidents.
+func (c *ClusterGroupsCollection) groupStarts() map[string]time.Time {
+	ret := make(map[string]time.Time)
+	for _, gc := range c.Clusters {
+		for _, g := range gc.Groups {
+			start := g.Start.Time()
+			if s, ok := ret[g.RootGroupID]; !ok || start.Before(s) {
+				ret[g.RootGroupID] = start
+			}
+		}
+	}
+	return ret
+}
+
+// partitionRangeVector splits the range vector by the cluster ID of its series.
+func partitionRangeVector(rv prom.RangeVector, clusterID func(model.LabelSet) string) map[string]prom.RangeVector {
+	ret := make(map[string]prom.RangeVector)
+	for _, r := range rv {
+		id := clusterID(r.Metric)
+		ret[id] = append(ret[id], r)
+	}
+	return ret
+}
+
+// setClusterIDs sets the cluster ID of the health maps to the value of the cluster
+// label of their signals, so that the exported metrics identify the clusters
+// the same way regardless the label used by the source. The health maps
+// correspond to the signals by their index.
+func setClusterIDs(healthMaps []ComponentHealthMap, signals []model.LabelSet, clusterLabel model.LabelName) {
+	if clusterLabel == "" || clusterLabel == ClusterIDLabel {
+		return
+	}
+	for i := range healthMaps {
+		healthMaps[i].ClusterID = string(signals[i][clusterLabel])
+	}
+}
+
+// setClusterLabels sets the labels of the cluster of the exported series.
+// The cluster labels are set only in the multi-cluster setup.
+func setClusterLabels(labels model.LabelSet, cluster, clusterID string) {
+	if cluster != "" {
+		labels[ClusterLabel] = model.LabelValue(cluster)
+	}
+	if clusterID != "" {
+		labels[ClusterIDLabel] = model.LabelValue(clusterID)
+	}
+}
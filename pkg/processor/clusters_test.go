@@ -0,0 +1,154 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+	"github.com/openshift/cluster-health-analyzer/pkg/test/mocks"
+)
+
+func TestClusterGroupsCollection_ProcessAlertsBatch(t *testing.T) {
+	alerts := func() []model.LabelSet {
+		return []model.LabelSet{
+			{"alertname": "KubePodCrashLooping", "namespace": "foo", "pod": "bar", "severity": "warning", "clusterID": "c1"},
+			{"alertname": "KubePodNotReady", "namespace": "foo", "pod": "bar", "severity": "warning", "clusterID": "c1"},
+			{"alertname": "KubePodCrashLooping", "namespace": "foo", "pod": "bar", "severity": "warning", "clusterID": "c2"},
+		}
+	}
+	groupIDs := func(alerts []model.LabelSet) map[string]map[model.LabelValue]model.LabelValue {
+		ret := make(map[string]map[model.LabelValue]model.LabelValue)
+		for _, a := range alerts {
+			cluster := string(a["clusterID"])
+			if ret[cluster] == nil {
+				ret[cluster] = make(map[model.LabelValue]model.LabelValue)
+			}
+			ret[cluster][a["alertname"]] = a["group_id"]
+		}
+		return ret
+	}
+	now := time.Now()
+
+	// The alerts of different clusters are grouped separately.
+	c := NewClusterGroupsCollection("clusterID", nil)
+	got := groupIDs(c.ProcessAlertsBatch(alerts(), now))
+	assert.Len(t, c.Clusters, 2)
+	assert.Equal(t, got["c1"]["KubePodCrashLooping"], got["c1"]["KubePodNotReady"])
+	assert.NotEqual(t, got["c1"]["KubePodCrashLooping"], got["c2"]["KubePodCrashLooping"])
+
+	// Without the cluster label, all the alerts are considered from a single cluster.
+	c = NewClusterGroupsCollection("", nil)
+	got = groupIDs(c.ProcessAlertsBatch(alerts(), now))
+	assert.Len(t, c.Clusters, 1)
+	assert.Equal(t, got["c1"]["KubePodCrashLooping"], got["c2"]["KubePodCrashLooping"])
+}
+
+func TestClusterGroupsCollection_ProcessConditions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	loader := mocks.NewMockPrometheusLoader(ctrl)
+	loader.EXPECT().LoadQuery(ctx, clusterOperatorConditionsQuery, now).Return([]model.LabelSet{
+		{"name": "etcd", "condition": "Degraded", "cluster": "prod", "managed_cluster": "uuid-1"},
+		{"name": "etcd", "condition": "Degraded", "cluster": "stage", "managed_cluster": "uuid-2"},
+	}, nil)
+
+	p := &processor{loader: loader, clusterLabel: "managed_cluster"}
+	conditions, err := p.loadConditions(ctx, now)
+	require.NoError(t, err)
+
+	// The same condition of different clusters is grouped separately.
+	c := NewClusterGroupsCollection("managed_cluster", nil)
+	processed := c.ProcessAlertsBatch(conditions, now)
+	assert.Len(t, c.Clusters, 2)
+	require.Len(t, processed, 2)
+	assert.NotEqual(t, processed[0]["group_id"], processed[1]["group_id"])
+
+	// And exported with the labels of its cluster.
+	healthMaps := MapAlerts(processed)
+	setClusterIDs(healthMaps, processed, "managed_cluster")
+	for i, want := range []model.LabelSet{
+		{ClusterLabel: "prod", ClusterIDLabel: "uuid-1"},
+		{ClusterLabel: "stage", ClusterIDLabel: "uuid-2"},
+	} {
+		labels := healthMaps[i].Labels()
+		assert.Equal(t, ClusterOperatorCondition, healthMaps[i].SrcType)
+		assert.Equal(t, want[ClusterLabel], labels[ClusterLabel])
+		assert.Equal(t, want[ClusterIDLabel], labels[ClusterIDLabel])
+	}
+}
+
+func TestClusterGroupsCollection_UpdateGroupUUIDs(t *testing.T) {
+	c := NewClusterGroupsCollection("clusterID", nil)
+	samples := []model.SamplePair{{Timestamp: model.TimeFromUnix(60)}, {Timestamp: model.TimeFromUnix(120)}}
+	c.processHistoricalAlerts(prom.RangeVector{
+		{
+			Metric:  model.LabelSet{"alertname": "KubePodCrashLooping", "namespace": "foo", "clusterID": "c1"},
+			Samples: samples,
+			Step:    time.Minute,
+		},
+		{
+			Metric:  model.LabelSet{"alertname": "KubePodCrashLooping", "namespace": "foo", "clusterID": "c2"},
+			Samples: samples,
+			Step:    time.Minute,
+		},
+	})
+
+	// The same alert was already exported for both the clusters, each with its group_id.
+	c.UpdateGroupUUIDs(prom.RangeVector{
+		{
+			Metric: model.LabelSet{"group_id": "uuid-1", "clusterID": "c1",
+				"src_alertname": "KubePodCrashLooping", "src_namespace": "foo"},
+			Samples: samples,
+			Step:    time.Minute,
+		},
+		{
+			Metric: model.LabelSet{"group_id": "uuid-2", "clusterID": "c2",
+				"src_alertname": "KubePodCrashLooping", "src_namespace": "foo"},
+			Samples: samples,
+			Step:    time.Minute,
+		},
+	})
+
+	assert.Equal(t, "uuid-1", c.Clusters["c1"].Groups[0].RootGroupID)
+	assert.Equal(t, "uuid-2", c.Clusters["c2"].Groups[0].RootGroupID)
+}
+
+func TestClusterGroupsCollection_Snapshot(t *testing.T) {
+	c := NewClusterGroupsCollection("clusterID", nil)
+	c.cluster("").Groups = testGroupsCollection().Groups
+	c.cluster("c1").Groups = testGroupsCollection().Groups
+
+	snapshot := c.Snapshot(time.Unix(3000, 0))
+	assert.Len(t, snapshot.Groups, 2)
+	require.Contains(t, snapshot.Clusters, "c1")
+	assert.Len(t, snapshot.Clusters["c1"], 2)
+
+	restored := NewClusterGroupsCollectionFromSnapshot(snapshot, "clusterID", nil)
+	assert.Equal(t, c.Clusters, restored.Clusters)
+}
+
+func TestSetClusterIDs(t *testing.T) {
+	signals := []model.LabelSet{
+		{"alertname": "KubePodCrashLooping", "namespace": "foo", "severity": "warning",
+			"cluster": "prod", "managed_cluster": "uuid-1"},
+	}
+	healthMaps := MapAlerts(signals)
+	setClusterIDs(healthMaps, signals, "managed_cluster")
+
+	labels := healthMaps[0].Labels()
+	assert.Equal(t, model.LabelValue("prod"), labels[ClusterLabel])
+	assert.Equal(t, model.LabelValue("uuid-1"), labels[ClusterIDLabel])
+
+	// The cluster labels are not exported in the single cluster setup.
+	labels = MapAlerts([]model.LabelSet{{"alertname": "KubePodCrashLooping", "namespace": "foo"}})[0].Labels()
+	assert.NotContains(t, labels, model.LabelName(ClusterLabel))
+	assert.NotContains(t, labels, model.LabelName(ClusterIDLabel))
+}
@@ -0,0 +1,97 @@
+package processor
+
+// This file contains logic for using the ClusterOperator conditions as a signal.
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+const (
+	// ClusterOperatorConditionsMetric is the metric exposing the conditions
+	// of the ClusterOperators.
+	ClusterOperatorConditionsMetric = "cluster_operator_conditions"
+
+	// clusterOperatorConditionsQuery selects the conditions indicating
+	// an issue with the operator.
+	clusterOperatorConditionsQuery = ClusterOperatorConditionsMetric + `{condition="Degraded"} == 1 or ` +
+		ClusterOperatorConditionsMetric + `{condition="Available"} == 0 or ` +
+		ClusterOperatorConditionsMetric + `{condition="Progressing"} == 1`
+)
+
+// conditionSeverities maps the problematic conditions to the normalized severity.
+var conditionSeverities = map[model.LabelValue]HealthValue{
+	"Available":   Critical, // Available=False
+	"Degraded":    Warning,  // Degraded=True
+	"Progressing": Healthy,  // Progressing=True
+}
+
+// conditionSignal converts the cluster_operator_conditions series to the signal
+// labels. Only the identifying labels are kept (and not the scrape target ones),
+// so that the signal is not fuzzy-matched with unrelated alerts. The labels
+// identifying the cluster are kept as well, so that the conditions of multiple
+// clusters are grouped and exported per cluster, the same way as the alerts.
+//
+// The signal type is distinguished by the __name__ label, the same way
+// the alerts have __name__="ALERTS".
+func conditionSignal(labels model.LabelSet, clusterLabel model.LabelName) model.LabelSet {
+	ret := model.LabelSet{
+		model.MetricNameLabel: ClusterOperatorConditionsMetric,
+		"name":                labels["name"],
+		"condition":           labels["condition"],
+		"severity":            model.LabelValue(conditionSeverities[labels["condition"]].String()),
+	}
+	if reason := labels["reason"]; reason != "" {
+		ret["reason"] = reason
+	}
+	for _, l := range []model.LabelName{clusterLabel, ClusterLabel, ClusterIDLabel} {
+		if v := labels[l]; l != "" && v != "" {
+			ret[l] = v
+		}
+	}
+	return ret
+}
+
+// isConditionSignal returns true if the labels represent a ClusterOperator condition.
+func isConditionSignal(labels model.LabelSet) bool {
+	return labels[model.MetricNameLabel] == ClusterOperatorConditionsMetric
+}
+
+// loadConditions loads the ClusterOperator conditions indicating an issue at the time t.
+func (p *processor) loadConditions(ctx context.Context, t time.Time) ([]model.LabelSet, error) {
+	conditions, err := p.loader.LoadQuery(ctx, clusterOperatorConditionsQuery, t)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]model.LabelSet, 0, len(conditions))
+	for _, c := range conditions {
+		ret = append(ret, conditionSignal(c, p.clusterLabel))
+	}
+	return ret, nil
+}
+
+// loadConditionsRange loads the ClusterOperator conditions indicating an issue
+// for the given time range.
+func (p *processor) loadConditionsRange(ctx context.Context, start, end time.Time, step time.Duration) (prom.RangeVector, error) {
+	rv, err := p.loader.LoadVectorRange(ctx, clusterOperatorConditionsQuery, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rv {
+		rv[i].Metric = conditionSignal(rv[i].Metric, p.clusterLabel)
+	}
+	return rv, nil
+}
+
+// clusterOperatorConditionMatcher assigns the ClusterOperator conditions
+// to the core component of the operator.
+func clusterOperatorConditionMatcher(labels model.LabelSet) (layer, comp model.LabelValue, keys []model.LabelName) {
+	if isConditionSignal(labels) {
+		return "core", labels["name"], []model.LabelName{"name", "condition"}
+	}
+	return "", "", nil
+}
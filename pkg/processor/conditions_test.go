@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/test/mocks"
+)
+
+func TestLoadConditions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	loader := mocks.NewMockPrometheusLoader(ctrl)
+	loader.EXPECT().LoadQuery(ctx, clusterOperatorConditionsQuery, now).Return([]model.LabelSet{
+		{
+			"__name__": "cluster_operator_conditions", "name": "etcd", "condition": "Degraded",
+			"reason": "EtcdMembersDegraded", "namespace": "openshift-cluster-version", "pod": "cvo-0",
+		},
+		{"name": "authentication", "condition": "Available", "reason": "OAuthServerDown"},
+		{"name": "machine-config", "condition": "Progressing"},
+	}, nil)
+
+	p := &processor{loader: loader}
+	conditions, err := p.loadConditions(ctx, now)
+	require.NoError(t, err)
+
+	// The scrape target labels are dropped and the severity is normalized.
+	assert.Equal(t, []model.LabelSet{
+		{
+			"__name__": "cluster_operator_conditions", "name": "etcd", "condition": "Degraded",
+			"reason": "EtcdMembersDegraded", "severity": "warning",
+		},
+		{
+			"__name__": "cluster_operator_conditions", "name": "authentication", "condition": "Available",
+			"reason": "OAuthServerDown", "severity": "critical",
+		},
+		{
+			"__name__": "cluster_operator_conditions", "name": "machine-config", "condition": "Progressing",
+			"severity": "info",
+		},
+	}, conditions)
+
+	healthMaps := MapAlerts(conditions)
+	assert.Equal(t, ComponentHealthMap{
+		Layer:     "core",
+		Component: "etcd",
+		SrcType:   ClusterOperatorCondition,
+		SrcLabels: model.LabelSet{"name": "etcd", "condition": "Degraded", "severity": "warning"},
+		Health:    Warning,
+	}, healthMaps[0])
+	assert.Equal(t, Critical, healthMaps[1].Health)
+	assert.Equal(t, Healthy, healthMaps[2].Health)
+}
+
+func TestGroupsCollection_ConditionWithAlerts(t *testing.T) {
+	start := model.TimeFromUnix(0)
+	condition := conditionSignal(model.LabelSet{"name": "etcd", "condition": "Degraded"}, "")
+	gc := &GroupsCollection{}
+
+	gi := gc.ProcessIntervalsBatch([]Interval{
+		{Metric: condition, Start: start, End: start.Add(time.Hour)},
+	})
+	conditionGroup := gi[0].GroupMatcher.RootGroupID
+
+	// The CVO alert for the same operator lands in the same incident,
+	// even outside of the time-based matching window.
+	later := start.Add(30 * time.Minute)
+	gi = gc.ProcessIntervalsBatch([]Interval{
+		{
+			Metric: model.LabelSet{"alertname": "ClusterOperatorDegraded", "name": "etcd",
+				"namespace": "openshift-cluster-version"},
+			Start: later, End: later.Add(time.Minute),
+		},
+		{
+			Metric: model.LabelSet{"alertname": "ClusterOperatorDegraded", "name": "dns",
+				"namespace": "openshift-cluster-version"},
+			Start: later, End: later.Add(time.Minute),
+		},
+	})
+	assert.Equal(t, conditionGroup, gi[0].GroupMatcher.RootGroupID)
+
+	// Other conditions and alerts are not matched to the group.
+	gi = gc.ProcessIntervalsBatch([]Interval{
+		{
+			Metric: conditionSignal(model.LabelSet{"name": "kube-apiserver", "condition": "Degraded"}, ""),
+			Start:  later, End: later.Add(time.Minute),
+		},
+	})
+	assert.NotEqual(t, conditionGroup, gi[0].GroupMatcher.RootGroupID)
+}
@@ -0,0 +1,220 @@
+package processor
+
+// This file contains the dependency graph between the components, used to
+// correlate the signals of the dependent components into the same incidents.
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// allComponents stands for all the components with a higher rank
+	// than the upstream one in the dependencies config.
+	allComponents = "*"
+
+	// dependencyMatchDistance is the distance of the matchers linking
+	// the signals of the dependent components. It's preferred over the pure
+	// time-based matching, but not over the matching on the signal labels.
+	dependencyMatchDistance = 3
+	// dependencyLabel is the synthetic label holding the component
+	// of the signal in the dependency matchers.
+	dependencyLabel = "__component"
+)
+
+// ComponentDependency defines the components depending on the upstream
+// component, e.g. all the components depending on the kube-apiserver.
+type ComponentDependency struct {
+	Upstream string `yaml:"upstream"`
+	// Downstream are the components depending on the upstream one.
+	// "*" stands for all the components with a higher rank.
+	Downstream []string `yaml:"downstream"`
+}
+
+// DependencyGraph holds the dependencies between the components by their name.
+// The graph is acyclic.
+type DependencyGraph struct {
+	// downstreams are the components depending directly on the component.
+	downstreams map[string][]string
+	// upstreams are the components the component directly depends on.
+	upstreams map[string][]string
+	// linked are the components listed explicitly as the direct upstreams
+	// or downstreams of the component. Unlike the other edges, they don't
+	// include the "*" expansion and are used for grouping the signals:
+	// the broad dependencies would merge unrelated incidents.
+	linked map[string][]string
+}
+
+// ComponentDependencies returns the dependency graph defined in the
+// component mappings.
+func ComponentDependencies() *DependencyGraph {
+	return mappings.Load().dependencies
+}
+
+// compileDependencies validates the dependencies against the components
+// and builds the graph from them.
+func compileDependencies(deps []ComponentDependency, components []layerComponentMatcher) (*DependencyGraph, error) {
+	ranks := make(map[string]int, len(components))
+	for _, c := range components {
+		ranks[c.component] = c.rank
+	}
+
+	g := &DependencyGraph{
+		downstreams: make(map[string][]string),
+		upstreams:   make(map[string][]string),
+		linked:      make(map[string][]string),
+	}
+	addEdge := func(upstream, downstream string) {
+		if slices.Contains(g.downstreams[upstream], downstream) {
+			return
+		}
+		g.downstreams[upstream] = append(g.downstreams[upstream], downstream)
+		g.upstreams[downstream] = append(g.upstreams[downstream], upstream)
+	}
+
+	for i, d := range deps {
+		if d.Upstream == "" {
+			return nil, fmt.Errorf("dependency #%d: missing upstream component", i)
+		}
+		rank, ok := ranks[d.Upstream]
+		if !ok {
+			return nil, fmt.Errorf("dependency of %q: unknown component", d.Upstream)
+		}
+		if len(d.Downstream) == 0 {
+			return nil, fmt.Errorf("dependency of %q: no downstream components", d.Upstream)
+		}
+		for _, downstream := range d.Downstream {
+			switch {
+			case downstream == allComponents:
+				for _, c := range components {
+					if c.rank > rank {
+						addEdge(d.Upstream, c.component)
+					}
+				}
+			case downstream == d.Upstream:
+				return nil, fmt.Errorf("dependency of %q: component can't depend on itself", d.Upstream)
+			default:
+				if _, ok := ranks[downstream]; !ok {
+					return nil, fmt.Errorf("dependency of %q: unknown downstream component %q", d.Upstream, downstream)
+				}
+				addEdge(d.Upstream, downstream)
+				if !slices.Contains(g.linked[d.Upstream], downstream) {
+					g.linked[d.Upstream] = append(g.linked[d.Upstream], downstream)
+					g.linked[downstream] = append(g.linked[downstream], d.Upstream)
+				}
+			}
+		}
+	}
+
+	for _, c := range slices.Sorted(maps.Keys(g.downstreams)) {
+		if slices.Contains(g.Downstreams(c), c) {
+			return nil, fmt.Errorf("dependency of %q: cycle detected", c)
+		}
+	}
+	return g, nil
+}
+
+// Downstreams returns the components depending on the component,
+// directly or transitively, ordered by name.
+func (g *DependencyGraph) Downstreams(component string) []string {
+	return g.reachable(component, g.downstreams)
+}
+
+// Upstreams returns the components the component depends on,
+// directly or transitively, ordered by name.
+func (g *DependencyGraph) Upstreams(component string) []string {
+	return g.reachable(component, g.upstreams)
+}
+
+func (g *DependencyGraph) reachable(component string, edges map[string][]string) []string {
+	if g == nil {
+		return nil
+	}
+	seen := make(map[string]struct{})
+	queue := slices.Clone(edges[component])
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if _, ok := seen[c]; ok {
+			continue
+		}
+		seen[c] = struct{}{}
+		queue = append(queue, edges[c]...)
+	}
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// DependsOn returns true if the component depends on the upstream
+// component, directly or transitively.
+func (g *DependencyGraph) DependsOn(component, upstream string) bool {
+	return slices.Contains(g.Upstreams(component), upstream)
+}
+
+// linkedTo returns true if one of the components is listed explicitly
+// as the direct dependency of the other one.
+func (g *DependencyGraph) linkedTo(a, b string) bool {
+	return g != nil && slices.Contains(g.linked[a], b)
+}
+
+// UpstreamComponents returns the components other components of the set
+// depend on, while not depending on any of them themselves, ordered by name.
+// These are the components the problem most likely originates from.
+func (g *DependencyGraph) UpstreamComponents(components []string) []string {
+	var ret []string
+	for _, c := range components {
+		hasDownstream, hasUpstream := false, false
+		for _, other := range components {
+			hasDownstream = hasDownstream || (other != c && g.DependsOn(other, c))
+			hasUpstream = hasUpstream || (other != c && g.DependsOn(c, other))
+		}
+		if hasDownstream && !hasUpstream && !slices.Contains(ret, c) {
+			ret = append(ret, c)
+		}
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// dependencyGroupMatcher returns the matcher linking the alert with the
+// alerts of the components it directly and explicitly depends on or that
+// directly and explicitly depend on it. It's nil for the alerts of the
+// components without such dependencies and for the other signals: the
+// conditions and events are long-lasting and already grouped by the
+// cluster operator rules.
+func dependencyGroupMatcher(labels model.LabelSet) *GroupMatcher {
+	if srcType(labels) != Alert {
+		return nil
+	}
+	component := signalComponent(labels)
+	if component == "" || len(ComponentDependencies().linked[component]) == 0 {
+		return nil
+	}
+	g := newGroupMatcherExact(model.LabelSet{dependencyLabel: model.LabelValue(component)})
+	g.Distance = dependencyMatchDistance
+	return g
+}
+
+// matchesDependency returns true if the dependency matcher of the group
+// links directly to the component.
+func (g *GroupMatcher) matchesDependency(component string) bool {
+	if component == "" {
+		return false
+	}
+	deps := ComponentDependencies()
+	for _, m := range g.Matchers {
+		if deps.linkedTo(component, string(m.Labels[dependencyLabel])) {
+			return true
+		}
+	}
+	return false
+}
+
+// signalComponent returns the component of the signal the same way
+// as when mapping the signal to the components.
+func signalComponent(labels model.LabelSet) string {
+	_, component, _ := determineComponent(labels)
+	return component
+}
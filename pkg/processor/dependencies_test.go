@@ -0,0 +1,155 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultMappingsDependencies(t *testing.T) {
+	deps := DefaultMappings().dependencies
+
+	assert.Equal(t, []string{"etcd"}, deps.Upstreams("kube-apiserver"))
+	assert.Equal(t, []string{"dns", "etcd", "kube-apiserver", "network"}, deps.Upstreams("ingress"))
+	assert.Equal(t, []string{"etcd", "kube-apiserver"}, deps.Upstreams("monitoring"))
+	// the more fundamental components don't depend on the kube-apiserver
+	assert.Empty(t, deps.Upstreams("compute"))
+	assert.Empty(t, deps.Upstreams("etcd"))
+
+	assert.True(t, deps.DependsOn("ingress", "network"))
+	assert.False(t, deps.DependsOn("network", "ingress"))
+	// only the explicit direct dependencies link the components for grouping
+	assert.True(t, deps.linkedTo("kube-apiserver", "etcd"))
+	assert.True(t, deps.linkedTo("etcd", "kube-apiserver"))
+	assert.False(t, deps.linkedTo("ingress", "network"))
+	assert.False(t, deps.linkedTo("kube-apiserver", "monitoring"))
+}
+
+func TestParseMappings_Dependencies(t *testing.T) {
+	components := `
+components:
+- {layer: core, component: a, rank: 10, matchers: [{label: namespace, values: [a]}]}
+- {layer: core, component: b, rank: 20, matchers: [{label: namespace, values: [b]}]}
+- {layer: core, component: c, rank: 30, matchers: [{label: namespace, values: [c]}]}
+`
+	tests := []struct {
+		name        string
+		deps        string
+		downstreams map[string][]string
+		wantErr     string
+	}{
+		{
+			name:        "no dependencies",
+			downstreams: map[string][]string{"a": nil, "b": nil, "c": nil},
+		},
+		{
+			name: "cycle",
+			deps: `
+dependencies:
+- {upstream: c, downstream: [a]}
+- {upstream: a, downstream: ["*"]}
+`,
+			wantErr: `dependency of "a": cycle detected`,
+		},
+		{
+			name: "all the components with higher rank",
+			deps: `
+dependencies:
+- {upstream: b, downstream: ["*"]}
+- {upstream: c, downstream: [a]}
+`,
+			downstreams: map[string][]string{"a": nil, "b": {"a", "c"}, "c": {"a"}},
+		},
+		{
+			name:    "unknown upstream",
+			deps:    `dependencies: [{upstream: d, downstream: [a]}]`,
+			wantErr: `dependency of "d": unknown component`,
+		},
+		{
+			name:    "unknown downstream",
+			deps:    `dependencies: [{upstream: a, downstream: [d]}]`,
+			wantErr: `dependency of "a": unknown downstream component "d"`,
+		},
+		{
+			name:    "self dependency",
+			deps:    `dependencies: [{upstream: a, downstream: [a]}]`,
+			wantErr: `dependency of "a": component can't depend on itself`,
+		},
+		{
+			name:    "no downstream",
+			deps:    `dependencies: [{upstream: a}]`,
+			wantErr: `dependency of "a": no downstream components`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseMappings([]byte(components + tt.deps))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			for c, want := range tt.downstreams {
+				assert.Equal(t, want, m.dependencies.Downstreams(c), c)
+			}
+		})
+	}
+}
+
+func TestDependencyGraph_UpstreamComponents(t *testing.T) {
+	deps := DefaultMappings().dependencies
+
+	assert.Equal(t, []string{"etcd"}, deps.UpstreamComponents([]string{"kube-apiserver", "etcd", "monitoring"}))
+	assert.Equal(t, []string{"network"}, deps.UpstreamComponents([]string{"ingress", "network"}))
+	// no dependency within the components
+	assert.Empty(t, deps.UpstreamComponents([]string{"monitoring", "network"}))
+	assert.Empty(t, deps.UpstreamComponents([]string{"etcd"}))
+}
+
+func TestGroupsCollection_DependencyMatching(t *testing.T) {
+	start := model.TimeFromUnixNano(
+		time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+
+	process := func(gc *GroupsCollection, alert model.LabelSet, t model.Time) string {
+		gi := gc.ProcessIntervalsBatch([]Interval{{Metric: alert, Start: t, End: t.Add(time.Minute)}})
+		return gi[0].GroupMatcher.RootGroupID
+	}
+	network := model.LabelSet{"alertname": "OVNKubernetesNodeOVSOverflowUserspace", "namespace": "openshift-ovn-kubernetes"}
+	monitoring := model.LabelSet{"alertname": "PrometheusRuleFailures", "namespace": "openshift-monitoring"}
+	dns := model.LabelSet{"alertname": "CoreDNSErrorsHigh", "namespace": "openshift-dns"}
+
+	gc := &GroupsCollection{}
+	networkGroup := process(gc, network, start)
+	// the components are not related and outside of the time matching window
+	monitoringGroup := process(gc, monitoring, start.Add(30*time.Minute))
+	assert.NotEqual(t, networkGroup, monitoringGroup)
+
+	// dns depends on the network: the dependency wins over the time proximity
+	assert.Equal(t, networkGroup, process(gc, dns, start.Add(40*time.Minute)))
+
+	// outside of the dependency matching window
+	gc = &GroupsCollection{}
+	networkGroup = process(gc, network, start)
+	assert.NotEqual(t, networkGroup, process(gc, dns, start.Add(2*time.Hour)))
+
+	// without the dependency, the time proximity is used
+	gc = &GroupsCollection{}
+	process(gc, network, start)
+	monitoringGroup = process(gc, monitoring, start.Add(30*time.Minute))
+	assert.Equal(t, monitoringGroup,
+		process(gc, model.LabelSet{"alertname": "KubeStateMetricsListErrors", "namespace": "openshift-kube-state"},
+			start.Add(40*time.Minute)))
+
+	// the "*" dependencies are not used for grouping: the workload alerts
+	// don't join the incident of the etcd, unlike the kube-apiserver ones
+	etcd := model.LabelSet{"alertname": "etcdMembersDown", "namespace": "openshift-etcd"}
+	workload := model.LabelSet{"alertname": "KubePodCrashLooping", "namespace": "openshift-logging"}
+	apiserver := model.LabelSet{"alertname": "KubeAPIErrorBudgetBurn", "namespace": "openshift-kube-apiserver"}
+	gc = &GroupsCollection{}
+	etcdGroup := process(gc, etcd, start)
+	assert.NotEqual(t, etcdGroup, process(gc, workload, start.Add(30*time.Minute)))
+	assert.Equal(t, etcdGroup, process(gc, apiserver, start.Add(40*time.Minute)))
+}
@@ -0,0 +1,165 @@
+package processor
+
+// This file contains logic for discovering the components installed in the cluster.
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/common/model"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	clusterOperatorsGVR = schema.GroupVersionResource{
+		Group:    "config.openshift.io",
+		Version:  "v1",
+		Resource: "clusteroperators",
+	}
+	clusterServiceVersionsGVR = schema.GroupVersionResource{
+		Group:    "operators.coreos.com",
+		Version:  "v1alpha1",
+		Resource: "clusterserviceversions",
+	}
+)
+
+// InstalledResources holds the resources found in the cluster that are used
+// to decide which components are relevant for the cluster.
+type InstalledResources struct {
+	// ClusterOperators are the names of the ClusterOperators.
+	ClusterOperators []string
+	// Operators are the names of the OLM operators, based on the
+	// ClusterServiceVersions without the version suffix.
+	Operators []string
+	// Namespaces are the names of the existing namespaces.
+	Namespaces []string
+}
+
+// installed holds the resources installed in the cluster. When nil,
+// the installed resources are unknown and all the components are considered
+// relevant.
+var installed atomic.Pointer[InstalledResources]
+
+// SetInstalledResources replaces the resources used for deciding which
+// components are relevant for the cluster. Nil means all components are relevant.
+func SetInstalledResources(r *InstalledResources) {
+	installed.Store(r)
+}
+
+// operatorName returns the name of the operator from the ClusterServiceVersion name,
+// e.g. "openshift-gitops-operator" for "openshift-gitops-operator.v1.12.0".
+func operatorName(csvName string) string {
+	name, _, _ := strings.Cut(csvName, ".v")
+	return name
+}
+
+// relevant returns true if the component is relevant for the cluster with the
+// installed resources, i.e.:
+//
+//   - it has a ClusterOperator or an OLM operator with the same name,
+//   - any of its namespace matchers matches an existing namespace, or
+//   - the relevance can't be determined (per-node or no namespace matchers).
+func (r *InstalledResources) relevant(c layerComponentMatcher) bool {
+	if c.perNode || len(c.namespaceMatchers) == 0 {
+		return true
+	}
+	if slices.Contains(r.ClusterOperators, c.component) {
+		return true
+	}
+	for _, op := range r.Operators {
+		if op == c.component || strings.TrimSuffix(op, "-operator") == c.component {
+			return true
+		}
+	}
+	for _, ns := range r.Namespaces {
+		labels := model.LabelSet{"namespace": model.LabelValue(ns)}
+		for _, m := range c.namespaceMatchers {
+			if matches, _ := m.Matches(labels); matches {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ComponentsDiscovery periodically discovers the resources installed in the cluster.
+type ComponentsDiscovery struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	interval      time.Duration
+}
+
+// NewComponentsDiscovery creates a new ComponentsDiscovery.
+func NewComponentsDiscovery(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface,
+	interval time.Duration) *ComponentsDiscovery {
+	return &ComponentsDiscovery{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		interval:      interval,
+	}
+}
+
+// Start starts the discovery in a goroutine and returns immediately.
+func (d *ComponentsDiscovery) Start(ctx context.Context) {
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := d.refresh(ctx); err != nil {
+			slog.Error("Failed to discover installed components, keeping the previous ones", "err", err)
+		}
+	}, d.interval)
+}
+
+func (d *ComponentsDiscovery) refresh(ctx context.Context) error {
+	nsList, err := d.kubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	r := &InstalledResources{}
+	for _, ns := range nsList.Items {
+		r.Namespaces = append(r.Namespaces, ns.Name)
+	}
+
+	r.ClusterOperators, err = d.listNames(ctx, clusterOperatorsGVR)
+	if err != nil {
+		return err
+	}
+
+	csvNames, err := d.listNames(ctx, clusterServiceVersionsGVR)
+	if err != nil {
+		return err
+	}
+	for _, name := range csvNames {
+		op := operatorName(name)
+		if !slices.Contains(r.Operators, op) {
+			r.Operators = append(r.Operators, op)
+		}
+	}
+
+	SetInstalledResources(r)
+	return nil
+}
+
+// listNames lists the names of the resources. Missing resource type (e.g. OLM
+// not being installed) results in an empty list.
+func (d *ComponentsDiscovery) listNames(ctx context.Context, gvr schema.GroupVersionResource) ([]string, error) {
+	list, err := d.dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(list.Items))
+	for _, it := range list.Items {
+		names = append(names, it.GetName())
+	}
+	return names, nil
+}
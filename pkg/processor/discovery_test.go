@@ -0,0 +1,127 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+// testMetricSet is a prom.MetricSet remembering the last update.
+type testMetricSet struct {
+	prom.MetricSet
+	metrics []prom.Metric
+}
+
+func (m *testMetricSet) Update(metrics []prom.Metric) {
+	m.metrics = metrics
+}
+
+func rankedComponents() map[string]ComponentRank {
+	ret := make(map[string]ComponentRank)
+	for _, r := range BuildComponentRanks() {
+		ret[r.Component] = r
+	}
+	return ret
+}
+
+func TestBuildComponentRanks_Installed(t *testing.T) {
+	defer SetInstalledResources(nil)
+
+	// Without the installed resources, all components are relevant.
+	assert.Contains(t, rankedComponents(), "openshift-logging")
+
+	SetInstalledResources(&InstalledResources{
+		ClusterOperators: []string{"etcd"},
+		Operators:        []string{"openshift-gitops-operator"},
+		Namespaces:       []string{"openshift-etcd", "openshift-monitoring"},
+	})
+	ranks := rankedComponents()
+
+	// Per-node and components without namespace matchers.
+	assert.Contains(t, ranks, "compute")
+	// Matching ClusterOperator.
+	assert.Contains(t, ranks, "etcd")
+	// Matching namespace.
+	assert.Contains(t, ranks, "monitoring")
+	// Matching OLM operator.
+	assert.Contains(t, ranks, "openshift-gitops")
+	// Not installed.
+	assert.NotContains(t, ranks, "openshift-logging")
+	assert.NotContains(t, ranks, "kube-scheduler")
+}
+
+func TestComponentsDiscovery_refresh(t *testing.T) {
+	defer SetInstalledResources(nil)
+
+	kubeClient := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "openshift-etcd"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "openshift-gitops"}},
+	)
+
+	newObj := func(gvr schema.GroupVersionResource, kind, namespace, name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(gvr.GroupVersion().String())
+		obj.SetKind(kind)
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		return obj
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			clusterOperatorsGVR:       "ClusterOperatorList",
+			clusterServiceVersionsGVR: "ClusterServiceVersionList",
+		},
+		newObj(clusterOperatorsGVR, "ClusterOperator", "", "etcd"),
+		newObj(clusterServiceVersionsGVR, "ClusterServiceVersion", "openshift-gitops",
+			"openshift-gitops-operator.v1.12.0"),
+		newObj(clusterServiceVersionsGVR, "ClusterServiceVersion", "openshift-etcd",
+			"openshift-gitops-operator.v1.12.0"),
+	)
+
+	d := NewComponentsDiscovery(kubeClient, dynamicClient, time.Minute)
+	require.NoError(t, d.refresh(context.Background()))
+
+	assert.Equal(t, &InstalledResources{
+		ClusterOperators: []string{"etcd"},
+		Operators:        []string{"openshift-gitops-operator"},
+		Namespaces:       []string{"openshift-etcd", "openshift-gitops"},
+	}, installed.Load())
+}
+
+func TestUpdateComponentsMetrics_Healthy(t *testing.T) {
+	defer SetInstalledResources(nil)
+	SetInstalledResources(&InstalledResources{
+		ClusterOperators: []string{"etcd", "kube-apiserver", "dns"},
+	})
+
+	metrics := &testMetricSet{}
+	p := &processor{componentsMetrics: metrics}
+	p.updateComponentsMetrics([]ComponentHealthMap{
+		{Layer: "core", Component: "etcd", Health: Critical, Silenced: "false"},
+		{Layer: "core", Component: "kube-apiserver", Health: Healthy, Silenced: "false"},
+		{Layer: "core", Component: "dns", Health: Warning, Silenced: "true"},
+	})
+
+	healthy := make(map[model.LabelValue]model.LabelValue)
+	for _, m := range metrics.metrics {
+		healthy[m.Labels["component"]] = m.Labels["healthy"]
+	}
+	assert.Equal(t, model.LabelValue("false"), healthy["etcd"])
+	assert.Equal(t, model.LabelValue("true"), healthy["kube-apiserver"])
+	assert.Equal(t, model.LabelValue("true"), healthy["dns"])
+	assert.Equal(t, model.LabelValue("true"), healthy["compute"])
+	assert.NotContains(t, healthy, model.LabelValue("openshift-logging"))
+}
@@ -0,0 +1,198 @@
+package processor
+
+// This file contains logic for using the Kubernetes Warning Events as a signal.
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// KubeEventsSignal is the __name__ of the signal built from the Kubernetes Events.
+	KubeEventsSignal = "kube_events"
+
+	// eventsActiveWindow is the time since the last occurrence of the event
+	// during which the event is considered active.
+	eventsActiveWindow = 10 * time.Minute
+
+	// eventsPageSize is the max number of the events loaded in a single
+	// request, the events are listed in pages to limit the load of the
+	// Kubernetes API in the clusters with many events.
+	eventsPageSize = 500
+)
+
+// eventLabels are the labels identifying the events signal, besides the namespace.
+var eventLabels = []model.LabelName{"reason", "kind"}
+
+// eventKey identifies the events aggregated into a single signal.
+type eventKey struct {
+	namespace string
+	reason    string
+	kind      string
+}
+
+// eventInterval holds the aggregated occurrences of the events with the same key.
+type eventInterval struct {
+	start time.Time
+	end   time.Time
+	count int32
+}
+
+// SignalSource provides additional signals to be processed together with the alerts.
+type SignalSource interface {
+	// Signals returns the signals active at the time t.
+	Signals(t time.Time) []model.LabelSet
+}
+
+// EventsIngester periodically loads the Warning Events from the Kubernetes API
+// and turns the repeated ones into signals.
+type EventsIngester struct {
+	client   kubernetes.Interface
+	interval time.Duration
+	// minCount is the minimal number of occurrences for the event to be used.
+	minCount int32
+
+	mtx       sync.Mutex
+	intervals map[eventKey]eventInterval
+}
+
+// NewEventsIngester creates a new EventsIngester.
+func NewEventsIngester(client kubernetes.Interface, interval time.Duration, minCount int) *EventsIngester {
+	return &EventsIngester{
+		client:   client,
+		interval: interval,
+		minCount: int32(minCount),
+	}
+}
+
+// Start starts loading the events in a goroutine and returns immediately.
+func (e *EventsIngester) Start(ctx context.Context) {
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := e.refresh(ctx); err != nil {
+			slog.Error("Failed to load events, keeping the previous ones", "err", err)
+		}
+	}, e.interval)
+}
+
+func (e *EventsIngester) refresh(ctx context.Context) error {
+	intervals := make(map[eventKey]eventInterval)
+	opts := metav1.ListOptions{
+		FieldSelector: "type=" + corev1.EventTypeWarning,
+		Limit:         eventsPageSize,
+	}
+	for {
+		list, err := e.client.CoreV1().Events(metav1.NamespaceAll).List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		aggregateEvents(intervals, list.Items)
+		if list.Continue == "" {
+			break
+		}
+		opts.Continue = list.Continue
+	}
+
+	e.mtx.Lock()
+	e.intervals = intervals
+	e.mtx.Unlock()
+	return nil
+}
+
+// Signals returns the signals for the repeated events occurring
+// within the eventsActiveWindow before the time t.
+func (e *EventsIngester) Signals(t time.Time) []model.LabelSet {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	var ret []model.LabelSet
+	for k, i := range e.intervals {
+		if i.count < e.minCount || i.start.After(t) || i.end.Before(t.Add(-eventsActiveWindow)) {
+			continue
+		}
+		ret = append(ret, eventSignal(k))
+	}
+	return ret
+}
+
+// aggregateEvents aggregates the Warning Events by the namespace, reason
+// and the kind of the involved object into the intervals.
+func aggregateEvents(ret map[eventKey]eventInterval, events []corev1.Event) {
+	for _, ev := range events {
+		if ev.Type != corev1.EventTypeWarning {
+			continue
+		}
+		k := eventKey{
+			namespace: ev.Namespace,
+			reason:    ev.Reason,
+			kind:      ev.InvolvedObject.Kind,
+		}
+		start, end, count := eventOccurrences(ev)
+
+		i, ok := ret[k]
+		if !ok {
+			ret[k] = eventInterval{start: start, end: end, count: count}
+			continue
+		}
+		if start.Before(i.start) {
+			i.start = start
+		}
+		if end.After(i.end) {
+			i.end = end
+		}
+		i.count += count
+		ret[k] = i
+	}
+}
+
+// eventOccurrences returns the first and last occurrence of the event and
+// the number of occurrences, taking into account both the legacy fields
+// and the event series.
+func eventOccurrences(ev corev1.Event) (start, end time.Time, count int32) {
+	start = ev.FirstTimestamp.Time
+	if start.IsZero() {
+		start = ev.EventTime.Time
+	}
+	if start.IsZero() {
+		start = ev.CreationTimestamp.Time
+	}
+
+	end = ev.LastTimestamp.Time
+	count = ev.Count
+	if ev.Series != nil {
+		if ev.Series.LastObservedTime.After(end) {
+			end = ev.Series.LastObservedTime.Time
+		}
+		count = max(count, ev.Series.Count)
+	}
+	if end.IsZero() {
+		end = start
+	}
+	return start, end, max(count, 1)
+}
+
+// eventSignal returns the signal labels for the aggregated events.
+func eventSignal(k eventKey) model.LabelSet {
+	ret := model.LabelSet{
+		model.MetricNameLabel: KubeEventsSignal,
+		"reason":              model.LabelValue(k.reason),
+		"kind":                model.LabelValue(k.kind),
+		"severity":            model.LabelValue(Warning.String()),
+	}
+	if k.namespace != "" {
+		ret["namespace"] = model.LabelValue(k.namespace)
+	}
+	return ret
+}
+
+// isEventSignal returns true if the labels represent the Kubernetes Events.
+func isEventSignal(labels model.LabelSet) bool {
+	return labels[model.MetricNameLabel] == KubeEventsSignal
+}
@@ -0,0 +1,115 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testKubeEvent(name, namespace, eventType, reason, kind string, count int32, first, last time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Type:           eventType,
+		Reason:         reason,
+		InvolvedObject: corev1.ObjectReference{Kind: kind, Namespace: namespace, Name: name},
+		Count:          count,
+		FirstTimestamp: metav1.NewTime(first),
+		LastTimestamp:  metav1.NewTime(last),
+	}
+}
+
+func TestEventsIngester_Signals(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	client := fake.NewClientset(
+		// Repeated events for different pods are aggregated.
+		testKubeEvent("pod-a", "openshift-etcd", corev1.EventTypeWarning, "BackOff", "Pod",
+			1, now.Add(-time.Hour), now.Add(-time.Minute)),
+		testKubeEvent("pod-b", "openshift-etcd", corev1.EventTypeWarning, "BackOff", "Pod",
+			1, now.Add(-30*time.Minute), now.Add(-2*time.Minute)),
+		// Single occurrence.
+		testKubeEvent("pod-c", "openshift-etcd", corev1.EventTypeWarning, "FailedMount", "Pod",
+			1, now.Add(-time.Minute), now.Add(-time.Minute)),
+		// Not active anymore.
+		testKubeEvent("pod-d", "openshift-ingress", corev1.EventTypeWarning, "FailedScheduling", "Pod",
+			5, now.Add(-time.Hour), now.Add(-30*time.Minute)),
+		// Not a warning.
+		testKubeEvent("pod-e", "openshift-etcd", corev1.EventTypeNormal, "Pulled", "Pod",
+			5, now.Add(-time.Hour), now.Add(-time.Minute)),
+	)
+
+	e := NewEventsIngester(client, time.Minute, 2)
+	require.NoError(t, e.refresh(context.Background()))
+
+	signals := e.Signals(now)
+	assert.Equal(t, []model.LabelSet{
+		{
+			"__name__": "kube_events", "namespace": "openshift-etcd", "reason": "BackOff",
+			"kind": "Pod", "severity": "warning",
+		},
+	}, signals)
+
+	healthMaps := MapAlerts(signals)
+	assert.Equal(t, Event, healthMaps[0].SrcType)
+	assert.Equal(t, model.LabelSet{
+		"namespace": "openshift-etcd", "reason": "BackOff", "kind": "Pod", "severity": "warning",
+	}, healthMaps[0].SrcLabels)
+	assert.Equal(t, "etcd", healthMaps[0].Component)
+}
+
+func TestEventsIngester_Paging(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	client := fake.NewClientset()
+	pages := map[string]*corev1.EventList{
+		"": {
+			ListMeta: metav1.ListMeta{Continue: "page-2"},
+			Items: []corev1.Event{*testKubeEvent("pod-a", "openshift-etcd", corev1.EventTypeWarning, "BackOff", "Pod",
+				1, now.Add(-time.Hour), now.Add(-time.Minute))},
+		},
+		"page-2": {
+			Items: []corev1.Event{*testKubeEvent("pod-b", "openshift-etcd", corev1.EventTypeWarning, "BackOff", "Pod",
+				1, now.Add(-30*time.Minute), now.Add(-2*time.Minute))},
+		},
+	}
+	var limits []int64
+	client.PrependReactor("list", "events", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		opts := action.(k8stesting.ListActionImpl).ListOptions
+		limits = append(limits, opts.Limit)
+		return true, pages[opts.Continue], nil
+	})
+
+	e := NewEventsIngester(client, time.Minute, 2)
+	require.NoError(t, e.refresh(context.Background()))
+
+	assert.Equal(t, []int64{eventsPageSize, eventsPageSize}, limits)
+	// the events from both pages are aggregated together
+	assert.Equal(t, []model.LabelSet{
+		{
+			"__name__": "kube_events", "namespace": "openshift-etcd", "reason": "BackOff",
+			"kind": "Pod", "severity": "warning",
+		},
+	}, e.Signals(now))
+}
+
+func TestEventOccurrences_Series(t *testing.T) {
+	created := time.Unix(1000, 0)
+	observed := time.Unix(2000, 0)
+	start, end, count := eventOccurrences(corev1.Event{
+		EventTime: metav1.NewMicroTime(created),
+		Series: &corev1.EventSeries{
+			Count:            4,
+			LastObservedTime: metav1.NewMicroTime(observed),
+		},
+	})
+	assert.True(t, start.Equal(created))
+	assert.True(t, end.Equal(observed))
+	assert.Equal(t, int32(4), count)
+}
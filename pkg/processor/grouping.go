@@ -0,0 +1,175 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert/yaml"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/common"
+)
+
+// GroupingConfig holds the parameters of grouping the alerts into incidents.
+type GroupingConfig struct {
+	// FuzzyMatchWindow is the maximal time between the alert and the last
+	// modification of a group for fuzzy matching. Unless we have a direct
+	// match, we try fuzzy matching.
+	FuzzyMatchWindow time.Duration `yaml:"fuzzyMatchWindow"`
+
+	// TimeMatchWindow is the maximal time between the alert and the last
+	// modification of a group for pure time-based matching. If we have no
+	// match yet, we try to match on the time, but just very close events.
+	TimeMatchWindow time.Duration `yaml:"timeMatchWindow"`
+
+	// DirectMatchWindow is the maximal time between the alert and the end
+	// of a group for direct (exact) matching. No match yet: look for direct
+	// matches deeper in the past.
+	DirectMatchWindow time.Duration `yaml:"directMatchWindow"`
+
+	// DependencyMatchWindow is the maximal time between the alert and the last
+	// modification of a group for matching the alerts of the components
+	// depending on each other, as defined in the component mappings. Such
+	// matches are preferred over the pure time-based ones.
+	DependencyMatchWindow time.Duration `yaml:"dependencyMatchWindow"`
+
+	// SubsetLabels are the label keys used for matching with distance 1.
+	// The alerts with the same values of these labels are considered close
+	// enough to belong to the same group.
+	SubsetLabels []model.LabelName `yaml:"subsetLabels"`
+
+	// FuzzyLabels are the label keys used for fuzzy matching with distance 2.
+	// Any single label with the same value is enough for the match.
+	FuzzyLabels []model.LabelName `yaml:"fuzzyLabels"`
+
+	// Rules encode the domain knowledge about grouping specific alerts.
+	Rules GroupingRules `yaml:"rules"`
+}
+
+// DefaultGroupingConfig returns the built-in grouping parameters.
+func DefaultGroupingConfig() *GroupingConfig {
+	return &GroupingConfig{
+		FuzzyMatchWindow:      24 * time.Hour,
+		TimeMatchWindow:       15 * time.Minute,
+		DirectMatchWindow:     5 * 24 * time.Hour,
+		DependencyMatchWindow: time.Hour,
+		SubsetLabels:          []model.LabelName{"namespace", "alertname", "service", "job", "container"},
+		FuzzyLabels:           []model.LabelName{"alertname", "namespace"},
+		Rules:                 defaultGroupingRules(),
+	}
+}
+
+// LoadGroupingConfig reads the grouping config from the YAML file on the given path.
+// The parameters missing in the file are set to the default values.
+func LoadGroupingConfig(path string) (*GroupingConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGroupingConfig(data)
+}
+
+// ParseGroupingConfig parses and validates the YAML representation
+// of the grouping config. The parameters missing in the data are set
+// to the default values and the rules are merged with the default rules.
+func ParseGroupingConfig(data []byte) (*GroupingConfig, error) {
+	config := DefaultGroupingConfig()
+	config.Rules = GroupingRules{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse grouping config: %w", err)
+	}
+	rules, err := mergeDefaultRules(config.Rules)
+	if err != nil {
+		return nil, err
+	}
+	config.Rules = rules
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// BuildGroupingConfig returns the grouping config loaded from the file set
+// in the options (or the default one when not set) with the parameters
+// overridden by the options.
+func BuildGroupingConfig(o common.GroupingOptions) (*GroupingConfig, error) {
+	config := DefaultGroupingConfig()
+	if o.GroupingConfigPath != "" {
+		var err error
+		config, err = LoadGroupingConfig(o.GroupingConfigPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	config.Override(GroupingConfig{
+		FuzzyMatchWindow:      o.FuzzyMatchWindow,
+		TimeMatchWindow:       o.TimeMatchWindow,
+		DirectMatchWindow:     o.DirectMatchWindow,
+		DependencyMatchWindow: o.DependencyMatchWindow,
+		SubsetLabels:          toLabelNames(o.SubsetLabels),
+		FuzzyLabels:           toLabelNames(o.FuzzyLabels),
+	})
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Override replaces the parameters of the config with the non-zero
+// parameters of the other config.
+func (c *GroupingConfig) Override(other GroupingConfig) {
+	if other.FuzzyMatchWindow != 0 {
+		c.FuzzyMatchWindow = other.FuzzyMatchWindow
+	}
+	if other.TimeMatchWindow != 0 {
+		c.TimeMatchWindow = other.TimeMatchWindow
+	}
+	if other.DirectMatchWindow != 0 {
+		c.DirectMatchWindow = other.DirectMatchWindow
+	}
+	if other.DependencyMatchWindow != 0 {
+		c.DependencyMatchWindow = other.DependencyMatchWindow
+	}
+	if len(other.SubsetLabels) > 0 {
+		c.SubsetLabels = slices.Clone(other.SubsetLabels)
+	}
+	if len(other.FuzzyLabels) > 0 {
+		c.FuzzyLabels = slices.Clone(other.FuzzyLabels)
+	}
+}
+
+// Validate checks the config is consistent.
+func (c *GroupingConfig) Validate() error {
+	if c.FuzzyMatchWindow <= 0 {
+		return errors.New("fuzzyMatchWindow must be positive")
+	}
+	if c.TimeMatchWindow <= 0 {
+		return errors.New("timeMatchWindow must be positive")
+	}
+	if c.DirectMatchWindow <= 0 {
+		return errors.New("directMatchWindow must be positive")
+	}
+	if c.DependencyMatchWindow <= 0 {
+		return errors.New("dependencyMatchWindow must be positive")
+	}
+	if len(c.SubsetLabels) == 0 {
+		return errors.New("subsetLabels must not be empty")
+	}
+	for _, l := range append(slices.Clone(c.SubsetLabels), c.FuzzyLabels...) {
+		if !l.IsValid() {
+			return fmt.Errorf("invalid label name %q", l)
+		}
+	}
+	return c.Rules.Validate()
+}
+
+func toLabelNames(labels []string) []model.LabelName {
+	ret := make([]model.LabelName, 0, len(labels))
+	for _, l := range labels {
+		ret = append(ret, model.LabelName(l))
+	}
+	return ret
+}
@@ -0,0 +1,334 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/prometheus/common/model"
+)
+
+// This file contains the rules to encode domain knowledge about the alerts
+// into the incidents grouping.
+//
+// The always-group and group-by rules are implemented via synthetic labels:
+// the alerts matching the rule get an extra label when being matched
+// against the groups, and the groups get a distance 1 matcher on this label.
+
+const (
+	alwaysGroupLabelPrefix = "__always_group_"
+	groupByLabelPrefix     = "__group_by_"
+)
+
+var ruleNameRe = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// GroupingRules holds the rules for grouping specific alerts.
+type GroupingRules struct {
+	// AlwaysGroup rules group together the alerts matching the rule,
+	// e.g. known pairs of alerts with different names.
+	AlwaysGroup []AlwaysGroupRule `yaml:"alwaysGroup"`
+
+	// NeverFuzzy lists the alerts that are never fuzzy-matched with other alerts,
+	// e.g. alerts that are always firing.
+	NeverFuzzy []AlertMatcher `yaml:"neverFuzzy"`
+
+	// GroupBy rules group the alerts matching the rule by the values
+	// of the given labels.
+	GroupBy []GroupByRule `yaml:"groupBy"`
+
+	// Disable lists the names of the default rules to be disabled.
+	Disable []string `yaml:"disable"`
+}
+
+// AlertMatcher matches the alerts by the label values. The alert matches if
+// for every label in the matcher, the alert label value is one of the values.
+type AlertMatcher map[model.LabelName][]model.LabelValue
+
+// Matches returns true if the labels satisfy the matcher.
+func (m AlertMatcher) Matches(labels model.LabelSet) bool {
+	for name, values := range m {
+		if !slices.Contains(values, labels[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// AlwaysGroupRule groups together the alerts matching any of the matchers.
+type AlwaysGroupRule struct {
+	Name   string         `yaml:"name"`
+	Alerts []AlertMatcher `yaml:"alerts"`
+}
+
+func (r AlwaysGroupRule) label() model.LabelName {
+	return model.LabelName(alwaysGroupLabelPrefix + r.Name)
+}
+
+func (r AlwaysGroupRule) matches(labels model.LabelSet) bool {
+	for _, m := range r.Alerts {
+		if m.Matches(labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupByRule groups the alerts matching the matcher, or any of the alert
+// matchers, by the values of the labels.
+type GroupByRule struct {
+	Name   string            `yaml:"name"`
+	Match  AlertMatcher      `yaml:"match"`
+	Alerts []AlertMatcher    `yaml:"alerts"`
+	Labels []model.LabelName `yaml:"labels"`
+}
+
+func (r GroupByRule) label() model.LabelName {
+	return model.LabelName(groupByLabelPrefix + r.Name)
+}
+
+// matches returns true if the alert matches the rule and has all the
+// labels to group by.
+func (r GroupByRule) matches(labels model.LabelSet) bool {
+	if !slices.ContainsFunc(r.matchers(), func(m AlertMatcher) bool { return m.Matches(labels) }) {
+		return false
+	}
+	for _, l := range r.Labels {
+		if _, ok := labels[l]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchers returns all the alert matchers of the rule.
+func (r GroupByRule) matchers() []AlertMatcher {
+	if len(r.Match) == 0 {
+		return r.Alerts
+	}
+	return append([]AlertMatcher{r.Match}, r.Alerts...)
+}
+
+// neverFuzzy returns true if the alert should not be fuzzy-matched.
+func (r *GroupingRules) neverFuzzy(labels model.LabelSet) bool {
+	for _, m := range r.NeverFuzzy {
+		if m.Matches(labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// rulesLabels returns the synthetic labels for the rules the alert matches.
+func (r *GroupingRules) rulesLabels(labels model.LabelSet) model.LabelSet {
+	var ret model.LabelSet
+	add := func(name model.LabelName) {
+		if ret == nil {
+			ret = make(model.LabelSet)
+		}
+		ret[name] = "true"
+	}
+	for _, rule := range r.AlwaysGroup {
+		if rule.matches(labels) {
+			add(rule.label())
+		}
+	}
+	for _, rule := range r.GroupBy {
+		if rule.matches(labels) {
+			add(rule.label())
+		}
+	}
+	return ret
+}
+
+// withRulesLabels returns the labels extended with the synthetic labels
+// for the rules the alert matches. The original labels are not modified.
+func (r *GroupingRules) withRulesLabels(labels model.LabelSet) model.LabelSet {
+	rulesLabels := r.rulesLabels(labels)
+	if len(rulesLabels) == 0 {
+		return labels
+	}
+	return labels.Merge(rulesLabels)
+}
+
+// rulesGroupMatchers returns the distance 1 matchers for the rules the alert matches.
+func (r *GroupingRules) rulesGroupMatchers(labels model.LabelSet) []*GroupMatcher {
+	var ret []*GroupMatcher
+	for _, rule := range r.AlwaysGroup {
+		if rule.matches(labels) {
+			ret = append(ret, newGroupMatcherExact(model.LabelSet{rule.label(): "true"}))
+		}
+	}
+	for _, rule := range r.GroupBy {
+		if rule.matches(labels) {
+			ruleLabels := getMapSubset(labels, rule.Labels...)
+			ruleLabels[rule.label()] = "true"
+			ret = append(ret, newGroupMatcherExact(ruleLabels))
+		}
+	}
+	for _, g := range ret {
+		g.Distance = 1
+	}
+	return ret
+}
+
+// Validate checks the rules are consistent.
+func (r *GroupingRules) Validate() error {
+	names := make(map[model.LabelName]struct{})
+	checkName := func(kind, name string, label model.LabelName) error {
+		if !ruleNameRe.MatchString(name) {
+			return fmt.Errorf("%s rule %q: name must consist of alphanumeric characters and '_'", kind, name)
+		}
+		if _, ok := names[label]; ok {
+			return fmt.Errorf("%s rule %q: defined multiple times", kind, name)
+		}
+		names[label] = struct{}{}
+		return nil
+	}
+
+	for _, rule := range r.AlwaysGroup {
+		if err := checkName("alwaysGroup", rule.Name, rule.label()); err != nil {
+			return err
+		}
+		if len(rule.Alerts) == 0 {
+			return fmt.Errorf("alwaysGroup rule %q: no alert matchers", rule.Name)
+		}
+		for _, m := range rule.Alerts {
+			if len(m) == 0 {
+				return fmt.Errorf("alwaysGroup rule %q: empty alert matcher", rule.Name)
+			}
+		}
+	}
+	for _, m := range r.NeverFuzzy {
+		if len(m) == 0 {
+			return errors.New("neverFuzzy: empty alert matcher")
+		}
+	}
+	for _, rule := range r.GroupBy {
+		if err := checkName("groupBy", rule.Name, rule.label()); err != nil {
+			return err
+		}
+		if len(rule.Match) == 0 && len(rule.Alerts) == 0 {
+			return fmt.Errorf("groupBy rule %q: empty alert matcher", rule.Name)
+		}
+		for _, m := range rule.Alerts {
+			if len(m) == 0 {
+				return fmt.Errorf("groupBy rule %q: empty alert matcher", rule.Name)
+			}
+		}
+		if len(rule.Labels) == 0 {
+			return fmt.Errorf("groupBy rule %q: no labels to group by", rule.Name)
+		}
+	}
+	return nil
+}
+
+// mergeDefaultRules returns the default rules merged with the user rules.
+// The user rules replace the default rules of the same name and the user
+// neverFuzzy matchers are added to the default ones. The default rules
+// listed in Disable are dropped.
+func mergeDefaultRules(user GroupingRules) (GroupingRules, error) {
+	defaults := defaultGroupingRules()
+	neverFuzzy := defaultNeverFuzzyRules()
+
+	known := make(map[string]struct{})
+	for _, r := range defaults.AlwaysGroup {
+		known[r.Name] = struct{}{}
+	}
+	for _, r := range defaults.GroupBy {
+		known[r.Name] = struct{}{}
+	}
+	for _, r := range neverFuzzy {
+		known[r.name] = struct{}{}
+	}
+	disabled := make(map[string]struct{}, len(user.Disable))
+	for _, name := range user.Disable {
+		if _, ok := known[name]; !ok {
+			return GroupingRules{}, fmt.Errorf("disable: unknown default rule %q", name)
+		}
+		disabled[name] = struct{}{}
+	}
+
+	ret := GroupingRules{
+		AlwaysGroup: mergeRulesByName(defaults.AlwaysGroup, user.AlwaysGroup, disabled,
+			func(r AlwaysGroupRule) string { return r.Name }),
+		GroupBy: mergeRulesByName(defaults.GroupBy, user.GroupBy, disabled,
+			func(r GroupByRule) string { return r.Name }),
+		Disable: user.Disable,
+	}
+	for _, r := range neverFuzzy {
+		if _, ok := disabled[r.name]; !ok {
+			ret.NeverFuzzy = append(ret.NeverFuzzy, r.matcher)
+		}
+	}
+	ret.NeverFuzzy = append(ret.NeverFuzzy, user.NeverFuzzy...)
+	return ret, nil
+}
+
+// mergeRulesByName returns the default rules that are neither disabled nor
+// replaced by the user rules of the same name, followed by the user rules.
+func mergeRulesByName[T any](defaults, user []T, disabled map[string]struct{}, name func(T) string) []T {
+	var ret []T
+	for _, d := range defaults {
+		if _, ok := disabled[name(d)]; ok {
+			continue
+		}
+		if slices.ContainsFunc(user, func(u T) bool { return name(u) == name(d) }) {
+			continue
+		}
+		ret = append(ret, d)
+	}
+	return append(ret, user...)
+}
+
+// namedAlertMatcher is a default neverFuzzy matcher, named so that
+// it can be disabled.
+type namedAlertMatcher struct {
+	name    string
+	matcher AlertMatcher
+}
+
+func defaultNeverFuzzyRules() []namedAlertMatcher {
+	return []namedAlertMatcher{
+		{
+			name:    "watchdog",
+			matcher: AlertMatcher{"alertname": {"Watchdog"}, "namespace": {"openshift-monitoring"}},
+		},
+		{
+			name: "alertmanager_receivers_not_configured",
+			matcher: AlertMatcher{"alertname": {"AlertmanagerReceiversNotConfigured"},
+				"namespace": {"openshift-monitoring"}},
+		},
+	}
+}
+
+// defaultGroupingRules returns the built-in grouping rules.
+func defaultGroupingRules() GroupingRules {
+	var neverFuzzy []AlertMatcher
+	for _, r := range defaultNeverFuzzyRules() {
+		neverFuzzy = append(neverFuzzy, r.matcher)
+	}
+	return GroupingRules{
+		AlwaysGroup: []AlwaysGroupRule{
+			{
+				Name: "api_removed",
+				Alerts: []AlertMatcher{
+					{"alertname": {"APIRemovedInNextReleaseInUse", "APIRemovedInNextEUSReleaseInUse"}},
+				},
+			},
+		},
+		NeverFuzzy: neverFuzzy,
+		GroupBy: []GroupByRule{
+			{
+				// The ClusterOperator conditions and the CVO alerts about the
+				// same operator belong to the same incident.
+				Name: "cluster_operator",
+				Alerts: []AlertMatcher{
+					{"alertname": {"ClusterOperatorDown", "ClusterOperatorDegraded"}},
+					{model.MetricNameLabel: {ClusterOperatorConditionsMetric}},
+				},
+				Labels: []model.LabelName{"name"},
+			},
+		},
+	}
+}
@@ -0,0 +1,247 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupingRules(t *testing.T) {
+	start := model.TimeFromUnixNano(
+		time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+
+	config, err := ParseGroupingConfig([]byte(`
+rules:
+  alwaysGroup:
+  - name: etcd_api
+    alerts:
+    - alertname: [etcdHighNumberOfLeaderChanges]
+    - alertname: [KubeAPIErrorBudgetBurn]
+  neverFuzzy:
+  - alertname: [KubePodNotReady]
+  groupBy:
+  - name: cluster_operator
+    match:
+      alertname: [ClusterOperatorDown, ClusterOperatorDegraded]
+    labels: [name]
+`))
+	require.NoError(t, err)
+
+	process := func(gc *GroupsCollection, alert model.LabelSet, t model.Time) string {
+		gi := gc.ProcessIntervalsBatch([]Interval{{Metric: alert, Start: t, End: t.Add(time.Minute)}})
+		return gi[0].GroupMatcher.RootGroupID
+	}
+
+	t.Run("always group", func(t *testing.T) {
+		gc := &GroupsCollection{Config: config}
+		g1 := process(gc, model.LabelSet{"alertname": "etcdHighNumberOfLeaderChanges", "namespace": "openshift-etcd"}, start)
+		g2 := process(gc, model.LabelSet{"alertname": "KubeAPIErrorBudgetBurn", "namespace": "openshift-kube-apiserver"},
+			start.Add(2*time.Hour))
+		assert.Equal(t, g1, g2)
+
+		// Without the rule, the alerts are not related.
+		gc = &GroupsCollection{}
+		g1 = process(gc, model.LabelSet{"alertname": "etcdHighNumberOfLeaderChanges", "namespace": "openshift-etcd"}, start)
+		g2 = process(gc, model.LabelSet{"alertname": "KubeAPIErrorBudgetBurn", "namespace": "openshift-kube-apiserver"},
+			start.Add(2*time.Hour))
+		assert.NotEqual(t, g1, g2)
+	})
+
+	t.Run("never fuzzy", func(t *testing.T) {
+		gc := &GroupsCollection{Config: config}
+		g1 := process(gc, model.LabelSet{"alertname": "KubePodNotReady", "namespace": "foo", "pod": "a"}, start)
+		g2 := process(gc, model.LabelSet{"alertname": "KubePodCrashLooping", "namespace": "foo", "pod": "b"},
+			start.Add(2*time.Hour))
+		assert.NotEqual(t, g1, g2)
+	})
+
+	t.Run("group by", func(t *testing.T) {
+		gc := &GroupsCollection{Config: config}
+		g1 := process(gc, model.LabelSet{"alertname": "ClusterOperatorDown", "namespace": "openshift-cluster-version",
+			"name": "machine-config"}, start)
+		// Different alert for the same operator from a different namespace.
+		g2 := process(gc, model.LabelSet{"alertname": "ClusterOperatorDegraded", "namespace": "openshift-machine-config",
+			"name": "machine-config"}, start.Add(2*time.Hour))
+		assert.Equal(t, g1, g2)
+
+		// Different operator.
+		gc = &GroupsCollection{Config: config}
+		g1 = process(gc, model.LabelSet{"alertname": "ClusterOperatorDown", "namespace": "openshift-cluster-version",
+			"name": "ingress"}, start)
+		g2 = process(gc, model.LabelSet{"alertname": "ClusterOperatorDegraded", "namespace": "openshift-machine-config",
+			"name": "machine-config"}, start.Add(2*time.Hour))
+		assert.NotEqual(t, g1, g2)
+	})
+}
+
+func TestGroupingRules_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "invalid rule name",
+			data: `
+rules:
+  alwaysGroup:
+  - name: foo-bar
+    alerts:
+    - alertname: [Foo]
+`,
+			wantErr: `alwaysGroup rule "foo-bar": name must consist of alphanumeric characters and '_'`,
+		},
+		{
+			name: "duplicate rule name",
+			data: `
+rules:
+  groupBy:
+  - name: foo
+    match: {alertname: [Foo]}
+    labels: [name]
+  - name: foo
+    match: {alertname: [Bar]}
+    labels: [name]
+`,
+			wantErr: `groupBy rule "foo": defined multiple times`,
+		},
+		{
+			name: "no alert matchers",
+			data: `
+rules:
+  alwaysGroup:
+  - name: foo
+`,
+			wantErr: `alwaysGroup rule "foo": no alert matchers`,
+		},
+		{
+			name: "empty never fuzzy matcher",
+			data: `
+rules:
+  neverFuzzy:
+  - {}
+`,
+			wantErr: "neverFuzzy: empty alert matcher",
+		},
+		{
+			name: "group by without labels",
+			data: `
+rules:
+  groupBy:
+  - name: foo
+    match: {alertname: [Foo]}
+`,
+			wantErr: `groupBy rule "foo": no labels to group by`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGroupingConfig([]byte(tt.data))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseGroupingConfig_MergeDefaultRules(t *testing.T) {
+	watchdog := model.LabelSet{"alertname": "Watchdog", "namespace": "openshift-monitoring"}
+	condition := model.LabelSet{"__name__": "cluster_operator_conditions", "name": "dns", "condition": "Degraded"}
+	groupByNames := func(rules GroupingRules) []string {
+		var ret []string
+		for _, r := range rules.GroupBy {
+			ret = append(ret, r.Name)
+		}
+		return ret
+	}
+
+	t.Run("never fuzzy", func(t *testing.T) {
+		config, err := ParseGroupingConfig([]byte(`
+rules:
+  neverFuzzy:
+  - alertname: [KubePodNotReady]
+`))
+		require.NoError(t, err)
+		// The user matchers are added to the default ones.
+		assert.True(t, config.Rules.neverFuzzy(watchdog))
+		assert.True(t, config.Rules.neverFuzzy(model.LabelSet{"alertname": "AlertmanagerReceiversNotConfigured",
+			"namespace": "openshift-monitoring"}))
+		assert.True(t, config.Rules.neverFuzzy(model.LabelSet{"alertname": "KubePodNotReady"}))
+	})
+
+	t.Run("group by", func(t *testing.T) {
+		config, err := ParseGroupingConfig([]byte(`
+rules:
+  groupBy:
+  - name: node
+    match: {alertname: [KubeNodeNotReady]}
+    labels: [node]
+`))
+		require.NoError(t, err)
+		// The default rules are kept next to the user rules.
+		assert.Equal(t, []string{"cluster_operator", "node"}, groupByNames(config.Rules))
+		assert.Contains(t, config.Rules.rulesLabels(condition), model.LabelName(groupByLabelPrefix+"cluster_operator"))
+	})
+
+	t.Run("replace by name", func(t *testing.T) {
+		config, err := ParseGroupingConfig([]byte(`
+rules:
+  groupBy:
+  - name: cluster_operator
+    match: {alertname: [ClusterOperatorDown]}
+    labels: [name]
+`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"cluster_operator"}, groupByNames(config.Rules))
+		assert.Empty(t, config.Rules.rulesLabels(condition))
+	})
+
+	t.Run("disable", func(t *testing.T) {
+		config, err := ParseGroupingConfig([]byte(`
+rules:
+  disable: [watchdog, cluster_operator, api_removed]
+`))
+		require.NoError(t, err)
+		assert.False(t, config.Rules.neverFuzzy(watchdog))
+		assert.Len(t, config.Rules.NeverFuzzy, 1)
+		assert.Empty(t, config.Rules.GroupBy)
+		assert.Empty(t, config.Rules.AlwaysGroup)
+
+		_, err = ParseGroupingConfig([]byte(`
+rules:
+  disable: [foo]
+`))
+		assert.EqualError(t, err, `disable: unknown default rule "foo"`)
+	})
+}
+
+func TestGroupByRule_Matchers(t *testing.T) {
+	config, err := ParseGroupingConfig([]byte(`
+rules:
+  groupBy:
+  - name: cluster_operator
+    match: {alertname: [ClusterOperatorDown]}
+    alerts:
+    - alertname: [ClusterOperatorDegraded]
+    - __name__: [cluster_operator_conditions]
+    labels: [name]
+`))
+	require.NoError(t, err)
+
+	rule := config.Rules.GroupBy[0]
+	assert.True(t, rule.matches(model.LabelSet{"alertname": "ClusterOperatorDown", "name": "dns"}))
+	assert.True(t, rule.matches(model.LabelSet{"alertname": "ClusterOperatorDegraded", "name": "dns"}))
+	assert.True(t, rule.matches(model.LabelSet{"__name__": "cluster_operator_conditions", "name": "dns"}))
+	assert.False(t, rule.matches(model.LabelSet{"alertname": "KubePodNotReady", "name": "dns"}))
+
+	_, err = ParseGroupingConfig([]byte(`
+rules:
+  groupBy:
+  - name: foo
+    alerts: [{alertname: [Foo]}, {}]
+    labels: [name]
+`))
+	assert.EqualError(t, err, `groupBy rule "foo": empty alert matcher`)
+}
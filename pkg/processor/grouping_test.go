@@ -0,0 +1,135 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/common"
+)
+
+func TestParseGroupingConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    func(c *GroupingConfig)
+		wantErr string
+	}{
+		{
+			name: "empty config uses defaults",
+			data: ``,
+			want: func(c *GroupingConfig) {},
+		},
+		{
+			name: "partial config",
+			data: `
+fuzzyMatchWindow: 6h
+fuzzyLabels: [namespace]
+`,
+			want: func(c *GroupingConfig) {
+				c.FuzzyMatchWindow = 6 * time.Hour
+				c.FuzzyLabels = []model.LabelName{"namespace"}
+			},
+		},
+		{
+			name:    "non-positive window",
+			data:    `timeMatchWindow: 0s`,
+			wantErr: "timeMatchWindow must be positive",
+		},
+		{
+			name:    "empty subset labels",
+			data:    `subsetLabels: []`,
+			wantErr: "subsetLabels must not be empty",
+		},
+		{
+			name:    "invalid label",
+			data:    `fuzzyLabels: ["foo-bar"]`,
+			wantErr: `"foo-bar" is not a valid label name`,
+		},
+		{
+			name:    "invalid duration",
+			data:    `directMatchWindow: often`,
+			wantErr: "failed to parse grouping config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseGroupingConfig([]byte(tt.data))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			want := DefaultGroupingConfig()
+			tt.want(want)
+			assert.Equal(t, want, config)
+		})
+	}
+}
+
+func TestBuildGroupingConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grouping.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("timeMatchWindow: 5m\nfuzzyMatchWindow: 12h\n"), 0o600))
+
+	// Options override the config file.
+	config, err := BuildGroupingConfig(common.GroupingOptions{
+		GroupingConfigPath: path,
+		FuzzyMatchWindow:   time.Hour,
+		SubsetLabels:       []string{"namespace", "alertname"},
+	})
+	require.NoError(t, err)
+
+	want := DefaultGroupingConfig()
+	want.TimeMatchWindow = 5 * time.Minute
+	want.FuzzyMatchWindow = time.Hour
+	want.SubsetLabels = []model.LabelName{"namespace", "alertname"}
+	assert.Equal(t, want, config)
+
+	config, err = BuildGroupingConfig(common.GroupingOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultGroupingConfig(), config)
+
+	_, err = BuildGroupingConfig(common.GroupingOptions{GroupingConfigPath: filepath.Join(t.TempDir(), "missing.yaml")})
+	assert.Error(t, err)
+}
+
+// TestGroupsCollectionConfig checks the grouping parameters are taken
+// from the collection config.
+func TestGroupsCollectionConfig(t *testing.T) {
+	start := model.TimeFromUnixNano(
+		time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+
+	alert1 := model.LabelSet{"alertname": "Alert1", "namespace": "foo", "pod": "a"}
+	alert2 := model.LabelSet{"alertname": "Alert2", "namespace": "foo", "pod": "b"}
+
+	process := func(gc *GroupsCollection, alert model.LabelSet, t model.Time) string {
+		gi := gc.ProcessIntervalsBatch([]Interval{{Metric: alert, Start: t, End: t.Add(time.Minute)}})
+		return gi[0].GroupMatcher.RootGroupID
+	}
+
+	// With the default config, alerts in the same namespace within 24h are grouped.
+	gc := &GroupsCollection{}
+	g1 := process(gc, alert1, start)
+	assert.Equal(t, g1, process(gc, alert2, start.Add(2*time.Hour)))
+
+	// With shorter fuzzy window, they are not.
+	config := DefaultGroupingConfig()
+	config.FuzzyMatchWindow = time.Hour
+	gc = &GroupsCollection{Config: config}
+	g1 = process(gc, alert1, start)
+	assert.NotEqual(t, g1, process(gc, alert2, start.Add(2*time.Hour)))
+
+	// Without the namespace in fuzzy labels, neither.
+	config = DefaultGroupingConfig()
+	config.FuzzyLabels = []model.LabelName{"alertname"}
+	gc = &GroupsCollection{Config: config}
+	g1 = process(gc, alert1, start)
+	assert.NotEqual(t, g1, process(gc, alert2, start.Add(2*time.Hour)))
+}
@@ -50,11 +50,6 @@ func (c Change) String() string {
 
 type ChangeSet []Change
 
-var noMatchAlerts = []common.LabelsSubsetMatcher{
-	{Labels: model.LabelSet{"alertname": "Watchdog", "namespace": "openshift-monitoring"}},
-	{Labels: model.LabelSet{"alertname": "AlertmanagerReceiversNotConfigured", "namespace": "openshift-monitoring"}},
-}
-
 func MetricsIntervals(rangeVector prom.RangeVector) []Interval {
 	if len(rangeVector) == 0 {
 		return nil
@@ -218,34 +213,42 @@ func watchdogAlert(i Interval) bool {
 		i.Metric["namespace"] == "openshift-monitoring"
 }
 
-func alertFuzzyLabels(i Interval) model.LabelSet {
-	for _, m := range noMatchAlerts {
-		// For certain alerts, we don't want to do any fuzzy matching.
-		if match, _ := m.Matches(i.Metric); match {
-			return nil
-		}
+func (c *GroupingConfig) alertFuzzyLabels(i Interval) model.LabelSet {
+	// For certain alerts, we don't want to do any fuzzy matching.
+	if c.Rules.neverFuzzy(i.Metric) {
+		return nil
 	}
-	// TODO: add option for some alerts to match some known pairs, but not others.
-	// E.g. APIRemovedInNextReleaseInUse and APIRemovedInNextEUSReleaseInUse
-	return getMapSubset(i.Metric, "alertname", "namespace")
+	return getMapSubset(i.Metric, c.FuzzyLabels...)
 }
 
 // alertGroupMatchers returns a list of matchers for the alert.
 // This includes exact matcher with 0 distance, as well as various fuzzy matchers
 // based on the alert labels.
-func alertGroupMatchers(interval Interval) []*GroupMatcher {
+func (c *GroupingConfig) alertGroupMatchers(interval Interval) []*GroupMatcher {
 	labels := interval.Metric
 	groups := []*GroupMatcher{
 		newGroupMatcherExact(labels),
-		// Match on main subset of labels - should be still close enough.
-		newGroupMatcherSubset(labels, []model.LabelName{"namespace", "alertname", "service", "job", "container"}, 1),
 	}
+	// Match on main subset of labels - should be still close enough.
+	// Signals without any of the labels (e.g. ClusterOperator conditions) are
+	// skipped, as the empty subset would match everything.
+	if subset := newGroupMatcherSubset(labels, c.SubsetLabels, 1); len(subset.Matchers[0].Labels) > 0 {
+		groups = append(groups, subset)
+	}
+	// Known relations between the alerts, defined via the rules.
+	groups = append(groups, c.Rules.rulesGroupMatchers(labels)...)
 
-	for k, v := range alertFuzzyLabels(interval) {
+	for k, v := range c.alertFuzzyLabels(interval) {
 		groups = append(groups,
 			newGroupMatcherSubset(model.LabelSet{k: v}, []model.LabelName{k}, 2),
 		)
 	}
+	// Known dependencies between the components of the alerts.
+	if !c.Rules.neverFuzzy(labels) {
+		if g := dependencyGroupMatcher(labels); g != nil {
+			groups = append(groups, g)
+		}
+	}
 	for _, g := range groups {
 		g.Start = interval.Start
 		g.Modified = interval.Start
@@ -256,6 +259,22 @@ func alertGroupMatchers(interval Interval) []*GroupMatcher {
 
 type GroupsCollection struct {
 	Groups []*GroupMatcher
+
+	// Config holds the grouping parameters. DefaultGroupingConfig is used when nil.
+	Config *GroupingConfig
+
+	// Timelines holds the timelines of the incidents by their root group ID.
+	Timelines map[string]*incidentTimeline
+}
+
+// defaultGroupingConfig is used for collections without explicit config.
+var defaultGroupingConfig = DefaultGroupingConfig()
+
+func (gc *GroupsCollection) config() *GroupingConfig {
+	if gc.Config == nil {
+		return defaultGroupingConfig
+	}
+	return gc.Config
 }
 
 func (gc *GroupsCollection) AddGroup(g *GroupMatcher) {
@@ -308,14 +327,44 @@ func (gc *GroupsCollection) ProcessAlertsBatch(alerts []model.LabelSet, timestam
 	return ret
 }
 
+// ResolveAlertsBatch extends the directly matching groups of the resolved
+// alerts until the time they ended. Unlike ProcessAlertsBatch, it never
+// creates new groups: the resolved alerts without a direct match are ignored.
+// It returns the number of the alerts with a direct match.
+func (gc *GroupsCollection) ResolveAlertsBatch(alerts []model.LabelSet, timestamp time.Time) int {
+	modelT := model.TimeFromUnixNano(timestamp.UnixNano())
+
+	matched := 0
+	for _, a := range alerts {
+		found := false
+		for _, g := range gc.Groups {
+			if g.Distance != 0 || g.Start.After(modelT) {
+				continue
+			}
+			for _, m := range g.Matchers {
+				if ok, _ := m.Matches(a); ok {
+					g.End = max(g.End, modelT)
+					found = true
+					break
+				}
+			}
+		}
+		if found {
+			matched++
+		}
+	}
+	return matched
+}
+
 // PruneGroups removes groups that can't be matched anymore.
 //
 // It calculates the threshold based on the provided time and removes groups.
 func (gc *GroupsCollection) PruneGroups(t time.Time) {
+	config := gc.config()
 	// Directs matches have longer retention times.
-	gc.pruneGroupsBefore(0, 0, t.Add(-1*directMatchLongTimeDelta))
+	gc.pruneGroupsBefore(0, 0, t.Add(-1*config.DirectMatchWindow))
 	// Fuzzy matches have shorter retention times.
-	gc.pruneGroupsBefore(1, math.Inf(1), t.Add(-1*fuzzyMatchTimeDelta))
+	gc.pruneGroupsBefore(1, math.Inf(1), t.Add(-1*config.FuzzyMatchWindow))
 }
 
 func (gc *GroupsCollection) pruneGroupsBefore(minDistance, maxDistance float64, t time.Time) {
@@ -379,7 +428,7 @@ func (gc *GroupsCollection) addIntervalsGroups(intervals []Interval, groupMatche
 	if len(intervals) == 0 {
 		return nil
 	}
-	newGc := &GroupsCollection{}
+	newGc := &GroupsCollection{Config: gc.Config}
 
 	isWatchdogGroup := false
 	for _, i := range intervals {
@@ -414,7 +463,7 @@ func (gc *GroupsCollection) addIntervalsGroups(intervals []Interval, groupMatche
 		// for this interval. If Distance is 0, we assume the fuzzy matchers
 		// to be already present.
 		if iGroupMatcher.Distance > 0 {
-			newGroupCands := alertGroupMatchers(i)
+			newGroupCands := gc.config().alertGroupMatchers(i)
 			for _, g := range newGroupCands {
 				if g.Distance == iGroupMatcher.Distance && iGroupMatcher.isSubsetOf(g) {
 					iGroupMatcher.expandMatchers(g.Matchers)
@@ -440,18 +489,8 @@ func (gc *GroupsCollection) addIntervalsGroups(intervals []Interval, groupMatche
 	return ret
 }
 
-var (
-	// Unless we have a direct match, we try fuzzy matching.
-	fuzzyMatchTimeDelta = 24 * time.Hour
-
-	// If we have no match yet, we try to match on the time, but just very close events.
-	timeMatchTimeDelta = 15 * time.Minute
-
-	// No match yet: look for direct matches deeper in the past.
-	directMatchLongTimeDelta = 5 * 24 * time.Hour
-)
-
 func (gc *GroupsCollection) bestMatch(interval Interval) *GroupMatcher {
+	config := gc.config()
 	matches := gc.matches(interval)
 	var directLongMatch *match
 	var shortCandidates []match
@@ -461,14 +500,14 @@ func (gc *GroupsCollection) bestMatch(interval Interval) *GroupMatcher {
 	})
 
 	for _, m := range matches {
-		if m.TimeDist <= fuzzyMatchTimeDelta {
+		if m.TimeDist <= config.FuzzyMatchWindow {
 			shortCandidates = append(shortCandidates, m)
 			continue
 		}
 
-		if m.TimeDist <= directMatchLongTimeDelta && m.GroupMatcher.Distance == 0 {
+		if m.TimeDist <= config.DirectMatchWindow && m.GroupMatcher.Distance == 0 {
 			directLongMatch = &m
-			// Given matches are sorted by time and we crossed the fuzzyMatchTimeDelta,
+			// Given matches are sorted by time and we crossed the FuzzyMatchWindow,
 			// there is no change to match anything better at this point.
 			break
 		}
@@ -498,8 +537,13 @@ func (gc *GroupsCollection) bestMatch(interval Interval) *GroupMatcher {
 
 func (gc *GroupsCollection) matches(interval Interval) []match {
 	var ret []match
-	allLabels := interval.Metric
-	fuzzyLabels := alertFuzzyLabels(interval)
+	config := gc.config()
+	// The rules are evaluated by extending the labels with the synthetic
+	// labels of the rules the alert matches.
+	allLabels := config.Rules.withRulesLabels(interval.Metric)
+	fuzzyLabels := config.alertFuzzyLabels(interval)
+	// The component is evaluated lazily, only when there are dependency matchers.
+	var component *string
 	for _, g := range gc.Groups {
 		var timeDist time.Duration
 		if g.Distance == 0 {
@@ -516,11 +560,27 @@ func (gc *GroupsCollection) matches(interval Interval) []match {
 		}
 
 		// Pure time-based grouping
-		if g.Distance == math.Inf(1) && timeDist <= timeMatchTimeDelta {
+		if g.Distance == math.Inf(1) && timeDist <= config.TimeMatchWindow {
 			ret = append(ret, match{g, timeDist})
 			continue
 		}
 
+		// Dependency-based grouping
+		if g.Distance == dependencyMatchDistance {
+			if timeDist > config.DependencyMatchWindow || srcType(interval.Metric) != Alert ||
+				config.Rules.neverFuzzy(interval.Metric) {
+				continue
+			}
+			if component == nil {
+				c := signalComponent(interval.Metric)
+				component = &c
+			}
+			if g.matchesDependency(*component) {
+				ret = append(ret, match{g, timeDist})
+			}
+			continue
+		}
+
 		labels := allLabels
 		// For fuzzy matching, we use only a subset of labels that can be overriden
 		// on per-alert basis.
@@ -50,14 +50,14 @@ func TestGroupsCollectionProcessAlertsBatch(t *testing.T) {
 	assert.Equal(t, case3[0]["group_id"], case3[1]["group_id"])
 
 	// Case 4: Alert with same alertname as one from case 2 fires within
-	// [processor.fuzzyMatchTimeDelta] time range.
+	// [GroupingConfig.FuzzyMatchWindow] time range.
 	//
 	// It should match the group created in case 3.
 	alerts = []model.LabelSet{{"alertname": "Alert3.1"}}
 	case4 := gc.ProcessAlertsBatch(alerts, start.Add(7*time.Hour).Time())
 	assert.Equal(t, case3[0]["group_id"], case4[0]["group_id"])
 
-	// Case 5: Alert from the same namespace firing within [processor.fuzzyMatchTimeDelta]
+	// Case 5: Alert from the same namespace firing within [GroupingConfig.FuzzyMatchWindow]
 	//
 	// It should match with the last active group from the same namespace.
 	alerts = []model.LabelSet{
@@ -95,7 +95,7 @@ func TestGroupsCollectionPruneGroups(t *testing.T) {
 
 	gc := GroupsCollection{}
 
-	// Time-based matcher should be pruned after [processor.fuzzyMatchTimeDelta]
+	// Time-based matcher should be pruned after [GroupingConfig.FuzzyMatchWindow]
 	gc.AddGroup(&GroupMatcher{
 		GroupID:  "time-matcher",
 		Start:    start.Add(1 * time.Hour),
@@ -103,7 +103,7 @@ func TestGroupsCollectionPruneGroups(t *testing.T) {
 		End:      start.Add(3 * time.Hour),
 		Distance: math.Inf(1)})
 
-	// Fuzzy matcher should be pruned after [processor.fuzzyMatchTimeDelta]
+	// Fuzzy matcher should be pruned after [GroupingConfig.FuzzyMatchWindow]
 	gc.AddGroup(&GroupMatcher{
 		GroupID:  "fuzzy-matcher-old",
 		Start:    start.Add(1 * time.Hour),
@@ -120,7 +120,7 @@ func TestGroupsCollectionPruneGroups(t *testing.T) {
 		End:      start.Add(3 * time.Hour),
 		Distance: 1})
 
-	// Direct matcher should be pruned after [processor.directMatchTimeDelta]
+	// Direct matcher should be pruned after [GroupingConfig.DirectMatchWindow]
 	// It can be active for longer time and should not be pruned first.
 	gc.AddGroup(&GroupMatcher{
 		GroupID:  "direct-matcher",
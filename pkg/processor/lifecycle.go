@@ -0,0 +1,202 @@
+package processor
+
+// This file contains logic for exporting the metrics of the incidents lifecycle.
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+const (
+	IncidentInfoMetric       = "cluster_health_incident_info"
+	IncidentSeverityMetric   = "cluster_health_incident_severity"
+	IncidentComponentsMetric = "cluster_health_incident_components"
+	IncidentAlertsMetric     = "cluster_health_incident_alerts"
+	IncidentsOpenedMetric    = "cluster_health_incidents_opened_total"
+	IncidentsResolvedMetric  = "cluster_health_incidents_resolved_total"
+	IncidentDurationMetric   = "cluster_health_incident_duration_seconds"
+)
+
+// IncidentMetrics holds the metrics of the incidents lifecycle.
+type IncidentMetrics struct {
+	// Info exposes a series per firing incident, with the first-seen
+	// timestamp as the value.
+	Info prom.MetricSet
+	// Severity exposes the max severity of the firing incidents.
+	Severity prom.MetricSet
+	// Components exposes the number of the components of the firing incidents.
+	Components prom.MetricSet
+	// Alerts exposes the number of the alerts of the firing incidents.
+	Alerts prom.MetricSet
+	// Opened counts the incidents that started firing.
+	Opened *prometheus.CounterVec
+	// Resolved counts the incidents that stopped firing.
+	Resolved *prometheus.CounterVec
+	// Duration observes the duration of the resolved incidents.
+	Duration *prometheus.HistogramVec
+	// TimelineEvents exposes the number of the changes of the incidents
+	// by their type.
+	TimelineEvents prom.MetricSet
+	// LastSeverityChange exposes the last severity change of the incidents,
+	// with the time of the change as the value.
+	LastSeverityChange prom.MetricSet
+	// RootCause exposes the most likely root cause of the firing incidents,
+	// with its score as the value.
+	RootCause prom.MetricSet
+}
+
+// NewIncidentMetrics creates the incidents lifecycle metrics. The counters
+// and the histogram are labeled with the cluster of the incident in the
+// multi-cluster setup. The resolved incidents are labeled also with their
+// max severity, which is not known yet when the incident is opened.
+func NewIncidentMetrics() *IncidentMetrics {
+	openedLabels := []string{ClusterLabel, ClusterIDLabel}
+	labels := []string{"severity", ClusterLabel, ClusterIDLabel}
+	return &IncidentMetrics{
+		Info: prom.NewMetricSet(IncidentInfoMetric,
+			"Firing incidents. The value is the first-seen timestamp."),
+		Severity: prom.NewMetricSet(IncidentSeverityMetric,
+			"The max severity of the firing incidents: 0 info, 1 warning, 2 critical."),
+		Components: prom.NewMetricSet(IncidentComponentsMetric,
+			"Number of the distinct components of the firing incidents."),
+		Alerts: prom.NewMetricSet(IncidentAlertsMetric,
+			"Number of the alerts of the firing incidents."),
+		Opened: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: IncidentsOpenedMetric,
+			Help: "Number of the incidents that started firing.",
+		}, openedLabels),
+		Resolved: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: IncidentsResolvedMetric,
+			Help: "Number of the incidents that stopped firing.",
+		}, labels),
+		Duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: IncidentDurationMetric,
+			Help: "Duration of the resolved incidents, from the first-seen time until the resolution.",
+			Buckets: []float64{
+				(5 * time.Minute).Seconds(),
+				(15 * time.Minute).Seconds(),
+				(30 * time.Minute).Seconds(),
+				time.Hour.Seconds(),
+				(2 * time.Hour).Seconds(),
+				(6 * time.Hour).Seconds(),
+				(12 * time.Hour).Seconds(),
+				(24 * time.Hour).Seconds(),
+				(48 * time.Hour).Seconds(),
+				(7 * 24 * time.Hour).Seconds(),
+			},
+		}, labels),
+		TimelineEvents: prom.NewMetricSet(IncidentTimelineEventsMetric,
+			"Number of the changes of the incidents by their type: severity changes, added and removed alerts and added components."),
+		LastSeverityChange: prom.NewMetricSet(IncidentLastSeverityChangeMetric,
+			"The last severity change of the incidents. The value is the time of the change."),
+		RootCause: prom.NewMetricSet(IncidentRootCauseMetric,
+			"The most likely root cause of the firing incidents. The value is the score of the root cause."),
+	}
+}
+
+// Collectors returns the collectors of the metrics to be registered.
+func (m *IncidentMetrics) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.Info, m.Severity, m.Components, m.Alerts, m.Opened, m.Resolved, m.Duration,
+		m.TimelineEvents, m.LastSeverityChange, m.RootCause}
+}
+
+// incidentState is the state of a firing incident.
+type incidentState struct {
+	firstSeen  time.Time
+	severity   HealthValue
+	components map[componentKey]struct{}
+	alerts     int
+}
+
+// incidentLifecycle tracks the firing incidents between the processing
+// iterations to detect the opened and resolved ones.
+type incidentLifecycle struct {
+	metrics *IncidentMetrics
+	// initialized is false until the first update. The incidents firing on
+	// the first update were opened before the start and are not counted.
+	initialized bool
+	incidents   map[groupKey]*incidentState
+}
+
+func newIncidentLifecycle(metrics *IncidentMetrics) *incidentLifecycle {
+	return &incidentLifecycle{
+		metrics:   metrics,
+		incidents: make(map[groupKey]*incidentState),
+	}
+}
+
+// update updates the metrics with the incidents of the current health map.
+// The firstSeen holds the start times of the known incidents by their group_id,
+// the incidents missing there are first seen at the time t.
+func (l *incidentLifecycle) update(healthMap []ComponentHealthMap, firstSeen map[string]time.Time, t time.Time) {
+	current := make(map[groupKey]*incidentState)
+	for _, hm := range healthMap {
+		if hm.GroupId == "" {
+			continue
+		}
+		k := groupKey{cluster: hm.Cluster, clusterID: hm.ClusterID, groupID: hm.GroupId}
+		state, ok := current[k]
+		if !ok {
+			state = &incidentState{firstSeen: t, components: make(map[componentKey]struct{})}
+			if prev, ok := l.incidents[k]; ok {
+				state.firstSeen = prev.firstSeen
+			} else if start, ok := firstSeen[hm.GroupId]; ok && start.Before(t) {
+				state.firstSeen = start
+			}
+			current[k] = state
+		}
+		state.severity = max(state.severity, hm.Health)
+		state.components[componentKey{hm.Layer, hm.Component}] = struct{}{}
+		if hm.SrcType == Alert {
+			state.alerts++
+		}
+	}
+
+	for k, state := range current {
+		prev, ok := l.incidents[k]
+		if ok {
+			// the severity of the incident is the max severity it ever had
+			state.severity = max(state.severity, prev.severity)
+		} else if l.initialized {
+			l.metrics.Opened.WithLabelValues(k.cluster, k.clusterID).Inc()
+		}
+	}
+	for k, prev := range l.incidents {
+		if _, ok := current[k]; ok {
+			continue
+		}
+		labels := []string{prev.severity.String(), k.cluster, k.clusterID}
+		l.metrics.Resolved.WithLabelValues(labels...).Inc()
+		l.metrics.Duration.WithLabelValues(labels...).Observe(t.Sub(prev.firstSeen).Seconds())
+	}
+	l.incidents = current
+	l.initialized = true
+
+	l.updateIncidentMetrics()
+}
+
+// updateIncidentMetrics updates the series of the firing incidents. All of them
+// have the same stable labels identifying the incident, the changing properties
+// of the incident are the values of the series.
+func (l *incidentLifecycle) updateIncidentMetrics() {
+	info := make([]prom.Metric, 0, len(l.incidents))
+	severities := make([]prom.Metric, 0, len(l.incidents))
+	components := make([]prom.Metric, 0, len(l.incidents))
+	alerts := make([]prom.Metric, 0, len(l.incidents))
+	for k, state := range l.incidents {
+		labels := model.LabelSet{"group_id": model.LabelValue(k.groupID)}
+		setClusterLabels(labels, k.cluster, k.clusterID)
+		info = append(info, prom.Metric{Labels: labels, Value: float64(state.firstSeen.Unix())})
+		severities = append(severities, prom.Metric{Labels: labels, Value: float64(state.severity)})
+		components = append(components, prom.Metric{Labels: labels, Value: float64(len(state.components))})
+		alerts = append(alerts, prom.Metric{Labels: labels, Value: float64(state.alerts)})
+	}
+	l.metrics.Info.Update(info)
+	l.metrics.Severity.Update(severities)
+	l.metrics.Components.Update(components)
+	l.metrics.Alerts.Update(alerts)
+}
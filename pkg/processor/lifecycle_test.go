@@ -0,0 +1,96 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+func TestIncidentLifecycle(t *testing.T) {
+	metrics := NewIncidentMetrics()
+	info, severity, components, alerts := &testMetricSet{}, &testMetricSet{}, &testMetricSet{}, &testMetricSet{}
+	metrics.Info, metrics.Severity, metrics.Components, metrics.Alerts = info, severity, components, alerts
+	l := newIncidentLifecycle(metrics)
+
+	start := time.Unix(1000, 0)
+	firstSeen := map[string]time.Time{"g1": start.Add(-time.Hour)}
+
+	// The incidents firing on the first update are not counted as opened.
+	l.update([]ComponentHealthMap{
+		{GroupId: "g1", Layer: "core", Component: "etcd", SrcType: Alert, Health: Warning},
+		{GroupId: "g1", Layer: "core", Component: "etcd", SrcType: Alert, Health: Warning},
+		{GroupId: "g1", Layer: "core", Component: "network", SrcType: ClusterOperatorCondition, Health: Critical},
+	}, firstSeen, start)
+	assert.Equal(t, 0, testutil.CollectAndCount(metrics.Opened))
+
+	// All the series of the incident have the same labels.
+	g1 := model.LabelSet{"group_id": "g1"}
+	assert.Equal(t, []prom.Metric{{Labels: g1, Value: float64(start.Add(-time.Hour).Unix())}}, info.metrics)
+	assert.Equal(t, []prom.Metric{{Labels: g1, Value: float64(Critical)}}, severity.metrics)
+	assert.Equal(t, []prom.Metric{{Labels: g1, Value: 2}}, components.metrics)
+	assert.Equal(t, []prom.Metric{{Labels: g1, Value: 2}}, alerts.metrics)
+
+	// A new incident is opened, the severity of g1 is kept at its max.
+	t2 := start.Add(time.Minute)
+	l.update([]ComponentHealthMap{
+		{GroupId: "g1", Layer: "core", Component: "etcd", SrcType: Alert, Health: Warning},
+		{GroupId: "g2", Layer: "compute", Component: "compute", SrcType: Alert, Health: Warning},
+	}, firstSeen, t2)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.Opened.WithLabelValues("", "")))
+
+	values := func(set *testMetricSet) map[model.LabelValue]float64 {
+		ret := make(map[model.LabelValue]float64)
+		for _, m := range set.metrics {
+			ret[m.Labels["group_id"]] = m.Value
+		}
+		return ret
+	}
+	assert.Equal(t, map[model.LabelValue]float64{"g1": float64(start.Add(-time.Hour).Unix()), "g2": float64(t2.Unix())},
+		values(info))
+	assert.Equal(t, map[model.LabelValue]float64{"g1": float64(Critical), "g2": float64(Warning)}, values(severity))
+	assert.Equal(t, map[model.LabelValue]float64{"g1": 1, "g2": 1}, values(components))
+	assert.Equal(t, map[model.LabelValue]float64{"g1": 1, "g2": 1}, values(alerts))
+
+	// Both incidents are resolved.
+	t3 := t2.Add(10 * time.Minute)
+	l.update(nil, firstSeen, t3)
+	assert.Empty(t, info.metrics)
+	assert.Empty(t, severity.metrics)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.Resolved.WithLabelValues("critical", "", "")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.Resolved.WithLabelValues("warning", "", "")))
+
+	// The duration is measured since the first-seen time.
+	var m dto.Metric
+	require.NoError(t, metrics.Duration.WithLabelValues("critical", "", "").(prometheus.Histogram).Write(&m))
+	assert.Equal(t, uint64(1), m.GetHistogram().GetSampleCount())
+	assert.Equal(t, t3.Sub(start.Add(-time.Hour)).Seconds(), m.GetHistogram().GetSampleSum())
+}
+
+func TestIncidentLifecycle_MultipleClusters(t *testing.T) {
+	metrics := NewIncidentMetrics()
+	info := &testMetricSet{}
+	metrics.Info = info
+	l := newIncidentLifecycle(metrics)
+
+	now := time.Unix(1000, 0)
+	l.update(nil, nil, now)
+	l.update([]ComponentHealthMap{
+		{GroupId: "g1", Cluster: "prod", ClusterID: "c1", Layer: "core", Component: "etcd", SrcType: Alert, Health: Critical},
+		{GroupId: "g2", Cluster: "dev", ClusterID: "c2", Layer: "core", Component: "etcd", SrcType: Alert, Health: Critical},
+	}, nil, now)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.Opened.WithLabelValues("prod", "c1")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.Opened.WithLabelValues("dev", "c2")))
+	for _, m := range info.metrics {
+		assert.Contains(t, m.Labels, model.LabelName(ClusterLabel))
+		assert.Contains(t, m.Labels, model.LabelName(ClusterIDLabel))
+	}
+}
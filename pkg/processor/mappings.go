@@ -1,270 +1,260 @@
 package processor
 
 import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
 	"regexp"
+	"sync/atomic"
 
-	"github.com/openshift/cluster-health-analyzer/pkg/common"
 	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert/yaml"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/common"
 )
 
 // This file contains data used to map the signal to particular components.
 
-var (
-	nodeAlerts []model.LabelValue = []model.LabelValue{
-		"NodeClockNotSynchronising",
-		"KubeNodeNotReady",
-		"KubeNodeUnreachable",
-		"NodeSystemSaturation",
-		"NodeFilesystemSpaceFillingUp",
-		"NodeFilesystemAlmostOutOfSpace",
-		"NodeMemoryMajorPagesFaults",
-		"NodeNetworkTransmitErrs",
-		"NodeTextFileCollectorScrapeError",
-		"NodeFilesystemFilesFillingUp",
-		"NodeNetworkReceiveErrs",
-		"NodeClockSkewDetected",
-		"NodeFilesystemAlmostOutOfFiles",
-		"NodeWithoutOVNKubeNodePodRunning",
-		"InfraNodesNeedResizingSRE",
-		"NodeHighNumberConntrackEntriesUsed",
-		"NodeMemHigh",
-		"NodeNetworkInterfaceFlapping",
-		"NodeWithoutSDNPod",
-		"NodeCpuHigh",
-		"CriticalNodeNotReady",
-		"NodeFileDescriptorLimit",
-		// subset of MCO alerts https://github.com/openshift/machine-config-operator/blob/204767253e30608b5b7fd70ad1ace02ba1d64b46/install/0000_90_machine-config_01_prometheus-rules.yaml#L115
-		"MCCPoolAlert",
-		"MCCDrainError",
-		"MCDRebootError",
-		"MCDPivotError",
+// defaultMappingsData contains the built-in component mappings shipped
+// with the analyzer.
+//
+//go:embed mappings.yaml
+var defaultMappingsData []byte
+
+// mappings holds the component mappings currently used by the analyzer.
+//
+// It can be swapped at runtime when the mappings config changes.
+var mappings atomic.Pointer[Mappings]
+
+func init() {
+	mappings.Store(DefaultMappings())
+}
+
+// MappingsConfig represents the mapping of signals to components as defined
+// in the "external" YAML config.
+type MappingsConfig struct {
+	Components []ComponentMapping `yaml:"components"`
+	// Dependencies define which components depend on which, used to correlate
+	// the signals of the dependent components.
+	Dependencies []ComponentDependency `yaml:"dependencies"`
+}
+
+// ComponentMapping represents a single component with its layer, rank and
+// the matchers used to assign the signal to it.
+//
+// The component matches if any of the label matchers match the labels.
+//
+// With PerNode set, the signal is assigned to the individual node it belongs
+// to (if it can be determined from the labels), in the "<layer>/control-plane"
+// or "<layer>/worker" layer based on the role of the node.
+type ComponentMapping struct {
+	Layer     string         `yaml:"layer"`
+	Component string         `yaml:"component"`
+	Rank      int            `yaml:"rank"`
+	PerNode   bool           `yaml:"perNode"`
+	Matchers  []LabelMatcher `yaml:"matchers"`
+}
+
+// LabelMatcher represents a matcher for a single label. Exactly one
+// of Values (exact match) or Regex (regular expressions) is expected.
+type LabelMatcher struct {
+	Label  string   `yaml:"label"`
+	Values []string `yaml:"values"`
+	Regex  []string `yaml:"regex"`
+}
+
+// Mappings is the validated and compiled form of the MappingsConfig.
+type Mappings struct {
+	components   []layerComponentMatcher
+	dependencies *DependencyGraph
+}
+
+// layerComponentMatcher extends the componentMatcher with the layer and rank
+// the component belongs to.
+type layerComponentMatcher struct {
+	componentMatcher
+	layer   string
+	rank    int
+	perNode bool
+	// namespaceMatchers are the matchers on the namespace label, used
+	// to determine whether the component is installed.
+	namespaceMatchers []common.LabelsMatcher
+}
+
+// DefaultMappings returns the built-in component mappings.
+func DefaultMappings() *Mappings {
+	return mustParseMappings(defaultMappingsData)
+}
+
+// SetMappings replaces the component mappings used for mapping the signal
+// to components and for ranking the components.
+func SetMappings(m *Mappings) {
+	mappings.Store(m)
+}
+
+// LoadMappings reads the file and parses the component mappings.
+func LoadMappings(filePath string) (*Mappings, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseMappings(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid component mappings in %q: %w", filePath, err)
 	}
+	slog.Info("Successfully loaded component mappings from ", "path", filePath)
+	return m, nil
+}
 
-	coreMatchers = []componentMatcher{
-		{"etcd", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace",
-				common.NewStringValuesMatcher(
-					"openshift-etcd",
-					"openshift-etcd-operator"))}},
-		{"kube-apiserver", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace",
-				common.NewStringValuesMatcher(
-					"openshift-kube-apiserver",
-					"openshift-kube-apiserver-operator"))}},
-		{"kube-controller-manager", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-kube-controller-manager",
-				"openshift-kube-controller-manager-operator",
-				"kube-system",
-			))}},
-		{"kube-scheduler", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-kube-scheduler",
-				"openshift-kube-scheduler-operator",
-			))}},
-		{"machine-approver", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-cluster-machine-approver",
-				"openshift-machine-approver-operator",
-			))}},
-		{"machine-config", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-machine-config-operator",
-			)),
-			common.NewLabelsMatcher("alertname", common.NewStringValuesMatcher(
-				"HighOverallControlPlaneMemory",
-				"ExtremelyHighIndividualControlPlaneMemory",
-				"MissingMachineConfig",
-				"MCCBootImageUpdateError",
-				"KubeletHealthState",
-				"SystemMemoryExceedsReservation",
-			))}},
-		{"version", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-cluster-version",
-				"openshift-version-operator",
-			)),
-			common.NewLabelsMatcher("alertname", common.NewStringValuesMatcher(
-				"ClusterNotUpgradeable",
-				"UpdateAvailable",
-			))}},
-		{"dns", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-dns",
-				"openshift-dns-operator",
-			))}},
-		{"authentication", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-authentication",
-				"openshift-oauth-apiserver",
-				"openshift-authentication-operator",
-			))}},
-		{"cert-manager", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-cert-manager",
-				"openshift-cert-manager-operator",
-			))}},
-		{"cloud-controller-manager", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-cloud-controller-manager",
-				"openshift-cloud-controller-manager-operator",
-			))}},
-		{"cloud-credential", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-cloud-credential-operator",
-			))}},
-		{"cluster-api", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-cluster-api",
-				"openshift-cluster-api-operator",
-			))}},
-		{"config-operator", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-config-operator",
-			))}},
-		{"kube-storage-version-migrator", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-kube-storage-version-migrator",
-				"openshift-kube-storage-version-migrator-operator",
-			))}},
-		{"image-registry", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-image-registry",
-				"openshift-image-registry-operator",
-			))}},
-		{"ingress", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-ingress",
-				"openshift-route-controller-manager",
-				"openshift-ingress-canary",
-				"openshift-ingress-operator",
-			))}},
-		{"console", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-console",
-				"openshift-console-operator",
-			))}},
-		{"insights", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-insights",
-				"openshift-insights-operator",
-			))}},
-		{"machine-api", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-machine-api",
-				"openshift-machine-api-operator",
-			))}},
-		{"monitoring", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-monitoring",
-				"openshift-monitoring-operator",
-			))}},
-		{"network", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-network-operator",
-				"openshift-ovn-kubernetes",
-				"openshift-multus",
-				"openshift-network-diagnostics",
-				"openshift-sdn",
-			))}},
-		{"node-tuning", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-cluster-node-tuning-operator",
-				"openshift-node-tuning-operator",
-			))}},
-		{"openshift-apiserver", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-apiserver",
-				"openshift-apiserver-operator",
-			))}},
-		{"openshift-controller-manager", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-controller-manager",
-				"openshift-controller-manager-operator",
-			))}},
-		{"openshift-samples", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-cluster-samples-operator",
-				"openshift-samples-operator",
-			))}},
-		{"operator-lifecycle-manager", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-operator-lifecycle-manager",
-			))}},
-		{"service-ca", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-service-ca",
-				"openshift-service-ca-operator",
-			))}},
-		{"storage", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-storage",
-				"openshift-cluster-csi-drivers",
-				"openshift-cluster-storage-operator",
-				"openshift-storage-operator",
-			))}},
-		{"vertical-pod-autoscaler", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-vertical-pod-autoscaler",
-				"openshift-vertical-pod-autoscaler-operator",
-			))}},
-		{"marketplace", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-marketplace",
-				"openshift-marketplace-operator",
-			)),
-		},
-		},
+// ParseMappings unmarshals and validates the component mappings.
+func ParseMappings(data []byte) (*Mappings, error) {
+	conf := &MappingsConfig{}
+	if err := yaml.Unmarshal(data, conf); err != nil {
+		return nil, err
 	}
+	return conf.compile()
+}
 
-	workloadMatchers = []componentMatcher{
-		{"openshift-compliance", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-compliance",
-			))}},
-		{"openshift-file-integrity", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-file-integrity",
-			))}},
-		{"openshift-logging", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-logging",
-			))}},
-		{"openshift-user-workload-monitoring", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-user-workload-monitoring",
-			))}},
-		{"openshift-gitops", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-gitops",
-				"openshift-gitops-operator",
-			))}},
-		{"openshift-operators", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-operators",
-			))}},
-		{"kubevirt", []common.LabelsMatcher{
-			common.NewLabelsMatcher("kubernetes_operator_part_of", common.NewStringValuesMatcher(
-				"kubevirt",
-			)),
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-cnv",
-			)),
-		}},
-		{"openshift-local-storage", []common.LabelsMatcher{
-			common.NewLabelsMatcher("namespace", common.NewStringValuesMatcher(
-				"openshift-local-storage",
-			))}},
-		{"quay", []common.LabelsMatcher{
-			common.NewLabelsMatcher("container", common.NewStringValuesMatcher(
-				"quay-app",
-				"quay-mirror",
-				"quay-app-upgrade",
-			))}},
-		{"Argo", []common.LabelsMatcher{
-			common.NewLabelsMatcher("alertname", common.NewRegexValuesMatcher(
-				regexp.MustCompile("^Argo"),
-			))},
-		},
+func mustParseMappings(data []byte) *Mappings {
+	m, err := ParseMappings(data)
+	if err != nil {
+		panic(err)
 	}
-)
+	return m
+}
+
+// compile validates the configuration and converts it to the Mappings.
+func (c *MappingsConfig) compile() (*Mappings, error) {
+	if len(c.Components) == 0 {
+		return nil, errors.New("no components defined")
+	}
+
+	seen := make(map[string]struct{}, len(c.Components))
+	ret := &Mappings{components: make([]layerComponentMatcher, 0, len(c.Components))}
+	for i, cm := range c.Components {
+		if cm.Component == "" {
+			return nil, fmt.Errorf("component #%d: missing component name", i)
+		}
+		if cm.Layer == "" {
+			return nil, fmt.Errorf("component %q: missing layer", cm.Component)
+		}
+		if cm.Rank <= 0 {
+			return nil, fmt.Errorf("component %q: rank must be a positive number", cm.Component)
+		}
+		if _, ok := seen[cm.Component]; ok {
+			return nil, fmt.Errorf("component %q: defined multiple times", cm.Component)
+		}
+		seen[cm.Component] = struct{}{}
+
+		if len(cm.Matchers) == 0 {
+			return nil, fmt.Errorf("component %q: no matchers defined", cm.Component)
+		}
+		matchers := make([]common.LabelsMatcher, 0, len(cm.Matchers))
+		var namespaceMatchers []common.LabelsMatcher
+		for _, lm := range cm.Matchers {
+			m, err := lm.compile()
+			if err != nil {
+				return nil, fmt.Errorf("component %q: %w", cm.Component, err)
+			}
+			matchers = append(matchers, m)
+			if lm.Label == "namespace" {
+				namespaceMatchers = append(namespaceMatchers, m)
+			}
+		}
+
+		ret.components = append(ret.components, layerComponentMatcher{
+			componentMatcher:  componentMatcher{component: cm.Component, matchers: matchers},
+			layer:             cm.Layer,
+			rank:              cm.Rank,
+			perNode:           cm.PerNode,
+			namespaceMatchers: namespaceMatchers,
+		})
+	}
+
+	deps, err := compileDependencies(c.Dependencies, ret.components)
+	if err != nil {
+		return nil, err
+	}
+	ret.dependencies = deps
+	return ret, nil
+}
+
+func (l LabelMatcher) compile() (common.LabelsMatcher, error) {
+	if l.Label == "" {
+		return nil, errors.New("matcher with missing label")
+	}
+
+	switch {
+	case len(l.Values) > 0 && len(l.Regex) > 0:
+		return nil, fmt.Errorf("matcher for label %q: only one of values or regex can be set", l.Label)
+	case len(l.Values) > 0:
+		return common.NewLabelsMatcher(l.Label, common.NewStringValuesMatcher(l.Values...)), nil
+	case len(l.Regex) > 0:
+		regexes := make([]*regexp.Regexp, 0, len(l.Regex))
+		for _, r := range l.Regex {
+			re, err := regexp.Compile(r)
+			if err != nil {
+				return nil, fmt.Errorf("matcher for label %q: %w", l.Label, err)
+			}
+			regexes = append(regexes, re)
+		}
+		return common.NewLabelsMatcher(l.Label, common.NewRegexValuesMatcher(regexes...)), nil
+	default:
+		return nil, fmt.Errorf("matcher for label %q: values or regex must be set", l.Label)
+	}
+}
+
+// matchComponent implements the componentMatcherFn on top of the mappings.
+//
+// The components are evaluated in the order of the definition and the first
+// match wins.
+//
+// For the per-node components, the node the signal belongs to is used
+// as the component when it can be determined.
+func (m *Mappings) matchComponent(labels model.LabelSet) (layer, comp model.LabelValue, keys []model.LabelName) {
+	for _, c := range m.components {
+		for _, labelsMatcher := range c.matchers {
+			matches, keys := labelsMatcher.Matches(labels)
+			if !matches {
+				continue
+			}
+			if c.perNode {
+				if node, nodeKey, ok := nodes.Load().matchNode(labels); ok {
+					return model.LabelValue(nodeLayer(c.layer, node.Role)), model.LabelValue(node.Name),
+						append(keys, nodeKey)
+				}
+			}
+			return model.LabelValue(c.layer), model.LabelValue(c.component), keys
+		}
+	}
+	return "", "", nil
+}
+
+// ranks returns the ranks of the components defined in the mappings.
+//
+// When the installed resources are known, only the components relevant
+// for the cluster are included. For the per-node components, the known
+// nodes are included as well.
+func (m *Mappings) ranks() []ComponentRank {
+	installed := installed.Load()
+	ret := make([]ComponentRank, 0, len(m.components))
+	for _, c := range m.components {
+		if installed != nil && !installed.relevant(c) {
+			continue
+		}
+		ret = append(ret, ComponentRank{Layer: c.layer, Component: c.component, Rank: c.rank})
+		if !c.perNode {
+			continue
+		}
+		for _, n := range nodes.Load().nodes {
+			ret = append(ret, ComponentRank{
+				Layer:     nodeLayer(c.layer, n.Role),
+				Component: n.Name,
+				Rank:      nodeRank(c.rank, n.Role),
+			})
+		}
+	}
+	return ret
+}
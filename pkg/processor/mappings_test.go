@@ -0,0 +1,150 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultMappingsRanks(t *testing.T) {
+	ranks := make(map[string]ComponentRank)
+	for _, r := range DefaultMappings().ranks() {
+		ranks[r.Component] = r
+	}
+
+	assert.Equal(t, ComponentRank{Layer: "compute", Component: "compute", Rank: 1}, ranks["compute"])
+	assert.Equal(t, ComponentRank{Layer: "core", Component: "etcd", Rank: 10}, ranks["etcd"])
+	assert.Equal(t, ComponentRank{Layer: "core", Component: "authentication", Rank: 50}, ranks["authentication"])
+	assert.Equal(t, ComponentRank{Layer: "workload", Component: "openshift-compliance", Rank: 1000}, ranks["openshift-compliance"])
+	assert.Equal(t, ComponentRank{Layer: "workload", Component: "Argo", Rank: 1045}, ranks["Argo"])
+}
+
+func TestParseMappings(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		labels  model.LabelSet
+		layer   model.LabelValue
+		comp    model.LabelValue
+		wantErr string
+	}{
+		{
+			name: "exact and regex matchers",
+			data: `
+components:
+- layer: core
+  component: my-operator
+  rank: 20
+  matchers:
+  - label: namespace
+    values: [my-operator]
+  - label: alertname
+    regex: ["^MyOperator"]
+`,
+			labels: model.LabelSet{"alertname": "MyOperatorDown", "namespace": "default"},
+			layer:  "core",
+			comp:   "my-operator",
+		},
+		{
+			name: "first matching component wins",
+			data: `
+components:
+- layer: core
+  component: first
+  rank: 20
+  matchers:
+  - label: namespace
+    values: [ns]
+- layer: workload
+  component: second
+  rank: 1000
+  matchers:
+  - label: namespace
+    values: [ns]
+`,
+			labels: model.LabelSet{"namespace": "ns"},
+			layer:  "core",
+			comp:   "first",
+		},
+		{
+			name:    "no components",
+			data:    `components: []`,
+			wantErr: "no components defined",
+		},
+		{
+			name: "missing rank",
+			data: `
+components:
+- layer: core
+  component: foo
+  matchers:
+  - label: namespace
+    values: [foo]
+`,
+			wantErr: `component "foo": rank must be a positive number`,
+		},
+		{
+			name: "duplicate component",
+			data: `
+components:
+- {layer: core, component: foo, rank: 10, matchers: [{label: namespace, values: [foo]}]}
+- {layer: core, component: foo, rank: 15, matchers: [{label: namespace, values: [bar]}]}
+`,
+			wantErr: `component "foo": defined multiple times`,
+		},
+		{
+			name: "both values and regex",
+			data: `
+components:
+- {layer: core, component: foo, rank: 10, matchers: [{label: namespace, values: [foo], regex: [foo]}]}
+`,
+			wantErr: `component "foo": matcher for label "namespace": only one of values or regex can be set`,
+		},
+		{
+			name: "invalid regex",
+			data: `
+components:
+- {layer: core, component: foo, rank: 10, matchers: [{label: alertname, regex: ["("]}]}
+`,
+			wantErr: `component "foo": matcher for label "alertname": error parsing regexp`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseMappings([]byte(tt.data))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			layer, comp, _ := m.matchComponent(tt.labels)
+			assert.Equal(t, tt.layer, layer)
+			assert.Equal(t, tt.comp, comp)
+		})
+	}
+}
+
+func TestSetMappings(t *testing.T) {
+	m, err := ParseMappings([]byte(`
+components:
+- {layer: workload, component: my-app, rank: 1000, matchers: [{label: namespace, values: [my-app]}]}
+`))
+	require.NoError(t, err)
+
+	SetMappings(m)
+	defer SetMappings(DefaultMappings())
+
+	healthMaps := MapAlerts([]model.LabelSet{
+		{"alertname": "KubePodCrashLooping", "namespace": "my-app"},
+		{"alertname": "KubePodCrashLooping", "namespace": "openshift-etcd"},
+	})
+	assert.Equal(t, "my-app", healthMaps[0].Component)
+	assert.Equal(t, "workload", healthMaps[0].Layer)
+	assert.Equal(t, "Others", healthMaps[1].Component)
+
+	assert.Equal(t, []ComponentRank{{Layer: "workload", Component: "my-app", Rank: 1000}}, BuildComponentRanks())
+}
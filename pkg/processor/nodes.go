@@ -0,0 +1,173 @@
+package processor
+
+// This file contains logic for mapping the signal to individual nodes.
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NodeRole represents the role of the node in the cluster.
+type NodeRole string
+
+const (
+	NodeRoleControlPlane NodeRole = "control-plane"
+	NodeRoleWorker       NodeRole = "worker"
+)
+
+// nodeLabels are the labels identifying the node in the signal,
+// in the order of preference.
+var nodeLabels = []model.LabelName{"node", "instance", "kubernetes_io_hostname"}
+
+// controlPlaneNodeLabels are the labels of the Node objects marking
+// the control-plane nodes.
+var controlPlaneNodeLabels = []string{
+	"node-role.kubernetes.io/control-plane",
+	"node-role.kubernetes.io/master",
+}
+
+// NodeInfo holds the information about a node needed for the mapping.
+type NodeInfo struct {
+	Name      string
+	Role      NodeRole
+	Addresses []string
+}
+
+// nodeRegistry allows looking up the nodes by name or address.
+type nodeRegistry struct {
+	nodes     []NodeInfo
+	byName    map[string]NodeInfo
+	byAddress map[string]NodeInfo
+}
+
+// nodes holds the nodes known to the analyzer. It's empty when the node
+// information is not available, e.g. without access to the Kubernetes API.
+var nodes atomic.Pointer[nodeRegistry]
+
+func init() {
+	SetNodes(nil)
+}
+
+// SetNodes replaces the nodes used for the mapping of the signal to nodes.
+func SetNodes(infos []NodeInfo) {
+	r := &nodeRegistry{
+		nodes:     infos,
+		byName:    make(map[string]NodeInfo, len(infos)),
+		byAddress: make(map[string]NodeInfo, len(infos)),
+	}
+	for _, n := range infos {
+		r.byName[n.Name] = n
+		for _, a := range n.Addresses {
+			r.byAddress[a] = n
+		}
+	}
+	nodes.Store(r)
+}
+
+// NodesFromKube converts the Kubernetes nodes to the NodeInfo.
+func NodesFromKube(kubeNodes []corev1.Node) []NodeInfo {
+	ret := make([]NodeInfo, 0, len(kubeNodes))
+	for _, n := range kubeNodes {
+		info := NodeInfo{Name: n.Name, Role: NodeRoleWorker}
+		for _, l := range controlPlaneNodeLabels {
+			if _, ok := n.Labels[l]; ok {
+				info.Role = NodeRoleControlPlane
+				break
+			}
+		}
+		for _, a := range n.Status.Addresses {
+			info.Addresses = append(info.Addresses, a.Address)
+		}
+		ret = append(ret, info)
+	}
+	return ret
+}
+
+// lookup finds the node by the name or address.
+func (r *nodeRegistry) lookup(id string) (NodeInfo, bool) {
+	if n, ok := r.byName[id]; ok {
+		return n, true
+	}
+	n, ok := r.byAddress[id]
+	return n, ok
+}
+
+// matchNode determines the node the signal belongs to.
+//
+// When the nodes are known, only the identifiers of the known nodes are
+// accepted. Otherwise, the value of the first node label is used as is.
+func (r *nodeRegistry) matchNode(labels model.LabelSet) (NodeInfo, model.LabelName, bool) {
+	for _, l := range nodeLabels {
+		id := string(labels[l])
+		if id == "" {
+			continue
+		}
+		if host, _, err := net.SplitHostPort(id); err == nil {
+			id = host
+		}
+
+		if len(r.byName) == 0 {
+			return NodeInfo{Name: id}, l, true
+		}
+		if n, ok := r.lookup(id); ok {
+			return n, l, true
+		}
+	}
+	return NodeInfo{}, "", false
+}
+
+// nodeLayer returns the layer of the node based on its role. The base layer
+// is used when the role is not known.
+func nodeLayer(base string, role NodeRole) string {
+	if role == "" {
+		return base
+	}
+	return base + "/" + string(role)
+}
+
+// nodeRank returns the rank of the node based on its role: the control-plane
+// nodes are ranked higher (lower number) than the worker nodes.
+func nodeRank(base int, role NodeRole) int {
+	if role == NodeRoleWorker {
+		return base + 1
+	}
+	return base
+}
+
+// NodesWatcher periodically loads the nodes from the Kubernetes API.
+type NodesWatcher struct {
+	client   kubernetes.Interface
+	interval time.Duration
+}
+
+// NewNodesWatcher creates a new NodesWatcher.
+func NewNodesWatcher(client kubernetes.Interface, interval time.Duration) *NodesWatcher {
+	return &NodesWatcher{client: client, interval: interval}
+}
+
+// Start starts loading the nodes in a goroutine and returns immediately.
+func (w *NodesWatcher) Start(ctx context.Context) {
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := w.refresh(ctx); err != nil {
+			slog.Error("Failed to load nodes, keeping the previous ones", "err", err)
+		}
+	}, w.interval)
+}
+
+func (w *NodesWatcher) refresh(ctx context.Context) error {
+	list, err := w.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	SetNodes(NodesFromKube(list.Items))
+	return nil
+}
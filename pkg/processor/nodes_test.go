@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testKubeNodes() []corev1.Node {
+	return []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "master-0",
+				Labels: map[string]string{"node-role.kubernetes.io/master": ""},
+			},
+			Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "worker-0",
+				Labels: map[string]string{"node-role.kubernetes.io/worker": ""},
+			},
+			Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.2"},
+			}},
+		},
+	}
+}
+
+func TestNodesFromKube(t *testing.T) {
+	assert.Equal(t, []NodeInfo{
+		{Name: "master-0", Role: NodeRoleControlPlane, Addresses: []string{"10.0.0.1"}},
+		{Name: "worker-0", Role: NodeRoleWorker, Addresses: []string{"10.0.0.2"}},
+	}, NodesFromKube(testKubeNodes()))
+}
+
+func TestMapAlerts_Nodes(t *testing.T) {
+	defer SetNodes(nil)
+
+	alerts := []model.LabelSet{
+		{"alertname": "KubeNodeNotReady", "node": "master-0"},
+		{"alertname": "NodeFilesystemSpaceFillingUp", "instance": "10.0.0.2:9100"},
+		{"alertname": "NodeFilesystemSpaceFillingUp", "instance": "10.0.0.3:9100"},
+		{"alertname": "MCCDrainError"},
+	}
+
+	// Without the node information, the node label is used as is.
+	healthMaps := MapAlerts(alerts)
+	assert.Equal(t, "master-0", healthMaps[0].Component)
+	assert.Equal(t, "compute", healthMaps[0].Layer)
+	assert.Equal(t, model.LabelValue("master-0"), healthMaps[0].SrcLabels["node"])
+	assert.Equal(t, "10.0.0.2", healthMaps[1].Component)
+	assert.Equal(t, "compute", healthMaps[3].Component)
+
+	SetNodes(NodesFromKube(testKubeNodes()))
+	healthMaps = MapAlerts(alerts)
+
+	assert.Equal(t, "master-0", healthMaps[0].Component)
+	assert.Equal(t, "compute/control-plane", healthMaps[0].Layer)
+
+	// The instance is resolved via the node address.
+	assert.Equal(t, "worker-0", healthMaps[1].Component)
+	assert.Equal(t, "compute/worker", healthMaps[1].Layer)
+	assert.Equal(t, model.LabelValue("10.0.0.2:9100"), healthMaps[1].SrcLabels["instance"])
+
+	// Unknown node and no node label fall back to the generic component.
+	assert.Equal(t, "compute", healthMaps[2].Component)
+	assert.Equal(t, "compute", healthMaps[2].Layer)
+	assert.Equal(t, "compute", healthMaps[3].Component)
+}
+
+func TestBuildComponentRanks_Nodes(t *testing.T) {
+	defer SetNodes(nil)
+	SetNodes(NodesFromKube(testKubeNodes()))
+
+	ranks := make(map[string]ComponentRank)
+	for _, r := range BuildComponentRanks() {
+		ranks[r.Component] = r
+	}
+	assert.Equal(t, ComponentRank{Layer: "compute", Component: "compute", Rank: 1}, ranks["compute"])
+	assert.Equal(t, ComponentRank{Layer: "compute/control-plane", Component: "master-0", Rank: 1}, ranks["master-0"])
+	assert.Equal(t, ComponentRank{Layer: "compute/worker", Component: "worker-0", Rank: 2}, ranks["worker-0"])
+}
+
+func TestNodesWatcher_refresh(t *testing.T) {
+	defer SetNodes(nil)
+
+	kubeNodes := testKubeNodes()
+	client := fake.NewClientset(&kubeNodes[0], &kubeNodes[1])
+	w := NewNodesWatcher(client, time.Minute)
+	require.NoError(t, w.refresh(context.Background()))
+
+	node, ok := nodes.Load().lookup("10.0.0.1")
+	require.True(t, ok)
+	assert.Equal(t, "master-0", node.Name)
+	assert.Len(t, nodes.Load().nodes, 2)
+}
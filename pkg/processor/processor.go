@@ -6,6 +6,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/openshift/cluster-health-analyzer/pkg/alertmanager"
@@ -35,21 +37,72 @@ type processor struct {
 	// groupSeverityCountMetrics exposes the current counts of group_ids by severity.
 	groupSeverityCountMetrics prom.MetricSet
 
+	// lifecycle exports the metrics of the incidents lifecycle. Optional.
+	lifecycle *incidentLifecycle
+
 	// interval is the time interval between processing iterations.
 	interval time.Duration
 
-	loader           prom.Loader
-	amLoader         alertmanager.Loader
-	groupsCollection *GroupsCollection
+	loader   prom.Loader
+	amLoader alertmanager.Loader
+
+	// mtx guards the groupsCollection, as it's accessed both by the processing
+	// loop and when saving the state.
+	mtx              sync.Mutex
+	groupsCollection *ClusterGroupsCollection
+	// processedUntil is the time of the last alerts processed by the groups collection.
+	processedUntil time.Time
+
+	// groupingConfig holds the parameters for grouping the alerts into incidents.
+	groupingConfig *GroupingConfig
+
+	// clusterLabel identifies the cluster of the alerts in the multi-cluster setup.
+	clusterLabel model.LabelName
+
+	// stateStore persists the groups collection across restarts. Optional.
+	stateStore StateStore
+	// stateSaveInterval is the time interval between saving the state.
+	stateSaveInterval time.Duration
+
+	// signalSources provide the signals processed together with the alerts.
+	signalSources []SignalSource
+
+	// refreshCh triggers the processing outside the regular interval.
+	refreshCh chan struct{}
+
+	// components holds the components status from the last processing.
+	components atomic.Pointer[[]ComponentStatus]
 }
 
 type ProcessorConfig struct {
 	Interval        time.Duration
 	PromURL         string
 	AlertManagerURL string
+
+	// Grouping holds the parameters for grouping the alerts into incidents.
+	// DefaultGroupingConfig is used when nil.
+	Grouping *GroupingConfig
+
+	// StateStore is used to persist the groups collection. When nil,
+	// the groups collection is always rebuilt from the Prometheus data.
+	StateStore StateStore
+	// StateSaveInterval is the time interval between saving the state
+	// to the StateStore.
+	StateSaveInterval time.Duration
+
+	// SignalSources provide additional signals (e.g. Kubernetes Events)
+	// to be processed together with the alerts.
+	SignalSources []SignalSource
+
+	// ClusterLabel is the label of the alerts identifying their cluster, e.g.
+	// "clusterID" when processing the alerts of multiple clusters from ACM/Thanos.
+	// The alerts of each cluster are grouped separately. When empty, all the
+	// alerts are considered to come from a single cluster.
+	ClusterLabel string
 }
 
-func NewProcessor(cfg ProcessorConfig, healthMapMetrics, componentsMetrics prom.MetricSet, groupSeverityCountMetrics prom.MetricSet) (*processor, error) {
+func NewProcessor(cfg ProcessorConfig, healthMapMetrics, componentsMetrics prom.MetricSet, groupSeverityCountMetrics prom.MetricSet,
+	incidentMetrics *IncidentMetrics) (*processor, error) {
 	promLoader, err := prom.NewLoader(cfg.PromURL)
 	if err != nil {
 		return nil, err
@@ -62,31 +115,82 @@ func NewProcessor(cfg ProcessorConfig, healthMapMetrics, componentsMetrics prom.
 		return nil, err
 	}
 
+	var lifecycle *incidentLifecycle
+	if incidentMetrics != nil {
+		lifecycle = newIncidentLifecycle(incidentMetrics)
+	}
+
 	return &processor{
 		healthMapMetrics:          healthMapMetrics,
 		componentsMetrics:         componentsMetrics,
 		groupSeverityCountMetrics: groupSeverityCountMetrics,
+		lifecycle:                 lifecycle,
 		interval:                  cfg.Interval,
 		loader:                    promLoader,
 		amLoader:                  amLoader,
+		groupingConfig:            cfg.Grouping,
+		clusterLabel:              model.LabelName(cfg.ClusterLabel),
+		stateStore:                cfg.StateStore,
+		stateSaveInterval:         cfg.StateSaveInterval,
+		signalSources:             cfg.SignalSources,
+		refreshCh:                 make(chan struct{}, 1),
 	}, nil
 }
 
 // Start starts the processor in a goroutine and returns immediately.
+//
+// When the state store is configured, the state is also saved periodically.
 func (p *processor) Start(ctx context.Context) {
 	go p.Run(ctx)
+
+	if p.stateStore != nil && p.stateSaveInterval > 0 {
+		go wait.Until(func() {
+			if err := p.SaveState(ctx); err != nil {
+				slog.Error("Failed to save groups collection state", "err", err)
+			}
+		}, p.stateSaveInterval, ctx.Done())
+	}
 }
 
 // initGroupsCollection initializes the groups collection by loading the alerts.
 //
 // The alerts are loaded for the given time range and step and prepares the structure
 // for assigning group-ids to the alerts.
+//
+// When a snapshot newer than the start is available in the state store, the groups
+// collection is restored from it and only the alerts since the snapshot are loaded.
 func (p *processor) InitGroupsCollection(ctx context.Context, start, end time.Time, step time.Duration) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
 	slog.Info("Initializing groups collection", "start", start, "end", end, "step", step)
-	p.groupsCollection = &GroupsCollection{}
+	p.groupsCollection = NewClusterGroupsCollection(p.clusterLabel, p.groupingConfig)
+	p.processedUntil = end
+
+	snapshot := p.loadState(ctx)
+	if snapshot != nil && snapshot.Timestamp.After(start) {
+		slog.Info("Restoring groups collection from snapshot",
+			"timestamp", snapshot.Timestamp, "groups", len(snapshot.Groups), "clusters", len(snapshot.Clusters))
+		p.groupsCollection = NewClusterGroupsCollectionFromSnapshot(snapshot, p.clusterLabel, p.groupingConfig)
+		if !snapshot.Timestamp.Before(end) {
+			return nil
+		}
+
+		slog.Info("Loading alerts range since snapshot")
+		alertsRange, err := p.loadSignalsRange(ctx, snapshot.Timestamp, end, step)
+		if err != nil {
+			return err
+		}
+		slog.Info("Loaded alerts range", "len", len(alertsRange))
+		p.groupsCollection.processHistoricalAlerts(alertsRange)
+
+		// The group-ids are already known from the snapshot, no need
+		// to recover them from the health map.
+		return nil
+	}
 
 	slog.Info("Loading alerts range")
-	alertsRange, err := p.loader.LoadAlertsRange(ctx, start, end, step)
+	alertsRange, err := p.loadSignalsRange(ctx, start, end, step)
 	if err != nil {
 		return err
 	}
@@ -109,32 +213,100 @@ func (p *processor) InitGroupsCollection(ctx context.Context, start, end time.Ti
 	return nil
 }
 
+// loadSignalsRange loads the firing alerts together with the ClusterOperator
+// conditions for the given time range.
+func (p *processor) loadSignalsRange(ctx context.Context, start, end time.Time, step time.Duration) (prom.RangeVector, error) {
+	alertsRange, err := p.loader.LoadAlertsRange(ctx, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	conditionsRange, err := p.loadConditionsRange(ctx, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	return append(alertsRange, conditionsRange...), nil
+}
+
+// loadState loads the last snapshot from the state store. Failing to load
+// the snapshot is not fatal: the groups collection is rebuilt from scratch instead.
+func (p *processor) loadState(ctx context.Context) *GroupsSnapshot {
+	if p.stateStore == nil {
+		return nil
+	}
+	snapshot, err := p.stateStore.Load(ctx)
+	if err != nil {
+		slog.Error("Failed to load groups collection state, rebuilding from history", "err", err)
+		return nil
+	}
+	if snapshot == nil {
+		slog.Info("No groups collection state found, rebuilding from history")
+	}
+	return snapshot
+}
+
+// SaveState saves the current snapshot of the groups collection to the state store.
+// It's a no-op if the state store is not configured.
+func (p *processor) SaveState(ctx context.Context) error {
+	if p.stateStore == nil {
+		return nil
+	}
+
+	p.mtx.Lock()
+	if p.groupsCollection == nil {
+		p.mtx.Unlock()
+		return nil
+	}
+	snapshot := p.groupsCollection.Snapshot(p.processedUntil)
+	p.mtx.Unlock()
+
+	if err := p.stateStore.Save(ctx, snapshot); err != nil {
+		return err
+	}
+	slog.Info("Saved groups collection state", "groups", len(snapshot.Groups))
+	return nil
+}
+
 // Run runs the processor and blocks until canceled via the ctx.
+//
+// The processing is repeated after the interval, or sooner when triggered
+// via TriggerRefresh.
 func (p *processor) Run(ctx context.Context) {
-	// wait.Until provides the core for the repeated execution of the Process method
-	wait.Until(func() {
-		// wait.ExponentialBackoffWithContext provides a backoff mechanism
-		// in case of errors during the Process method execution.
-		err := wait.ExponentialBackoffWithContext(
-			ctx,
-			wait.Backoff{Duration: time.Second, Steps: 4, Factor: 1.5},
-			func(ctx context.Context) (bool, error) {
-				slog.Info("Start processing")
-
-				err := p.Process(ctx)
-				if err != nil {
-					slog.Error("Error processing", "err", err)
-					// We don't return an error here because we want to keep retrying.
-					return false, nil
-				}
-
-				slog.Info("End processing")
-				return true, nil
-			})
-		if err != nil {
-			slog.Error("Error processing", "err", err)
+	for {
+		p.processWithBackoff(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.interval):
+		case <-p.refreshCh:
+			slog.Info("Processing triggered")
 		}
-	}, p.interval, ctx.Done())
+	}
+}
+
+// processWithBackoff runs a single processing iteration, retrying on errors.
+func (p *processor) processWithBackoff(ctx context.Context) {
+	// wait.ExponentialBackoffWithContext provides a backoff mechanism
+	// in case of errors during the Process method execution.
+	err := wait.ExponentialBackoffWithContext(
+		ctx,
+		wait.Backoff{Duration: time.Second, Steps: 4, Factor: 1.5},
+		func(ctx context.Context) (bool, error) {
+			slog.Info("Start processing")
+
+			err := p.Process(ctx)
+			if err != nil {
+				slog.Error("Error processing", "err", err)
+				// We don't return an error here because we want to keep retrying.
+				return false, nil
+			}
+
+			slog.Info("End processing")
+			return true, nil
+		})
+	if err != nil {
+		slog.Error("Error processing", "err", err)
+	}
 }
 
 // dedupHealthMaps deduplicates the health maps by combining the health values.
@@ -162,7 +334,11 @@ func dedupHealthMaps(healthMaps []ComponentHealthMap) []ComponentHealthMap {
 }
 
 func (p *processor) assignAlertsToGroups(alerts []model.LabelSet, t time.Time) []model.LabelSet {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
 	processedAlerts := p.groupsCollection.ProcessAlertsBatch(alerts, t)
+	p.processedUntil = t
 
 	// Prune the groups collection to remove old groups.
 	p.groupsCollection.PruneGroups(t)
@@ -171,24 +347,25 @@ func (p *processor) assignAlertsToGroups(alerts []model.LabelSet, t time.Time) [
 
 // Process performs a single iteration of the processor.
 func (p *processor) Process(ctx context.Context) error {
-	err := p.updateHealthMap(ctx)
+	healthMap, err := p.updateHealthMap(ctx)
 	if err != nil {
 		return err
 	}
 
-	p.updateComponentsMetrics()
+	p.updateComponentsMetrics(healthMap)
 
 	return nil
 }
 
-func (p *processor) updateHealthMap(ctx context.Context) error {
+func (p *processor) updateHealthMap(ctx context.Context) ([]ComponentHealthMap, error) {
 	t := time.Now()
 	alerts, err := p.loadAlerts(ctx, t)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	healthMap := MapAlerts(alerts)
+	setClusterIDs(healthMap, alerts, p.clusterLabel)
 	healthMap = dedupHealthMaps(healthMap)
 
 	healthMapMetrics := make([]prom.Metric, 0, len(healthMap))
@@ -203,7 +380,42 @@ func (p *processor) updateHealthMap(ctx context.Context) error {
 	severityCountsMetrics := p.computeSeverityCountMetrics(healthMap)
 	p.groupSeverityCountMetrics.Update(severityCountsMetrics)
 
-	return nil
+	timelineMetrics, severityChangeMetrics, rootCauseMetrics := p.updateTimelines(healthMap, t)
+	if p.lifecycle != nil {
+		p.lifecycle.update(healthMap, p.groupStarts(), t)
+		p.lifecycle.metrics.TimelineEvents.Update(timelineMetrics)
+		p.lifecycle.metrics.LastSeverityChange.Update(severityChangeMetrics)
+		p.lifecycle.metrics.RootCause.Update(rootCauseMetrics)
+	}
+
+	return healthMap, nil
+}
+
+// updateTimelines records the changes of the incidents and returns
+// the timeline and root cause metrics to be exported.
+func (p *processor) updateTimelines(healthMap []ComponentHealthMap, t time.Time) (timeline, severityChanges, rootCauses []prom.Metric) {
+	ranks := BuildComponentRanks()
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.groupsCollection == nil {
+		return nil, nil, nil
+	}
+	p.groupsCollection.UpdateTimelines(healthMap, t)
+	timeline, severityChanges = p.groupsCollection.timelineMetrics()
+	return timeline, severityChanges, p.groupsCollection.rootCauseMetrics(ranks)
+}
+
+// groupStarts returns the start times of the groups known to the groups collection.
+func (p *processor) groupStarts() map[string]time.Time {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.groupsCollection == nil {
+		return nil
+	}
+	return p.groupsCollection.groupStarts()
 }
 
 func (p *processor) loadAlerts(ctx context.Context, t time.Time) ([]model.LabelSet, error) {
@@ -212,6 +424,15 @@ func (p *processor) loadAlerts(ctx context.Context, t time.Time) ([]model.LabelS
 		return nil, err
 	}
 
+	conditions, err := p.loadConditions(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	alerts = append(alerts, conditions...)
+	for _, src := range p.signalSources {
+		alerts = append(alerts, src.Signals(t)...)
+	}
+
 	if p.groupsCollection != nil {
 		alerts = p.assignAlertsToGroups(alerts, t)
 	}
@@ -295,64 +516,140 @@ func (p *processor) computeSeverityCountMetrics(alertsHealthMap []ComponentHealt
 	severityCount := countSeverities(alertsHealthMap)
 
 	metrics := make([]prom.Metric, 0, len(severityCount))
-	for severity, count := range severityCount {
+	for k, count := range severityCount {
+		labels := model.LabelSet{
+			"severity": model.LabelValue(k.severity),
+		}
+		setClusterLabels(labels, k.cluster, k.clusterID)
 		metrics = append(metrics, prom.Metric{
-			Labels: model.LabelSet{
-				"severity": model.LabelValue(severity),
-			},
-			Value: float64(count),
+			Labels: labels,
+			Value:  float64(count),
 		})
 	}
 
 	return metrics
 }
 
-func countSeverities(healthMaps []ComponentHealthMap) map[string]int {
+// severityKey identifies the counted severity of the cluster.
+type severityKey struct {
+	cluster   string
+	clusterID string
+	severity  string
+}
+
+// groupKey identifies the group of the cluster.
+type groupKey struct {
+	cluster   string
+	clusterID string
+	groupID   string
+}
+
+func countSeverities(healthMaps []ComponentHealthMap) map[severityKey]int {
 	healthValues := getCurrentMaxHealthValues(healthMaps)
-	severities := convertHealthValuesToSeverities(healthValues)
 
-	count := make(map[string]int)
-	for _, severity := range severities {
-		count[severity]++
+	count := make(map[severityKey]int)
+	for k, health := range healthValues {
+		count[severityKey{cluster: k.cluster, clusterID: k.clusterID, severity: health.String()}]++
 	}
 
 	return count
 }
 
-func getCurrentMaxHealthValues(healthMaps []ComponentHealthMap) map[string]HealthValue {
-	healthValues := make(map[string]HealthValue)
+func getCurrentMaxHealthValues(healthMaps []ComponentHealthMap) map[groupKey]HealthValue {
+	healthValues := make(map[groupKey]HealthValue)
 	for _, alert := range healthMaps {
-		groupID := alert.GroupId
-		if groupID == "" {
+		if alert.GroupId == "" {
 			continue
 		}
-		healthValues[groupID] = max(healthValues[groupID], alert.Health)
+		k := groupKey{cluster: alert.Cluster, clusterID: alert.ClusterID, groupID: alert.GroupId}
+		healthValues[k] = max(healthValues[k], alert.Health)
 	}
 	return healthValues
 }
 
-func convertHealthValuesToSeverities(healthValues map[string]HealthValue) []string {
-	severities := make([]string, 0, len(healthValues))
-	for _, health := range healthValues {
-		severities = append(severities, health.String())
+// updateComponentsMetrics exports the ranks of the components, with the healthy
+// label based on the current health map.
+func (p *processor) updateComponentsMetrics(healthMap []ComponentHealthMap) {
+	statuses := buildComponentStatuses(healthMap)
+	p.components.Store(&statuses)
+
+	metrics := make([]prom.Metric, 0, len(statuses))
+	for _, c := range statuses {
+		metrics = append(metrics, prom.Metric{
+			Labels: model.LabelSet{
+				"layer":     model.LabelValue(c.Layer),
+				"component": model.LabelValue(c.Component),
+				"healthy":   model.LabelValue(fmt.Sprintf("%t", c.Healthy)),
+			},
+			Value: float64(c.Rank),
+		})
 	}
-	return severities
+	p.componentsMetrics.Update(metrics)
+}
+
+// ComponentStatus holds the current health status of a component.
+type ComponentStatus struct {
+	Layer     string `json:"layer"`
+	Component string `json:"component"`
+	Rank      int    `json:"rank"`
+	Healthy   bool   `json:"healthy"`
+	// Severity is the highest severity of the non-silenced signals of the component.
+	Severity string `json:"severity"`
 }
 
-func (p *processor) updateComponentsMetrics() {
+// buildComponentStatuses combines the ranked components with the health map.
+func buildComponentStatuses(healthMap []ComponentHealthMap) []ComponentStatus {
 	ranks := BuildComponentRanks()
+	unhealthy := unhealthyComponents(healthMap)
+
+	severities := make(map[componentKey]HealthValue)
+	for _, hm := range healthMap {
+		if hm.Silenced == "true" {
+			continue
+		}
+		k := componentKey{hm.Layer, hm.Component}
+		severities[k] = max(severities[k], hm.Health)
+	}
 
-	metrics := make([]prom.Metric, 0)
+	ret := make([]ComponentStatus, 0, len(ranks))
 	for _, r := range ranks {
-		metrics = append(metrics, prom.Metric{
-			Labels: model.LabelSet{
-				"layer":     model.LabelValue(r.Layer),
-				"component": model.LabelValue(r.Component),
-			},
-			Value: float64(r.Rank),
+		k := componentKey{r.Layer, r.Component}
+		_, isUnhealthy := unhealthy[k]
+		ret = append(ret, ComponentStatus{
+			Layer:     r.Layer,
+			Component: r.Component,
+			Rank:      r.Rank,
+			Healthy:   !isUnhealthy,
+			Severity:  severities[k].String(),
 		})
 	}
-	p.componentsMetrics.Update(metrics)
+	return ret
+}
+
+// Components returns the components with their health status as of the last
+// processing. It's empty before the first processing finishes.
+func (p *processor) Components() []ComponentStatus {
+	if c := p.components.Load(); c != nil {
+		return *c
+	}
+	return nil
+}
+
+type componentKey struct {
+	layer     string
+	component string
+}
+
+// unhealthyComponents returns the components with a non-silenced signal
+// of warning or critical severity.
+func unhealthyComponents(healthMap []ComponentHealthMap) map[componentKey]struct{} {
+	ret := make(map[componentKey]struct{})
+	for _, hm := range healthMap {
+		if hm.Health > Healthy && hm.Silenced != "true" {
+			ret[componentKey{hm.Layer, hm.Component}] = struct{}{}
+		}
+	}
+	return ret
 }
 
 func isAlertSilenced(alert model.LabelSet, silences map[string][]models.Alert) bool {
@@ -389,21 +686,8 @@ type ComponentRank struct {
 	Rank      int
 }
 
+// BuildComponentRanks returns the ranks of the components defined
+// in the component mappings.
 func BuildComponentRanks() []ComponentRank {
-	components := make(map[string]ComponentRank)
-	components["compute"] = ComponentRank{Layer: "compute", Component: "compute", Rank: 1}
-
-	for i, m := range coreMatchers {
-		components[m.component] = ComponentRank{Layer: "core", Component: m.component, Rank: 10 + i*5}
-	}
-
-	for i, m := range workloadMatchers {
-		components[m.component] = ComponentRank{Layer: "workload", Component: m.component, Rank: 1000 + i*5}
-	}
-
-	ret := make([]ComponentRank, 0, len(components))
-	for _, c := range components {
-		ret = append(ret, c)
-	}
-	return ret
+	return mappings.Load().ranks()
 }
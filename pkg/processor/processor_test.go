@@ -394,3 +394,41 @@ func Test_evaluateSilences(t *testing.T) {
 	}
 
 }
+
+func Test_computeSeverityCountMetrics_MultipleClusters(t *testing.T) {
+	alertsHealthMap := []ComponentHealthMap{
+		{
+			GroupId:   "group1",
+			Health:    Critical,
+			Cluster:   "prod",
+			ClusterID: "c1",
+		},
+		{
+			GroupId:   "group2",
+			Health:    Critical,
+			Cluster:   "prod",
+			ClusterID: "c1",
+		},
+		{
+			GroupId:   "group3",
+			Health:    Critical,
+			Cluster:   "dev",
+			ClusterID: "c2",
+		},
+	}
+	expected := []prom.Metric{
+		{
+			Labels: model.LabelSet{"severity": "critical", "cluster": "prod", "clusterID": "c1"},
+			Value:  2,
+		},
+		{
+			Labels: model.LabelSet{"severity": "critical", "cluster": "dev", "clusterID": "c2"},
+			Value:  1,
+		},
+	}
+	p := &processor{}
+
+	got := p.computeSeverityCountMetrics(alertsHealthMap)
+
+	assert.ElementsMatch(t, expected, got)
+}
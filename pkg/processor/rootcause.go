@@ -0,0 +1,205 @@
+package processor
+
+// This file contains logic for ranking the signals of the incidents
+// as the candidates for their root cause.
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+const IncidentRootCauseMetric = "cluster_health_incident_root_cause"
+
+// rootCauseWeights are the weights of the criteria the root cause candidates
+// are scored on. Each criterion is scored from 0 to 1, so the total score
+// is between 0 and the sum of the weights.
+type rootCauseWeights struct {
+	// causal favors the known causes of the other signals of the incident
+	// and disfavors their known effects.
+	causal float64
+	// start favors the signals that started firing first.
+	start float64
+	// rank favors the more fundamental components, with the lower rank.
+	rank float64
+	// layer favors the lower layers: compute over core over workload.
+	layer float64
+}
+
+// defaultRootCauseWeights sum up to 1.
+var defaultRootCauseWeights = rootCauseWeights{
+	causal: 0.4,
+	start:  0.25,
+	rank:   0.2,
+	layer:  0.15,
+}
+
+// layerScores are the scores of the layers, the lower the layer,
+// the more likely it's the root cause.
+var layerScores = map[string]float64{
+	"compute":  1,
+	"core":     0.5,
+	"workload": 0,
+}
+
+// causalPairs are the known causes of the signals by their name, e.g. the
+// pods are not ready because their node is not ready.
+var causalPairs = map[string][]string{
+	"KubeNodeNotReady":        {"KubePodNotReady", "KubeDeploymentReplicasMismatch", "KubeStatefulSetReplicasMismatch", "KubeDaemonSetRolloutStuck", "KubeDaemonSetMisScheduled", "TargetDown"},
+	"KubeNodeUnreachable":     {"KubePodNotReady", "KubeDeploymentReplicasMismatch", "KubeStatefulSetReplicasMismatch", "KubeDaemonSetRolloutStuck", "KubeDaemonSetMisScheduled", "TargetDown"},
+	"etcdNoLeader":            {"etcdHighNumberOfLeaderChanges", "KubeAPIErrorBudgetBurn", "KubeAPIDown", "ClusterOperatorDegraded", "ClusterOperatorDown"},
+	"etcdMembersDown":         {"etcdHighNumberOfFailedGRPCRequests", "KubeAPIErrorBudgetBurn", "ClusterOperatorDegraded"},
+	"etcdInsufficientMembers": {"etcdNoLeader", "KubeAPIErrorBudgetBurn", "KubeAPIDown", "ClusterOperatorDegraded", "ClusterOperatorDown"},
+	"KubeAPIDown":             {"KubeAPIErrorBudgetBurn", "TargetDown", "ClusterOperatorDegraded", "ClusterOperatorDown"},
+	"KubePodCrashLooping":     {"KubePodNotReady", "KubeDeploymentReplicasMismatch", "KubeStatefulSetReplicasMismatch", "KubeContainerWaiting"},
+	"ClusterOperatorDown":     {"ClusterOperatorDegraded"},
+	"Available":               {"Degraded", "Progressing", "ClusterOperatorDegraded"},
+}
+
+// rootCauseCandidate is a signal of the incident scored as its root cause.
+type rootCauseCandidate struct {
+	SrcType   SrcType
+	SrcLabels model.LabelSet
+	Layer     string
+	Component string
+	Score     float64
+}
+
+// Name returns the name of the signal: the alert name, the ClusterOperator
+// condition or the reason of the event.
+func (c rootCauseCandidate) Name() string {
+	return signalName(c.SrcLabels)
+}
+
+func signalName(srcLabels model.LabelSet) string {
+	for _, l := range []model.LabelName{AlertNameLabelKey, "condition", "reason"} {
+		if v := srcLabels[l]; v != "" {
+			return string(v)
+		}
+	}
+	return ""
+}
+
+// rankRootCauseCandidates scores the signals of the incident and returns them
+// ordered from the most likely root cause. The ranks are the ranks of the
+// components, the components without rank are considered the least fundamental.
+func rankRootCauseCandidates(signals []timelineSignal, ranks map[componentKey]int,
+	weights rootCauseWeights) []rootCauseCandidate {
+	if len(signals) == 0 {
+		return nil
+	}
+
+	names := make(map[string]bool, len(signals))
+	for _, s := range signals {
+		names[signalName(s.SrcLabels)] = true
+	}
+
+	first, last := signals[0].Start, signals[0].Start
+	minRank, maxRank := -1, -1
+	for _, s := range signals {
+		if s.Start.Before(first) {
+			first = s.Start
+		}
+		if s.Start.After(last) {
+			last = s.Start
+		}
+		if r, ok := ranks[componentKey{s.Layer, s.Component}]; ok {
+			if minRank < 0 || r < minRank {
+				minRank = r
+			}
+			maxRank = max(maxRank, r)
+		}
+	}
+
+	ret := make([]rootCauseCandidate, 0, len(signals))
+	for _, s := range signals {
+		name := signalName(s.SrcLabels)
+
+		// neutral unless the signal is a known cause or effect of the others
+		causal := 0.5
+		for cause, effects := range causalPairs {
+			if cause != name && names[cause] && slices.Contains(effects, name) {
+				causal = 0
+				break
+			}
+		}
+		for _, effect := range causalPairs[name] {
+			if effect != name && names[effect] {
+				causal = 1
+				break
+			}
+		}
+
+		start := 1.0
+		if span := last.Sub(first); span > 0 {
+			start = 1 - float64(s.Start.Sub(first))/float64(span)
+		}
+
+		rank := 0.0
+		if r, ok := ranks[componentKey{s.Layer, s.Component}]; ok {
+			rank = 1
+			if maxRank > minRank {
+				rank = 1 - float64(r-minRank)/float64(maxRank-minRank)
+			}
+		}
+
+		// the nodes layers are suffixed by the node role, e.g. compute/control-plane
+		layer, _, _ := strings.Cut(s.Layer, "/")
+
+		ret = append(ret, rootCauseCandidate{
+			SrcType:   s.SrcType,
+			SrcLabels: s.SrcLabels,
+			Layer:     s.Layer,
+			Component: s.Component,
+			Score: weights.causal*causal + weights.start*start +
+				weights.rank*rank + weights.layer*layerScores[layer],
+		})
+	}
+
+	slices.SortFunc(ret, func(a, b rootCauseCandidate) int {
+		return cmp.Or(
+			cmp.Compare(b.Score, a.Score),
+			strings.Compare(string(a.SrcType)+a.SrcLabels.String(), string(b.SrcType)+b.SrcLabels.String()),
+		)
+	})
+	return ret
+}
+
+// rootCauseMetrics returns the most likely root cause of each firing incident
+// of all the clusters to be exported, with its score as the value.
+func (c *ClusterGroupsCollection) rootCauseMetrics(componentRanks []ComponentRank) []prom.Metric {
+	ranks := make(map[componentKey]int, len(componentRanks))
+	for _, r := range componentRanks {
+		ranks[componentKey{r.Layer, r.Component}] = r.Rank
+	}
+
+	var ret []prom.Metric
+	for _, gc := range c.Clusters {
+		for _, id := range slices.Sorted(maps.Keys(gc.Timelines)) {
+			tl := gc.Timelines[id]
+			candidates := rankRootCauseCandidates(slices.Collect(maps.Values(tl.Signals)), ranks, defaultRootCauseWeights)
+			if len(candidates) == 0 {
+				continue
+			}
+			rc := candidates[0]
+			labels := model.LabelSet{
+				"group_id":   model.LabelValue(id),
+				"root_cause": model.LabelValue(rc.Name()),
+				"type":       model.LabelValue(rc.SrcType),
+				"layer":      model.LabelValue(rc.Layer),
+				"component":  model.LabelValue(rc.Component),
+			}
+			setClusterLabels(labels, tl.Cluster, tl.ClusterID)
+			for k, v := range rc.SrcLabels {
+				labels[SrcLabelPrefix+k] = v
+			}
+			ret = append(ret, prom.Metric{Labels: labels, Value: rc.Score})
+		}
+	}
+	return ret
+}
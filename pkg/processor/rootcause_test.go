@@ -0,0 +1,121 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankRootCauseCandidates(t *testing.T) {
+	now := time.Unix(1000, 0)
+	ranks := map[componentKey]int{
+		{"compute", "compute"}:               1,
+		{"core", "etcd"}:                     10,
+		{"core", "kube-apiserver"}:           15,
+		{"workload", "openshift-monitoring"}: 100,
+	}
+	signal := func(alertname, layer, component string, start time.Duration) timelineSignal {
+		return timelineSignal{
+			SrcType:   Alert,
+			SrcLabels: model.LabelSet{"alertname": model.LabelValue(alertname)},
+			Layer:     layer,
+			Component: component,
+			Health:    Warning,
+			Start:     now.Add(start),
+		}
+	}
+	names := func(candidates []rootCauseCandidate) []string {
+		ret := make([]string, 0, len(candidates))
+		for _, c := range candidates {
+			ret = append(ret, c.Name())
+		}
+		return ret
+	}
+
+	tests := []struct {
+		name     string
+		signals  []timelineSignal
+		expected []string
+	}{
+		{
+			name: "known cause wins over the earlier start",
+			signals: []timelineSignal{
+				signal("KubeAPIErrorBudgetBurn", "core", "kube-apiserver", 0),
+				signal("etcdNoLeader", "core", "etcd", time.Minute),
+			},
+			expected: []string{"etcdNoLeader", "KubeAPIErrorBudgetBurn"},
+		},
+		{
+			name: "earliest start and the more fundamental component",
+			signals: []timelineSignal{
+				signal("PrometheusRuleFailures", "workload", "openshift-monitoring", 2*time.Minute),
+				signal("etcdHighFsyncDurations", "core", "etcd", 0),
+				signal("KubeAPIErrorBudgetBurn", "core", "kube-apiserver", time.Minute),
+			},
+			expected: []string{"etcdHighFsyncDurations", "KubeAPIErrorBudgetBurn", "PrometheusRuleFailures"},
+		},
+		{
+			name: "lower layer of the node with the role",
+			signals: []timelineSignal{
+				signal("KubeNodeNotReady", "compute/control-plane", "master-0", time.Minute),
+				signal("KubePodNotReady", "workload", "openshift-monitoring", 0),
+			},
+			expected: []string{"KubeNodeNotReady", "KubePodNotReady"},
+		},
+		{
+			name: "lower layer of the worker node",
+			signals: []timelineSignal{
+				signal("KubeNodeNotReady", "compute/worker", "worker-0", time.Minute),
+				signal("KubePodNotReady", "workload", "openshift-monitoring", 0),
+			},
+			expected: []string{"KubeNodeNotReady", "KubePodNotReady"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := rankRootCauseCandidates(tt.signals, ranks, defaultRootCauseWeights)
+			assert.Equal(t, tt.expected, names(candidates))
+			for _, c := range candidates {
+				assert.GreaterOrEqual(t, c.Score, 0.0)
+				assert.LessOrEqual(t, c.Score, 1.0)
+			}
+		})
+	}
+
+	assert.Empty(t, rankRootCauseCandidates(nil, ranks, defaultRootCauseWeights))
+}
+
+func TestClusterGroupsCollection_RootCauseMetrics(t *testing.T) {
+	c := NewClusterGroupsCollection("", nil)
+	c.cluster("").AddGroup(&GroupMatcher{GroupID: "g1", RootGroupID: "g1"})
+	c.cluster("").AddGroup(&GroupMatcher{GroupID: "g2", RootGroupID: "g2"})
+
+	t0 := time.Unix(1000, 0)
+	c.UpdateTimelines([]ComponentHealthMap{
+		{GroupId: "g1", Layer: "core", Component: "kube-apiserver", SrcType: Alert, Health: Critical,
+			SrcLabels: model.LabelSet{"alertname": "KubeAPIErrorBudgetBurn", "severity": "critical"}},
+		{GroupId: "g2", Layer: "core", Component: "dns", SrcType: Alert, Health: Warning,
+			SrcLabels: model.LabelSet{"alertname": "CoreDNSErrorsHigh", "severity": "warning"}},
+	}, t0)
+	c.UpdateTimelines([]ComponentHealthMap{
+		{GroupId: "g1", Layer: "core", Component: "kube-apiserver", SrcType: Alert, Health: Critical,
+			SrcLabels: model.LabelSet{"alertname": "KubeAPIErrorBudgetBurn", "severity": "critical"}},
+		{GroupId: "g1", Layer: "core", Component: "etcd", SrcType: Alert, Health: Critical,
+			SrcLabels: model.LabelSet{"alertname": "etcdNoLeader", "namespace": "openshift-etcd", "severity": "critical"}},
+	}, t0.Add(time.Minute))
+
+	metrics := c.rootCauseMetrics([]ComponentRank{
+		{Layer: "core", Component: "etcd", Rank: 10},
+		{Layer: "core", Component: "kube-apiserver", Rank: 15},
+	})
+	// the resolved incident has no root cause
+	require.Len(t, metrics, 1)
+	assert.Equal(t, model.LabelSet{
+		"group_id": "g1", "root_cause": "etcdNoLeader", "type": "alert", "layer": "core", "component": "etcd",
+		"src_alertname": "etcdNoLeader", "src_namespace": "openshift-etcd", "src_severity": "critical",
+	}, metrics[0].Labels)
+	assert.InDelta(t, 0.4+0.2+0.15*0.5, metrics[0].Value, 1e-9)
+}
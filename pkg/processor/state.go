@@ -0,0 +1,174 @@
+package processor
+
+// This file contains logic for persisting the state of the groups collection
+// across restarts of the analyzer.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/common"
+)
+
+// GroupsSnapshot is a serializable representation of the GroupsCollection
+// at a specific point in time.
+type GroupsSnapshot struct {
+	// Timestamp is the time the snapshot was taken. Only the alerts
+	// after this time need to be replayed after restoring the snapshot.
+	Timestamp time.Time    `json:"timestamp"`
+	Groups    []groupState `json:"groups"`
+	// Clusters holds the groups by the cluster ID in the multi-cluster setup,
+	// the groups of the single cluster are kept in Groups.
+	Clusters map[string][]groupState `json:"clusters,omitempty"`
+	// Timelines holds the timelines of the incidents of all the clusters
+	// by their root group ID.
+	Timelines map[string]*incidentTimeline `json:"timelines,omitempty"`
+}
+
+// groupState is a serializable representation of the GroupMatcher.
+type groupState struct {
+	GroupID     string     `json:"group_id"`
+	RootGroupID string     `json:"root_group_id"`
+	Start       model.Time `json:"start"`
+	Modified    model.Time `json:"modified"`
+	End         model.Time `json:"end"`
+	// Distance is nil for the time-based matchers with infinite distance,
+	// as JSON doesn't support encoding infinity.
+	Distance *float64         `json:"distance,omitempty"`
+	Matchers []model.LabelSet `json:"matchers"`
+}
+
+// StateStore persists the snapshots of the groups collection.
+type StateStore interface {
+	// Load returns the last saved snapshot. It returns nil snapshot
+	// and no error if no snapshot has been saved yet.
+	Load(ctx context.Context) (*GroupsSnapshot, error)
+	// Save persists the snapshot, replacing the previous one.
+	Save(ctx context.Context, snapshot *GroupsSnapshot) error
+}
+
+// Snapshot returns the snapshot of the groups collection taken at time t.
+func (gc *GroupsCollection) Snapshot(t time.Time) *GroupsSnapshot {
+	return &GroupsSnapshot{Timestamp: t, Groups: gc.groupStates(), Timelines: cloneTimelines(gc.Timelines)}
+}
+
+// groupStates returns the serializable representation of the groups.
+func (gc *GroupsCollection) groupStates() []groupState {
+	groups := make([]groupState, 0, len(gc.Groups))
+	for _, g := range gc.Groups {
+		state := groupState{
+			GroupID:     g.GroupID,
+			RootGroupID: g.RootGroupID,
+			Start:       g.Start,
+			Modified:    g.Modified,
+			End:         g.End,
+			Matchers:    make([]model.LabelSet, 0, len(g.Matchers)),
+		}
+		if !math.IsInf(g.Distance, 1) {
+			distance := g.Distance
+			state.Distance = &distance
+		}
+		for _, m := range g.Matchers {
+			state.Matchers = append(state.Matchers, m.Labels)
+		}
+		groups = append(groups, state)
+	}
+	return groups
+}
+
+// NewGroupsCollectionFromSnapshot restores the groups collection from the snapshot.
+func NewGroupsCollectionFromSnapshot(snapshot *GroupsSnapshot) *GroupsCollection {
+	return newGroupsCollectionFromStates(snapshot.Groups, snapshot.Timelines)
+}
+
+// newGroupsCollectionFromStates restores the groups collection from the group
+// states, together with the timelines of its groups.
+func newGroupsCollectionFromStates(states []groupState, timelines map[string]*incidentTimeline) *GroupsCollection {
+	gc := &GroupsCollection{}
+	for _, state := range states {
+		g := &GroupMatcher{
+			GroupID:     state.GroupID,
+			RootGroupID: state.RootGroupID,
+			Start:       state.Start,
+			Modified:    state.Modified,
+			End:         state.End,
+			Distance:    math.Inf(1),
+			Matchers:    make([]common.LabelsSubsetMatcher, 0, len(state.Matchers)),
+		}
+		if state.Distance != nil {
+			g.Distance = *state.Distance
+		}
+		for _, labels := range state.Matchers {
+			g.Matchers = append(g.Matchers, common.LabelsSubsetMatcher{Labels: labels})
+		}
+		gc.AddGroup(g)
+		if tl, ok := timelines[g.RootGroupID]; ok {
+			if gc.Timelines == nil {
+				gc.Timelines = make(map[string]*incidentTimeline)
+			}
+			gc.Timelines[g.RootGroupID] = tl
+		}
+	}
+	return gc
+}
+
+// fileStateStore stores the snapshot as a JSON file, e.g. on a persistent volume.
+type fileStateStore struct {
+	path string
+}
+
+// NewFileStateStore creates a StateStore persisting the snapshot to the file
+// on the given path.
+func NewFileStateStore(path string) StateStore {
+	return &fileStateStore{path: path}
+}
+
+func (s *fileStateStore) Load(ctx context.Context) (*GroupsSnapshot, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalSnapshot(data)
+}
+
+// Save writes the snapshot to a temporary file first and renames it afterwards,
+// so that a crash during the write doesn't corrupt the previous snapshot.
+func (s *fileStateStore) Save(ctx context.Context, snapshot *GroupsSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // nolint:errcheck
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() // nolint:errcheck
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func unmarshalSnapshot(data []byte) (*GroupsSnapshot, error) {
+	snapshot := &GroupsSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,138 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// stateDataKey is the key of the snapshot in the ConfigMap or Secret data.
+const stateDataKey = "groups.json"
+
+// StateObjectKind represents the kind of the Kubernetes object used
+// to store the snapshot.
+type StateObjectKind string
+
+const (
+	ConfigMapStateObject StateObjectKind = "configmap"
+	SecretStateObject    StateObjectKind = "secret"
+)
+
+// kubeStateStore stores the snapshot in a ConfigMap or a Secret.
+//
+// Note the objects are limited to 1MiB in size, which limits
+// the number of groups that can be persisted.
+type kubeStateStore struct {
+	client    kubernetes.Interface
+	kind      StateObjectKind
+	namespace string
+	name      string
+}
+
+// NewKubeStateStore creates a StateStore persisting the snapshot to the ConfigMap
+// or Secret (based on the kind) with the given namespace and name.
+// The object is created if it doesn't exist.
+func NewKubeStateStore(client kubernetes.Interface, kind StateObjectKind, namespace, name string) (StateStore, error) {
+	if kind != ConfigMapStateObject && kind != SecretStateObject {
+		return nil, fmt.Errorf("unsupported state object kind %q", kind)
+	}
+	return &kubeStateStore{
+		client:    client,
+		kind:      kind,
+		namespace: namespace,
+		name:      name,
+	}, nil
+}
+
+func (s *kubeStateStore) Load(ctx context.Context) (*GroupsSnapshot, error) {
+	var data []byte
+	switch s.kind {
+	case ConfigMapStateObject:
+		cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = []byte(cm.Data[stateDataKey])
+	case SecretStateObject:
+		secret, err := s.client.CoreV1().Secrets(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = secret.Data[stateDataKey]
+	}
+
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return unmarshalSnapshot(data)
+}
+
+func (s *kubeStateStore) Save(ctx context.Context, snapshot *GroupsSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	switch s.kind {
+	case ConfigMapStateObject:
+		return s.saveConfigMap(ctx, data)
+	case SecretStateObject:
+		return s.saveSecret(ctx, data)
+	}
+	return nil
+}
+
+func (s *kubeStateStore) saveConfigMap(ctx context.Context, data []byte) error {
+	client := s.client.CoreV1().ConfigMaps(s.namespace)
+	cm, err := client.Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+			Data:       map[string]string{stateDataKey: string(data)},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string, 1)
+	}
+	cm.Data[stateDataKey] = string(data)
+	_, err = client.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+func (s *kubeStateStore) saveSecret(ctx context.Context, data []byte) error {
+	client := s.client.CoreV1().Secrets(s.namespace)
+	secret, err := client.Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+			Data:       map[string][]byte{stateDataKey: data},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte, 1)
+	}
+	secret.Data[stateDataKey] = data
+	_, err = client.Update(ctx, secret, metav1.UpdateOptions{})
+	return err
+}
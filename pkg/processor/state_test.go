@@ -0,0 +1,176 @@
+package processor
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/common"
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+	"github.com/openshift/cluster-health-analyzer/pkg/test/mocks"
+)
+
+func testGroupsCollection() *GroupsCollection {
+	gc := &GroupsCollection{}
+	gc.AddGroup(&GroupMatcher{
+		GroupID:     "group-1",
+		RootGroupID: "group-1",
+		Start:       model.TimeFromUnix(1000),
+		Modified:    model.TimeFromUnix(2000),
+		End:         model.TimeFromUnix(2000),
+		Distance:    0,
+		Matchers: []common.LabelsSubsetMatcher{
+			{Labels: model.LabelSet{"alertname": "KubePodCrashLooping", "namespace": "foo"}},
+		},
+	})
+	gc.AddGroup(&GroupMatcher{
+		GroupID:     "group-2",
+		RootGroupID: "group-1",
+		Start:       model.TimeFromUnix(1000),
+		Modified:    model.TimeFromUnix(2000),
+		End:         model.TimeFromUnix(2000),
+		Distance:    math.Inf(1),
+		Matchers:    []common.LabelsSubsetMatcher{},
+	})
+	return gc
+}
+
+func TestSnapshot_Roundtrip(t *testing.T) {
+	gc := testGroupsCollection()
+	snapshot := gc.Snapshot(time.Unix(3000, 0))
+
+	assert.Nil(t, snapshot.Groups[1].Distance)
+
+	restored := NewGroupsCollectionFromSnapshot(snapshot)
+	assert.Equal(t, gc.Groups, restored.Groups)
+}
+
+func TestStateStores(t *testing.T) {
+	stores := map[string]func() StateStore{
+		"file": func() StateStore {
+			return NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+		},
+		"configmap": func() StateStore {
+			store, err := NewKubeStateStore(fake.NewClientset(), ConfigMapStateObject, "ns", "state")
+			require.NoError(t, err)
+			return store
+		},
+		"secret": func() StateStore {
+			store, err := NewKubeStateStore(fake.NewClientset(), SecretStateObject, "ns", "state")
+			require.NoError(t, err)
+			return store
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore()
+
+			snapshot, err := store.Load(ctx)
+			require.NoError(t, err)
+			assert.Nil(t, snapshot)
+
+			gc := testGroupsCollection()
+			require.NoError(t, store.Save(ctx, gc.Snapshot(time.Unix(3000, 0))))
+
+			// Saving again updates the existing state.
+			gc.Groups = gc.Groups[:1]
+			require.NoError(t, store.Save(ctx, gc.Snapshot(time.Unix(4000, 0))))
+
+			snapshot, err = store.Load(ctx)
+			require.NoError(t, err)
+			require.NotNil(t, snapshot)
+			assert.True(t, snapshot.Timestamp.Equal(time.Unix(4000, 0)))
+			assert.Equal(t, gc.Groups, NewGroupsCollectionFromSnapshot(snapshot).Groups)
+		})
+	}
+}
+
+func TestKubeStateStore_ConfigMap(t *testing.T) {
+	client := fake.NewClientset()
+	store, err := NewKubeStateStore(client, ConfigMapStateObject, "ns", "state")
+	require.NoError(t, err)
+
+	require.NoError(t, store.Save(context.Background(), testGroupsCollection().Snapshot(time.Unix(3000, 0))))
+
+	cm, err := client.CoreV1().ConfigMaps("ns").Get(context.Background(), "state", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, cm.Data[stateDataKey], `"group_id":"group-1"`)
+
+	_, err = NewKubeStateStore(client, "pod", "ns", "state")
+	assert.ErrorContains(t, err, `unsupported state object kind "pod"`)
+}
+
+func TestInitGroupsCollection_FromSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	store := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	snapshotTime := time.Unix(3000, 0).UTC()
+	require.NoError(t, store.Save(ctx, testGroupsCollection().Snapshot(snapshotTime)))
+
+	start := time.Unix(0, 0)
+	end := time.Unix(3600, 0)
+	step := time.Minute
+
+	// Only the gap since the snapshot is replayed and group-ids are not
+	// recovered from the health map.
+	loader := mocks.NewMockPrometheusLoader(ctrl)
+	loader.EXPECT().LoadAlertsRange(ctx, snapshotTime, end, step).Return(prom.RangeVector{
+		{
+			Metric: model.LabelSet{"alertname": "KubePodCrashLooping", "namespace": "foo", "pod": "bar"},
+			Samples: []model.SamplePair{
+				{Timestamp: model.TimeFromUnix(3060)},
+				{Timestamp: model.TimeFromUnix(3120)},
+			},
+			Step: step,
+		},
+	}, nil)
+	loader.EXPECT().LoadVectorRange(ctx, clusterOperatorConditionsQuery, snapshotTime, end, step).
+		Return(prom.RangeVector{}, nil)
+
+	p := &processor{loader: loader, stateStore: store}
+	require.NoError(t, p.InitGroupsCollection(ctx, start, end, step))
+
+	// The replayed alert is matched to the restored group.
+	require.Len(t, p.groupsCollection.cluster("").Groups, 2)
+	assert.Equal(t, model.TimeFromUnix(3120), p.groupsCollection.cluster("").Groups[0].End)
+
+	require.NoError(t, p.SaveState(ctx))
+	snapshot, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.True(t, snapshot.Timestamp.Equal(end))
+}
+
+func TestInitGroupsCollection_StaleSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	store := NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, store.Save(ctx, testGroupsCollection().Snapshot(time.Unix(3000, 0))))
+
+	// The snapshot is older than the start: the full history is replayed.
+	start := time.Unix(4000, 0)
+	end := time.Unix(5000, 0)
+	step := time.Minute
+
+	loader := mocks.NewMockPrometheusLoader(ctrl)
+	loader.EXPECT().LoadAlertsRange(ctx, start, end, step).Return(prom.RangeVector{}, nil)
+	loader.EXPECT().LoadVectorRange(ctx, clusterOperatorConditionsQuery, start, end, step).
+		Return(prom.RangeVector{}, nil)
+	loader.EXPECT().LoadVectorRange(ctx, ClusterHealthComponentsMap, start, end, step).Return(prom.RangeVector{}, nil)
+
+	p := &processor{loader: loader, stateStore: store}
+	require.NoError(t, p.InitGroupsCollection(ctx, start, end, step))
+	assert.Empty(t, p.groupsCollection.cluster("").Groups)
+}
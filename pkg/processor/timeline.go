@@ -0,0 +1,360 @@
+package processor
+
+// This file contains logic for recording the timelines of the incidents,
+// i.e. how the incidents evolved over time.
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/common"
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+const (
+	IncidentTimelineEventsMetric     = "cluster_health_incident_timeline_events"
+	IncidentLastSeverityChangeMetric = "cluster_health_incident_last_severity_change"
+)
+
+// TimelineEventType is the type of the change of the incident.
+type TimelineEventType string
+
+const (
+	// SeverityChanged is recorded when the max severity of the firing
+	// signals of the incident changes, in both directions.
+	SeverityChanged TimelineEventType = "severity_changed"
+	// AlertAdded is recorded when a signal starts firing within the incident.
+	AlertAdded TimelineEventType = "alert_added"
+	// AlertRemoved is recorded when a signal of the incident stops firing.
+	AlertRemoved TimelineEventType = "alert_removed"
+	// ComponentAdded is recorded when the incident affects a new component.
+	ComponentAdded TimelineEventType = "component_added"
+)
+
+// TimelineEvent is a change of the incident.
+type TimelineEvent struct {
+	Time time.Time         `json:"time"`
+	Type TimelineEventType `json:"type"`
+	// Severity is the new severity of the incident for the severity changes,
+	// or the severity of the added or removed signal.
+	Severity string `json:"severity,omitempty"`
+	// PreviousSeverity is set only for the severity changes.
+	PreviousSeverity string `json:"previous_severity,omitempty"`
+	// SrcType and SrcLabels identify the added or removed signal.
+	SrcType   SrcType        `json:"src_type,omitempty"`
+	SrcLabels model.LabelSet `json:"src_labels,omitempty"`
+	Layer     string         `json:"layer,omitempty"`
+	Component string         `json:"component,omitempty"`
+}
+
+// timelineSignal is a firing signal of the incident.
+type timelineSignal struct {
+	SrcType   SrcType        `json:"src_type"`
+	SrcLabels model.LabelSet `json:"src_labels"`
+	Layer     string         `json:"layer"`
+	Component string         `json:"component"`
+	Health    HealthValue    `json:"health"`
+	// Start is the time the signal started firing within the incident.
+	Start time.Time `json:"start"`
+}
+
+// incidentTimeline records the changes of the incident together with its
+// current state the next changes are detected against. The events themselves
+// are not kept, so that the size of the saved state doesn't grow with them,
+// only their counts and the last severity change.
+type incidentTimeline struct {
+	// Initialized is set by the first update, whose added signals give
+	// the initial severity of the incident.
+	Initialized bool `json:"initialized"`
+	// Counts are the numbers of all the recorded events by their type.
+	Counts map[TimelineEventType]int `json:"counts,omitempty"`
+	// LastSeverityChange is the latest of the severity changes.
+	LastSeverityChange *TimelineEvent `json:"last_severity_change,omitempty"`
+	// Severity is the max severity of the signals of the incident. It's kept
+	// when the incident stops firing, to detect the change when it fires again.
+	Severity HealthValue `json:"severity"`
+	// Signals are the firing signals by their key.
+	Signals map[string]timelineSignal `json:"signals"`
+	// Components are the components ever affected by the incident.
+	Components []string `json:"components"`
+	// Cluster and ClusterID identify the cluster of the incident,
+	// only in the multi-cluster setup.
+	Cluster   string `json:"cluster,omitempty"`
+	ClusterID string `json:"cluster_id,omitempty"`
+}
+
+func newIncidentTimeline() *incidentTimeline {
+	return &incidentTimeline{Signals: make(map[string]timelineSignal)}
+}
+
+// clone returns a copy of the timeline to be used outside the lock,
+// e.g. when saving the state.
+func (tl *incidentTimeline) clone() *incidentTimeline {
+	ret := *tl
+	ret.Counts = maps.Clone(tl.Counts)
+	if tl.LastSeverityChange != nil {
+		e := *tl.LastSeverityChange
+		ret.LastSeverityChange = &e
+	}
+	ret.Signals = maps.Clone(tl.Signals)
+	ret.Components = slices.Clone(tl.Components)
+	return &ret
+}
+
+// cloneTimelines returns a copy of the timelines.
+func cloneTimelines(timelines map[string]*incidentTimeline) map[string]*incidentTimeline {
+	if len(timelines) == 0 {
+		return nil
+	}
+	ret := make(map[string]*incidentTimeline, len(timelines))
+	for id, tl := range timelines {
+		ret[id] = tl.clone()
+	}
+	return ret
+}
+
+func signalKey(hm ComponentHealthMap) string {
+	return string(hm.SrcType) + hm.SrcLabels.String()
+}
+
+func componentName(layer, component string) string {
+	return layer + "/" + component
+}
+
+// update records the changes between the firing signals of the incident
+// and the signals from the previous update and returns them.
+func (tl *incidentTimeline) update(healthMaps []ComponentHealthMap, t time.Time) []TimelineEvent {
+	current := make(map[string]timelineSignal, len(healthMaps))
+	for _, hm := range healthMaps {
+		k := signalKey(hm)
+		s, ok := current[k]
+		if ok && s.Health >= hm.Health {
+			continue
+		}
+		start := t
+		if prev, ok := tl.Signals[k]; ok && !prev.Start.IsZero() {
+			start = prev.Start
+		}
+		current[k] = timelineSignal{
+			SrcType:   hm.SrcType,
+			SrcLabels: hm.SrcLabels,
+			Layer:     hm.Layer,
+			Component: hm.Component,
+			Health:    hm.Health,
+			Start:     start,
+		}
+	}
+
+	var events []TimelineEvent
+	for _, k := range slices.Sorted(maps.Keys(current)) {
+		s := current[k]
+		if _, ok := tl.Signals[k]; ok {
+			continue
+		}
+		if name := componentName(s.Layer, s.Component); !slices.Contains(tl.Components, name) {
+			tl.Components = append(tl.Components, name)
+			events = append(events, TimelineEvent{
+				Time:      t,
+				Type:      ComponentAdded,
+				Layer:     s.Layer,
+				Component: s.Component,
+			})
+		}
+		events = append(events, s.event(t, AlertAdded))
+	}
+	for _, k := range slices.Sorted(maps.Keys(tl.Signals)) {
+		if _, ok := current[k]; !ok {
+			events = append(events, tl.Signals[k].event(t, AlertRemoved))
+		}
+	}
+
+	// The severity of the incident is not changed when it stops firing.
+	if len(current) > 0 {
+		severity := Healthy
+		for _, s := range current {
+			severity = max(severity, s.Health)
+		}
+		// The initial severity is given by the added signals.
+		if tl.Initialized && severity != tl.Severity {
+			events = append(events, TimelineEvent{
+				Time:             t,
+				Type:             SeverityChanged,
+				Severity:         severity.String(),
+				PreviousSeverity: tl.Severity.String(),
+			})
+		}
+		tl.Severity = severity
+	}
+
+	if tl.Counts == nil {
+		tl.Counts = make(map[TimelineEventType]int)
+	}
+	tl.count(events)
+
+	tl.Initialized = true
+	tl.Signals = current
+	return events
+}
+
+// count updates the counts of the events and the last severity change.
+func (tl *incidentTimeline) count(events []TimelineEvent) {
+	for _, e := range events {
+		tl.Counts[e.Type]++
+		if e.Type == SeverityChanged {
+			tl.LastSeverityChange = &e
+		}
+	}
+}
+
+func (s timelineSignal) event(t time.Time, eventType TimelineEventType) TimelineEvent {
+	return TimelineEvent{
+		Time:      t,
+		Type:      eventType,
+		Severity:  s.Health.String(),
+		SrcType:   s.SrcType,
+		SrcLabels: s.SrcLabels,
+		Layer:     s.Layer,
+		Component: s.Component,
+	}
+}
+
+// UpdateTimelines records the changes of the incidents of the collection
+// given the current health maps. The timelines of the groups no longer
+// in the collection are dropped.
+func (gc *GroupsCollection) UpdateTimelines(healthMaps []ComponentHealthMap, t time.Time) {
+	byGroup := make(map[string][]ComponentHealthMap)
+	for _, hm := range healthMaps {
+		if hm.GroupId != "" {
+			byGroup[hm.GroupId] = append(byGroup[hm.GroupId], hm)
+		}
+	}
+
+	if gc.Timelines == nil {
+		gc.Timelines = make(map[string]*incidentTimeline)
+	}
+	rootGroupIDs := make(map[string]struct{}, len(gc.Groups))
+	for _, g := range gc.Groups {
+		rootGroupIDs[g.RootGroupID] = struct{}{}
+	}
+	for id := range gc.Timelines {
+		if _, ok := rootGroupIDs[id]; !ok {
+			delete(gc.Timelines, id)
+		}
+	}
+
+	for id := range rootGroupIDs {
+		tl, ok := gc.Timelines[id]
+		if !ok {
+			if len(byGroup[id]) == 0 {
+				continue
+			}
+			tl = newIncidentTimeline()
+			tl.Cluster, tl.ClusterID = byGroup[id][0].Cluster, byGroup[id][0].ClusterID
+			gc.Timelines[id] = tl
+		}
+		tl.update(byGroup[id], t)
+	}
+}
+
+// UpdateTimelines records the changes of the incidents of all the clusters.
+func (c *ClusterGroupsCollection) UpdateTimelines(healthMaps []ComponentHealthMap, t time.Time) {
+	byCluster := make(map[string][]ComponentHealthMap)
+	for _, hm := range healthMaps {
+		id := ""
+		if c.ClusterLabel != "" {
+			id = hm.ClusterID
+		}
+		byCluster[id] = append(byCluster[id], hm)
+	}
+	for id, gc := range c.Clusters {
+		gc.UpdateTimelines(byCluster[id], t)
+	}
+}
+
+// timelineMetrics returns the metrics of the timelines of all the clusters
+// to be exported: the number of the events by their type and the last
+// severity change of each incident, with its time as the value. The events
+// themselves are not exported, the full timelines are reconstructed from
+// the health map history by TimelinesFromHealthMap.
+func (c *ClusterGroupsCollection) timelineMetrics() (events, severityChanges []prom.Metric) {
+	for _, gc := range c.Clusters {
+		for id, tl := range gc.Timelines {
+			incidentLabels := func() model.LabelSet {
+				labels := model.LabelSet{"group_id": model.LabelValue(id)}
+				setClusterLabels(labels, tl.Cluster, tl.ClusterID)
+				return labels
+			}
+			for eventType, count := range tl.Counts {
+				labels := incidentLabels()
+				labels["type"] = model.LabelValue(eventType)
+				events = append(events, prom.Metric{Labels: labels, Value: float64(count)})
+			}
+			if e := tl.LastSeverityChange; e != nil {
+				labels := incidentLabels()
+				labels["severity"] = model.LabelValue(e.Severity)
+				labels["previous_severity"] = model.LabelValue(e.PreviousSeverity)
+				severityChanges = append(severityChanges, prom.Metric{Labels: labels, Value: float64(e.Time.Unix())})
+			}
+		}
+	}
+
+	byLabels := func(a, b prom.Metric) int {
+		return strings.Compare(a.Labels.String(), b.Labels.String())
+	}
+	slices.SortFunc(events, byLabels)
+	slices.SortFunc(severityChanges, byLabels)
+	return events, severityChanges
+}
+
+// TimelinesFromHealthMap reconstructs the timelines of the incidents by their
+// group_id from the history of the health map series, the way the processor
+// records them. The changes are detected between the samples of the series,
+// i.e. with the precision of the step of the range query.
+func TimelinesFromHealthMap(rv prom.RangeVector) map[string][]TimelineEvent {
+	byTime := make(map[model.Time][]ComponentHealthMap)
+	for _, r := range rv {
+		hm := healthMapFromLabels(r.Metric)
+		if hm.GroupId == "" {
+			continue
+		}
+		for _, s := range r.Samples {
+			hm.Health = HealthValue(s.Value)
+			byTime[s.Timestamp] = append(byTime[s.Timestamp], hm)
+		}
+	}
+
+	timelines := make(map[string]*incidentTimeline)
+	ret := make(map[string][]TimelineEvent)
+	for _, t := range slices.Sorted(maps.Keys(byTime)) {
+		byGroup := make(map[string][]ComponentHealthMap)
+		for _, hm := range byTime[t] {
+			byGroup[hm.GroupId] = append(byGroup[hm.GroupId], hm)
+			if _, ok := timelines[hm.GroupId]; !ok {
+				timelines[hm.GroupId] = newIncidentTimeline()
+			}
+		}
+		// the groups without the samples record the removed signals
+		for id, tl := range timelines {
+			ret[id] = append(ret[id], tl.update(byGroup[id], t.Time())...)
+		}
+	}
+	return ret
+}
+
+// healthMapFromLabels parses the exported labels of the health map.
+func healthMapFromLabels(labels model.LabelSet) ComponentHealthMap {
+	return ComponentHealthMap{
+		Layer:     string(labels["layer"]),
+		Component: string(labels["component"]),
+		SrcType:   SrcType(labels["type"]),
+		SrcLabels: common.SrcLabels(model.Metric(labels)),
+		GroupId:   string(labels["group_id"]),
+		Silenced:  string(labels["silenced"]),
+		Cluster:   string(labels[ClusterLabel]),
+		ClusterID: string(labels[ClusterIDLabel]),
+	}
+}
@@ -0,0 +1,227 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+func TestIncidentTimeline_Update(t *testing.T) {
+	tl := newIncidentTimeline()
+
+	operatorDown := func(severity HealthValue) ComponentHealthMap {
+		return ComponentHealthMap{
+			GroupId: "g1", Layer: "core", Component: "network", SrcType: Alert, Health: severity,
+			SrcLabels: model.LabelSet{"alertname": "ClusterOperatorDown", "name": "network",
+				"severity": model.LabelValue(severity.String())},
+		}
+	}
+	podNotReady := ComponentHealthMap{
+		GroupId: "g1", Layer: "core", Component: "dns", SrcType: Alert, Health: Warning,
+		SrcLabels: model.LabelSet{"alertname": "KubePodNotReady", "namespace": "openshift-dns", "severity": "warning"},
+	}
+
+	t0 := time.Unix(1000, 0)
+	t1 := t0.Add(time.Minute)
+	t2 := t1.Add(time.Minute)
+	t3 := t2.Add(time.Minute)
+
+	var events []TimelineEvent
+	events = append(events, tl.update([]ComponentHealthMap{operatorDown(Warning)}, t0)...)
+	// the repeated signal is not recorded again
+	events = append(events, tl.update([]ComponentHealthMap{operatorDown(Warning)}, t0.Add(30*time.Second))...)
+	// the operator goes down
+	events = append(events, tl.update([]ComponentHealthMap{operatorDown(Critical), podNotReady}, t1)...)
+	events = append(events, tl.update([]ComponentHealthMap{podNotReady}, t2)...)
+	events = append(events, tl.update(nil, t3)...)
+
+	assert.Equal(t, []TimelineEvent{
+		{Time: t0, Type: ComponentAdded, Layer: "core", Component: "network"},
+		{Time: t0, Type: AlertAdded, Severity: "warning", SrcType: Alert, SrcLabels: operatorDown(Warning).SrcLabels,
+			Layer: "core", Component: "network"},
+
+		{Time: t1, Type: AlertAdded, Severity: "critical", SrcType: Alert, SrcLabels: operatorDown(Critical).SrcLabels,
+			Layer: "core", Component: "network"},
+		{Time: t1, Type: ComponentAdded, Layer: "core", Component: "dns"},
+		{Time: t1, Type: AlertAdded, Severity: "warning", SrcType: Alert, SrcLabels: podNotReady.SrcLabels,
+			Layer: "core", Component: "dns"},
+		{Time: t1, Type: AlertRemoved, Severity: "warning", SrcType: Alert, SrcLabels: operatorDown(Warning).SrcLabels,
+			Layer: "core", Component: "network"},
+		{Time: t1, Type: SeverityChanged, Severity: "critical", PreviousSeverity: "warning"},
+
+		{Time: t2, Type: AlertRemoved, Severity: "critical", SrcType: Alert, SrcLabels: operatorDown(Critical).SrcLabels,
+			Layer: "core", Component: "network"},
+		{Time: t2, Type: SeverityChanged, Severity: "warning", PreviousSeverity: "critical"},
+
+		// the severity is kept when the incident stops firing
+		{Time: t3, Type: AlertRemoved, Severity: "warning", SrcType: Alert, SrcLabels: podNotReady.SrcLabels,
+			Layer: "core", Component: "dns"},
+	}, events)
+	assert.Equal(t, map[TimelineEventType]int{
+		ComponentAdded: 2, AlertAdded: 3, AlertRemoved: 3, SeverityChanged: 2,
+	}, tl.Counts)
+	assert.Equal(t, &events[len(events)-2], tl.LastSeverityChange)
+	assert.Equal(t, Warning, tl.Severity)
+	assert.Empty(t, tl.Signals)
+}
+
+func TestGroupsCollection_UpdateTimelines(t *testing.T) {
+	gc := &GroupsCollection{}
+	gc.AddGroup(&GroupMatcher{GroupID: "g1", RootGroupID: "g1"})
+	hm := ComponentHealthMap{GroupId: "g1", Layer: "core", Component: "etcd", SrcType: Alert, Health: Warning,
+		SrcLabels: model.LabelSet{"alertname": "etcdNoLeader"}}
+
+	// the timelines are created for the groups with the signals
+	gc.UpdateTimelines(nil, time.Unix(1000, 0))
+	assert.Empty(t, gc.Timelines)
+	gc.UpdateTimelines([]ComponentHealthMap{hm}, time.Unix(1060, 0))
+	require.Contains(t, gc.Timelines, "g1")
+	assert.Equal(t, 1, gc.Timelines["g1"].Counts[AlertAdded])
+
+	// the timelines of the pruned groups are dropped
+	gc.Groups = nil
+	gc.UpdateTimelines(nil, time.Unix(1120, 0))
+	assert.Empty(t, gc.Timelines)
+}
+
+func TestClusterGroupsCollection_SnapshotTimelinesSize(t *testing.T) {
+	snapshotSize := func(updates int) int {
+		c := NewClusterGroupsCollection("", nil)
+		c.cluster("").AddGroup(&GroupMatcher{GroupID: "g1", RootGroupID: "g1"})
+		now := time.Unix(1000, 0)
+		for i := range updates {
+			// the flapping alerts, each update records the added and removed alert
+			hm := ComponentHealthMap{GroupId: "g1", Layer: "core", Component: "etcd", SrcType: Alert, Health: Warning,
+				SrcLabels: model.LabelSet{"alertname": model.LabelValue(fmt.Sprintf("alert-%d", i%2))}}
+			c.UpdateTimelines([]ComponentHealthMap{hm}, now.Add(time.Duration(i)*time.Minute))
+		}
+		data, err := json.Marshal(c.Snapshot(now))
+		require.NoError(t, err)
+		return len(data)
+	}
+
+	// only the digits of the counts grow
+	small, large := snapshotSize(10), snapshotSize(1000)
+	assert.Less(t, large-small, 16)
+}
+
+func TestClusterGroupsCollection_TimelineMetrics(t *testing.T) {
+	c := NewClusterGroupsCollection("clusterID", nil)
+	c.cluster("c1").AddGroup(&GroupMatcher{GroupID: "g1", RootGroupID: "g1"})
+
+	hm := ComponentHealthMap{GroupId: "g1", Layer: "core", Component: "etcd", SrcType: Alert, Health: Warning,
+		Cluster: "prod", ClusterID: "c1",
+		SrcLabels: model.LabelSet{"alertname": "etcdNoLeader", "severity": "warning"}}
+	critical := hm
+	critical.Health = Critical
+	critical.SrcLabels = model.LabelSet{"alertname": "etcdMembersDown", "severity": "critical"}
+	t0 := time.Unix(1000, 0)
+	c.UpdateTimelines([]ComponentHealthMap{hm}, t0)
+	c.UpdateTimelines(nil, t0.Add(time.Minute))
+	// the alert fires again, together with a critical one
+	c.UpdateTimelines([]ComponentHealthMap{hm, critical}, t0.Add(2*time.Minute))
+
+	events, severityChanges := c.timelineMetrics()
+	counts := make(map[model.LabelValue]float64)
+	for _, m := range events {
+		assert.Equal(t, model.LabelSet{
+			"group_id": "g1", "type": m.Labels["type"], ClusterLabel: "prod", ClusterIDLabel: "c1",
+		}, m.Labels)
+		counts[m.Labels["type"]] = m.Value
+	}
+	// a single series per the event type
+	assert.Equal(t, map[model.LabelValue]float64{
+		"component_added":  1,
+		"alert_added":      3,
+		"alert_removed":    1,
+		"severity_changed": 1,
+	}, counts)
+
+	assert.Equal(t, []prom.Metric{{
+		Labels: model.LabelSet{
+			"group_id": "g1", "severity": "critical", "previous_severity": "warning",
+			ClusterLabel: "prod", ClusterIDLabel: "c1",
+		},
+		Value: 1120,
+	}}, severityChanges)
+}
+
+func TestTimelinesFromHealthMap(t *testing.T) {
+	t0 := model.TimeFromUnix(1000)
+	step := 5 * time.Minute
+	samples := func(value HealthValue, steps ...int) []model.SamplePair {
+		var ret []model.SamplePair
+		for _, i := range steps {
+			ret = append(ret, model.SamplePair{Timestamp: t0.Add(time.Duration(i) * step), Value: model.SampleValue(value)})
+		}
+		return ret
+	}
+	operatorDown := ComponentHealthMap{
+		GroupId: "g1", Layer: "core", Component: "network", SrcType: Alert,
+		SrcLabels: model.LabelSet{"alertname": "ClusterOperatorDown", "name": "network", "severity": "critical"},
+	}
+	podNotReady := ComponentHealthMap{
+		GroupId: "g1", Layer: "core", Component: "dns", SrcType: Alert,
+		SrcLabels: model.LabelSet{"alertname": "KubePodNotReady", "namespace": "openshift-dns", "severity": "warning"},
+	}
+	rv := prom.RangeVector{
+		{Metric: podNotReady.Labels(), Samples: samples(Warning, 0, 1, 2, 3)},
+		{Metric: operatorDown.Labels(), Samples: samples(Critical, 1, 2)},
+		// the signals not assigned to any incident are skipped
+		{Metric: ComponentHealthMap{Layer: "core", Component: "etcd", SrcType: Alert,
+			SrcLabels: model.LabelSet{"alertname": "etcdNoLeader"}}.Labels(), Samples: samples(Critical, 0)},
+	}
+
+	at := func(i int) time.Time {
+		return t0.Add(time.Duration(i) * step).Time()
+	}
+	timelines := TimelinesFromHealthMap(rv)
+	require.Len(t, timelines, 1)
+	assert.Equal(t, []TimelineEvent{
+		{Time: at(0), Type: ComponentAdded, Layer: "core", Component: "dns"},
+		{Time: at(0), Type: AlertAdded, Severity: "warning", SrcType: Alert, SrcLabels: podNotReady.SrcLabels,
+			Layer: "core", Component: "dns"},
+		{Time: at(1), Type: ComponentAdded, Layer: "core", Component: "network"},
+		{Time: at(1), Type: AlertAdded, Severity: "critical", SrcType: Alert, SrcLabels: operatorDown.SrcLabels,
+			Layer: "core", Component: "network"},
+		{Time: at(1), Type: SeverityChanged, Severity: "critical", PreviousSeverity: "warning"},
+		{Time: at(3), Type: AlertRemoved, Severity: "critical", SrcType: Alert, SrcLabels: operatorDown.SrcLabels,
+			Layer: "core", Component: "network"},
+		{Time: at(3), Type: SeverityChanged, Severity: "warning", PreviousSeverity: "critical"},
+	}, timelines["g1"])
+}
+
+func TestClusterGroupsCollection_SnapshotTimelines(t *testing.T) {
+	c := NewClusterGroupsCollection("clusterID", nil)
+	c.cluster("c1").AddGroup(&GroupMatcher{GroupID: "g1", RootGroupID: "g1"})
+	c.cluster("c2").AddGroup(&GroupMatcher{GroupID: "g2", RootGroupID: "g2"})
+	c.UpdateTimelines([]ComponentHealthMap{
+		{GroupId: "g1", ClusterID: "c1", Layer: "core", Component: "etcd", SrcType: Alert, Health: Warning,
+			SrcLabels: model.LabelSet{"alertname": "etcdNoLeader"}},
+		{GroupId: "g2", ClusterID: "c2", Layer: "core", Component: "dns", SrcType: Alert, Health: Critical,
+			SrcLabels: model.LabelSet{"alertname": "CoreDNSDown"}},
+	}, time.Unix(1000, 0).UTC())
+
+	taken := c.Snapshot(time.Unix(2000, 0))
+	data, err := json.Marshal(taken)
+	require.NoError(t, err)
+	snapshot, err := unmarshalSnapshot(data)
+	require.NoError(t, err)
+	assert.Len(t, snapshot.Timelines, 2)
+
+	restored := NewClusterGroupsCollectionFromSnapshot(snapshot, "clusterID", nil)
+	assert.Equal(t, c.Clusters["c1"].Timelines, restored.Clusters["c1"].Timelines)
+	assert.Equal(t, c.Clusters["c2"].Timelines, restored.Clusters["c2"].Timelines)
+
+	// the snapshot doesn't share the timelines with the collection
+	c.UpdateTimelines(nil, time.Unix(1060, 0))
+	assert.Equal(t, 0, taken.Timelines["g1"].Counts[AlertRemoved])
+	assert.Equal(t, 1, c.Clusters["c1"].Timelines["g1"].Counts[AlertRemoved])
+}
@@ -17,11 +17,13 @@ import (
 type ComponentHealthMap struct {
 	Layer     string         // Layer of the component
 	Component string         // Component name
-	SrcType   SrcType        // Type of the source (alert, cluster_operator_condition)
+	SrcType   SrcType        // Type of the source (alert, cluster_operator_condition, event)
 	SrcLabels model.LabelSet // Identifying labels of the source
 	GroupId   string         // Group ID of the component
 	Health    HealthValue    // Health value of the component
 	Silenced  string         // Whether the alert is silenced or not
+	Cluster   string         // Name of the cluster of the source, in the multi-cluster setup
+	ClusterID string         // ID of the cluster of the source, in the multi-cluster setup
 }
 
 // SrcType represents the type of the source.
@@ -30,6 +32,7 @@ type SrcType string
 const (
 	Alert                    SrcType = "alert"
 	ClusterOperatorCondition SrcType = "cluster_operator_condition"
+	Event                    SrcType = "event"
 )
 
 // HealthValue represents the health value of the component.
@@ -99,6 +102,8 @@ func (c ComponentHealthMap) Labels() model.LabelSet {
 		"silenced":  model.LabelValue(c.Silenced),
 	}
 
+	setClusterLabels(metaLabels, c.Cluster, c.ClusterID)
+
 	labels := make(model.LabelSet, len(c.SrcLabels)+len(metaLabels))
 	for k, v := range metaLabels {
 		labels[k] = v
@@ -120,22 +125,6 @@ type componentMatcher struct {
 	matchers  []common.LabelsMatcher
 }
 
-// findComponent tries to dtermine a component for given labels using the provided matchers.
-//
-// It returns the component and the keys that matched.
-// If no match is found, it returns an empty component and nil keys.
-func findComponent(compMatchers []componentMatcher, labels model.LabelSet) (
-	component string, keys []model.LabelName) {
-	for _, compMatcher := range compMatchers {
-		for _, labelsMatcher := range compMatcher.matchers {
-			if matches, keys := labelsMatcher.Matches(labels); matches {
-				return compMatcher.component, keys
-			}
-		}
-	}
-	return "", nil
-}
-
 // componentMatcherFn is a function that tries matching provided labels to a component.
 // It returns the layer, component and the keys from the labels that were used for matching.
 // If no match is found, it returns an empty layer, component and nil keys.
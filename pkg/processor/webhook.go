@@ -0,0 +1,133 @@
+package processor
+
+// This file contains logic for ingesting the alerts pushed by the Alertmanager webhook.
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+const (
+	webhookStatusFiring   = "firing"
+	webhookStatusResolved = "resolved"
+)
+
+// WebhookMessage is the payload sent by the Alertmanager webhook receiver.
+// Only the fields needed for the processing are included.
+type WebhookMessage struct {
+	Version  string         `json:"version"`
+	Status   string         `json:"status"`
+	Receiver string         `json:"receiver"`
+	Alerts   []WebhookAlert `json:"alerts"`
+}
+
+// WebhookAlert is a single alert in the WebhookMessage.
+type WebhookAlert struct {
+	Status      string            `json:"status"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+	Fingerprint string            `json:"fingerprint"`
+}
+
+// alertLabels returns the labels of the alert in the same form as returned
+// by the ALERTS metric, so that they match the alerts loaded from Prometheus.
+func (a WebhookAlert) alertLabels() model.LabelSet {
+	labels := make(model.LabelSet, len(a.Labels)+2)
+	for k, v := range a.Labels {
+		labels[model.LabelName(k)] = model.LabelValue(v)
+	}
+	labels[model.MetricNameLabel] = "ALERTS"
+	labels["alertstate"] = "firing"
+	return labels
+}
+
+// webhookBatch holds the alerts of the webhook message changed at the same time.
+type webhookBatch struct {
+	firing   []Interval
+	resolved []model.LabelSet
+}
+
+// ProcessWebhook assigns the alerts from the Alertmanager webhook to the incidents
+// and triggers the refresh of the metrics.
+//
+// The firing alerts are processed from the time they started until the time t.
+// The resolved alerts only extend the groups they directly match until the time
+// they ended, to get precise resolution times of the incidents: the resolved
+// alerts not seen before don't create new incidents. The alerts are processed
+// in the order of the times. The periodic polling of Prometheus still takes
+// place to reconcile missed notifications.
+func (p *processor) ProcessWebhook(msg WebhookMessage, t time.Time) {
+	batches := make(map[time.Time]*webhookBatch)
+	batch := func(bt time.Time) *webhookBatch {
+		b, ok := batches[bt]
+		if !ok {
+			b = &webhookBatch{}
+			batches[bt] = b
+		}
+		return b
+	}
+
+	firingCount, resolvedCount := 0, 0
+	for _, a := range msg.Alerts {
+		switch a.Status {
+		case webhookStatusFiring:
+			start := webhookTime(a.StartsAt, t)
+			b := batch(start)
+			b.firing = append(b.firing, Interval{
+				Metric: a.alertLabels(),
+				Start:  model.TimeFromUnixNano(start.UnixNano()),
+				End:    model.TimeFromUnixNano(t.UnixNano()),
+			})
+			firingCount++
+		case webhookStatusResolved:
+			b := batch(webhookTime(a.EndsAt, t))
+			b.resolved = append(b.resolved, a.alertLabels())
+			resolvedCount++
+		default:
+			slog.Warn("Ignoring webhook alert with unknown status", "status", a.Status)
+		}
+	}
+
+	resolvedMatched := 0
+	p.mtx.Lock()
+	if p.groupsCollection != nil {
+		for _, bt := range slices.SortedFunc(maps.Keys(batches), time.Time.Compare) {
+			b := batches[bt]
+			if len(b.firing) > 0 {
+				p.groupsCollection.ProcessIntervalsBatch(b.firing)
+			}
+			if len(b.resolved) > 0 {
+				resolvedMatched += p.groupsCollection.ResolveAlertsBatch(b.resolved, bt)
+			}
+		}
+	}
+	p.mtx.Unlock()
+
+	slog.Info("Processed webhook alerts", "firing", firingCount, "resolved", resolvedCount,
+		"resolvedMatched", resolvedMatched)
+	p.TriggerRefresh()
+}
+
+// webhookTime returns the time of the alert, or the time t of receiving
+// the alert when missing or in the future.
+func webhookTime(at, t time.Time) time.Time {
+	if at.IsZero() || at.After(t) {
+		return t
+	}
+	return at
+}
+
+// TriggerRefresh requests processing outside the regular interval. It doesn't
+// block: multiple requests before the processing starts are coalesced.
+func (p *processor) TriggerRefresh() {
+	select {
+	case p.refreshCh <- struct{}{}:
+	default:
+	}
+}
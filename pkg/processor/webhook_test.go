@@ -0,0 +1,78 @@
+package processor
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessWebhook(t *testing.T) {
+	var msg WebhookMessage
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "version": "4",
+  "status": "firing",
+  "receiver": "cluster-health-analyzer",
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "KubePodCrashLooping", "namespace": "foo", "pod": "bar", "severity": "warning"},
+      "startsAt": "2025-01-01T10:00:00Z",
+      "endsAt": "0001-01-01T00:00:00Z"
+    },
+    {
+      "status": "resolved",
+      "labels": {"alertname": "KubePodNotReady", "namespace": "foo", "pod": "bar", "severity": "warning"},
+      "startsAt": "2025-01-01T09:00:00Z",
+      "endsAt": "2025-01-01T10:02:00Z"
+    }
+  ]
+}`), &msg))
+
+	now := time.Date(2025, 1, 1, 10, 5, 0, 0, time.UTC)
+	p := &processor{
+		groupsCollection: NewClusterGroupsCollection("", nil),
+		refreshCh:        make(chan struct{}, 1),
+	}
+	p.ProcessWebhook(msg, now)
+
+	// The resolved alert the processor has not seen creates no group.
+	exact := make(map[model.LabelValue]*GroupMatcher)
+	for _, g := range p.groupsCollection.cluster("").Groups {
+		if g.Distance == 0 {
+			exact[g.Matchers[0].Labels["alertname"]] = g
+		}
+	}
+	require.Len(t, exact, 1)
+	crashLooping := exact["KubePodCrashLooping"]
+	require.NotNil(t, crashLooping)
+
+	// The labels match the ones of the ALERTS metric.
+	labels := crashLooping.Matchers[0].Labels
+	assert.Equal(t, model.LabelValue("ALERTS"), labels["__name__"])
+	assert.Equal(t, model.LabelValue("firing"), labels["alertstate"])
+	assert.Equal(t, model.LabelValue("bar"), labels["pod"])
+
+	// The firing alert is processed from the time it started until now.
+	assert.Equal(t, model.TimeFromUnix(now.Add(-5*time.Minute).Unix()), crashLooping.Start)
+	assert.Equal(t, model.TimeFromUnix(now.Unix()), crashLooping.End)
+
+	// Once resolved, the group is extended until the time the alert ended.
+	resolved := WebhookMessage{Alerts: []WebhookAlert{{
+		Status:   "resolved",
+		Labels:   map[string]string{"alertname": "KubePodCrashLooping", "namespace": "foo", "pod": "bar", "severity": "warning"},
+		StartsAt: now.Add(-5 * time.Minute),
+		EndsAt:   now.Add(2 * time.Minute),
+	}}}
+	groups := len(p.groupsCollection.cluster("").Groups)
+	p.ProcessWebhook(resolved, now.Add(5*time.Minute))
+	assert.Equal(t, model.TimeFromUnix(now.Add(2*time.Minute).Unix()), crashLooping.End)
+	assert.Len(t, p.groupsCollection.cluster("").Groups, groups)
+
+	// The refresh is triggered and repeated triggers are coalesced.
+	p.ProcessWebhook(WebhookMessage{}, now)
+	assert.Len(t, p.refreshCh, 1)
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/alertmanager"
+	"github.com/openshift/cluster-health-analyzer/pkg/common"
+	"github.com/openshift/cluster-health-analyzer/pkg/incidents"
+	"github.com/openshift/cluster-health-analyzer/pkg/processor"
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+)
+
+const (
+	apiIncidentsPath  = "/api/v1/incidents"
+	apiComponentsPath = "/api/v1/components"
+)
+
+// componentsProvider provides the current status of the components.
+type componentsProvider interface {
+	Components() []processor.ComponentStatus
+}
+
+// apiHandler serves the incidents REST API.
+type apiHandler struct {
+	incidents  incidents.Service
+	components componentsProvider
+}
+
+// newAPIHandler creates the API handler loading the data with the credentials
+// of the analyzer. The caller is authorized by the server before reaching the handler.
+func newAPIHandler(options common.Options, components componentsProvider) (*apiHandler, error) {
+	promLoader, err := prom.NewLoader(options.PromURL)
+	if err != nil {
+		return nil, err
+	}
+	amLoader, err := alertmanager.NewLoader(alertmanager.LoaderConfig{
+		AlertManagerURL: options.AlertManagerURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &apiHandler{
+		incidents:  incidents.NewService(promLoader, amLoader),
+		components: components,
+	}, nil
+}
+
+// incidentsResponse is the response of the incidents endpoint.
+type incidentsResponse struct {
+	Total     int                  `json:"total"`
+	Incidents []incidents.Incident `json:"items"`
+}
+
+// componentsResponse is the response of the components endpoint.
+type componentsResponse struct {
+	Total      int                         `json:"total"`
+	Components []processor.ComponentStatus `json:"items"`
+}
+
+// registerAPI registers the incidents REST API handlers on the server.
+func registerAPI(server Server, h *apiHandler) {
+	server.Handle(apiIncidentsPath, http.HandlerFunc(h.listIncidents))
+	server.HandlePrefix(apiIncidentsPath+"/", http.HandlerFunc(h.getIncident))
+	server.Handle(apiComponentsPath, http.HandlerFunc(h.listComponents))
+}
+
+// listIncidents returns the incidents matching the query parameters:
+//
+//   - time_range: max age of the incidents in hours (default 360),
+//   - min_severity: info, warning or critical,
+//   - component: one of the affected components,
+//   - status: firing or resolved.
+func (h *apiHandler) listIncidents(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	query, err := parseIncidentsQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	list, err := h.incidents.List(r.Context(), query)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, incidentsResponse{
+		Total:     len(list),
+		Incidents: list,
+	})
+}
+
+// getIncident returns a single incident by its id.
+func (h *apiHandler) getIncident(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, apiIncidentsPath+"/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", incidents.ErrNotFound, id))
+		return
+	}
+
+	inc, err := h.incidents.Get(r.Context(), id)
+	if errors.Is(err, incidents.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, inc)
+}
+
+// listComponents returns the components with their health status,
+// optionally filtered by the layer and healthy query parameters.
+func (h *apiHandler) listComponents(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	query := r.URL.Query()
+	layer := query.Get("layer")
+	var healthy *bool
+	if v := query.Get("healthy"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid healthy value %q", v))
+			return
+		}
+		healthy = &b
+	}
+
+	components := make([]processor.ComponentStatus, 0)
+	for _, c := range h.components.Components() {
+		if layer != "" && c.Layer != layer {
+			continue
+		}
+		if healthy != nil && c.Healthy != *healthy {
+			continue
+		}
+		components = append(components, c)
+	}
+	writeJSON(w, http.StatusOK, componentsResponse{
+		Total:      len(components),
+		Components: components,
+	})
+}
+
+// parseIncidentsQuery parses the incidents query from the query parameters.
+func parseIncidentsQuery(r *http.Request) (incidents.Query, error) {
+	params := r.URL.Query()
+	query := incidents.Query{
+		Component: params.Get("component"),
+		Status:    params.Get("status"),
+	}
+
+	if v := params.Get("time_range"); v != "" {
+		hours, err := strconv.Atoi(v)
+		if err != nil || hours < 1 || time.Duration(hours)*time.Hour > incidents.DefaultTimeRange {
+			return query, fmt.Errorf("invalid time_range %q: expected number of hours between 1 and %d",
+				v, int(incidents.DefaultTimeRange.Hours()))
+		}
+		query.TimeRange = time.Duration(hours) * time.Hour
+	}
+	if v := params.Get("min_severity"); v != "" {
+		switch strings.ToLower(v) {
+		case processor.Healthy.String(), processor.Warning.String(), processor.Critical.String():
+			query.MinSeverity = processor.ParseHealthValue(v)
+		default:
+			return query, fmt.Errorf("invalid min_severity %q: expected info, warning or critical", v)
+		}
+	}
+	switch query.Status {
+	case "", "firing", "resolved":
+	default:
+		return query, fmt.Errorf("invalid status %q: expected firing or resolved", query.Status)
+	}
+	return query, nil
+}
+
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write API response", "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/incidents"
+	"github.com/openshift/cluster-health-analyzer/pkg/processor"
+	"github.com/openshift/cluster-health-analyzer/pkg/prom"
+	"github.com/openshift/cluster-health-analyzer/pkg/test/mocks"
+)
+
+// testMux is a Server recording the handlers on a http.ServeMux.
+type testMux struct {
+	*http.ServeMux
+}
+
+func (m testMux) HandlePrefix(prefix string, handler http.Handler) {
+	m.ServeMux.Handle(prefix, handler)
+}
+
+func (m testMux) Start(_ context.Context) error {
+	return nil
+}
+
+type testComponents []processor.ComponentStatus
+
+func (c testComponents) Components() []processor.ComponentStatus {
+	return c
+}
+
+func newTestAPI(t *testing.T) http.Handler {
+	ctrl := gomock.NewController(t)
+	now := model.Now()
+
+	promLoader := mocks.NewMockPrometheusLoader(ctrl)
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), processor.ClusterHealthComponentsMap,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{
+		{
+			Metric: model.LabelSet{
+				"group_id": "1", "component": "etcd", "type": "alert",
+				"src_alertname": "etcdNoLeader", "src_namespace": "openshift-etcd", "src_severity": "critical",
+			},
+			Samples: []model.SamplePair{{Value: 2, Timestamp: now.Add(-time.Minute)}},
+		},
+		{
+			Metric: model.LabelSet{
+				"group_id": "2", "component": "console", "type": "alert",
+				"src_alertname": "ConsoleDown", "src_namespace": "openshift-console", "src_severity": "warning",
+			},
+			Samples: []model.SamplePair{{Value: 1, Timestamp: now.Add(-2 * time.Hour)}},
+		},
+	}, nil).AnyTimes()
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), `ALERTS{alertstate!="pending"}`,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(prom.RangeVector{}, nil).AnyTimes()
+	promLoader.EXPECT().LoadVectorRange(gomock.Any(), processor.IncidentRootCauseMetric,
+		gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	promLoader.EXPECT().LoadQuery(gomock.Any(), "console_url", gomock.Any()).Return(nil, nil).AnyTimes()
+
+	amLoader := mocks.NewMockAlertManagerLoader(ctrl)
+	amLoader.EXPECT().SilencedAlerts().Return(nil, nil).AnyTimes()
+
+	mux := testMux{http.NewServeMux()}
+	registerAPI(mux, &apiHandler{
+		incidents: incidents.NewService(promLoader, amLoader),
+		components: testComponents{
+			{Layer: "core", Component: "etcd", Rank: 1, Healthy: false, Severity: "critical"},
+			{Layer: "core", Component: "console", Rank: 2, Healthy: true, Severity: "info"},
+		},
+	})
+	return mux
+}
+
+func doRequest(t *testing.T, h http.Handler, method, target string, out any) int {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	if out != nil && rec.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), out))
+	}
+	return rec.Code
+}
+
+func TestAPI_Incidents(t *testing.T) {
+	h := newTestAPI(t)
+
+	var list incidentsResponse
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/incidents", &list))
+	assert.Equal(t, 2, list.Total)
+
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet,
+		"/api/v1/incidents?min_severity=critical&status=firing&component=etcd", &list))
+	require.Equal(t, 1, list.Total)
+	assert.Equal(t, "1", list.Incidents[0].GroupId)
+
+	// The incident without recent samples is resolved.
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/incidents?status=resolved", &list))
+	assert.Equal(t, 1, list.Total)
+
+	var inc incidents.Incident
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/incidents/2", &inc))
+	assert.Equal(t, []string{"console"}, inc.AffectedComponents)
+
+	assert.Equal(t, http.StatusNotFound, doRequest(t, h, http.MethodGet, "/api/v1/incidents/3", nil))
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, h, http.MethodGet, "/api/v1/incidents?min_severity=foo", nil))
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, h, http.MethodGet, "/api/v1/incidents?time_range=1000", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, doRequest(t, h, http.MethodPost, "/api/v1/incidents", nil))
+}
+
+func TestAPI_Components(t *testing.T) {
+	h := newTestAPI(t)
+
+	var resp componentsResponse
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/components", &resp))
+	assert.Equal(t, 2, resp.Total)
+
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/components?healthy=false", &resp))
+	require.Equal(t, 1, resp.Total)
+	assert.Equal(t, "etcd", resp.Components[0].Component)
+
+	assert.Equal(t, http.StatusBadRequest, doRequest(t, h, http.MethodGet, "/api/v1/components?healthy=foo", nil))
+}
@@ -4,14 +4,18 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/stretchr/testify/assert/yaml"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/openshift/cluster-health-analyzer/pkg/common"
 	"github.com/openshift/cluster-health-analyzer/pkg/health"
@@ -27,6 +31,13 @@ const (
 	// default file path for the configuration of components for
 	// health evaluation
 	defaultComponentsConfigPath = "/etc/config/components.yaml"
+
+	// stateSaveTimeout is the maximum time for saving the state on shutdown.
+	stateSaveTimeout = 10 * time.Second
+
+	// discoveryInterval is the interval between loading the nodes and installed
+	// components from the Kubernetes API.
+	discoveryInterval = 5 * time.Minute
 )
 
 var (
@@ -43,17 +54,19 @@ var (
 		"Current counts of group_ids by severity.",
 	)
 
+	incidentMetrics = processor.NewIncidentMetrics()
+
 	componentHealthAlerts = prom.NewMetricSet(
-		"component_health_alert",
+		health.ComponentHealthAlertMetric,
 		"Health status of a component based on alerts",
 	)
 
 	componentHealthObjects = prom.NewMetricSet(
-		"component_health_object",
+		health.ComponentHealthObjectMetric,
 		"Health status of a component based on Kubernetes objects",
 	)
 	componentsHealth = prom.NewMetricSet(
-		"component_health",
+		health.ComponentHealthMetric,
 		"Health status of a component based on the child objects",
 	)
 )
@@ -63,6 +76,10 @@ type Server interface {
 	// Handle registers a handler for the given pattern, similar to http.Handle.
 	Handle(pattern string, handler http.Handler)
 
+	// HandlePrefix registers a handler for all the paths with the given prefix.
+	// The prefix must end with a slash.
+	HandlePrefix(prefix string, handler http.Handler)
+
 	// Start starts the server and blocks until the server is stopped.
 	Start(ctx context.Context) error
 }
@@ -72,9 +89,14 @@ type Server interface {
 func StartServer(interval time.Duration, server Server, options common.Options) {
 	slog.Info("Starting server")
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	// stateSaver saves the incidents state on shutdown.
+	var stateSaver interface {
+		SaveState(ctx context.Context) error
+	}
+
 	if options.EnableComponentsHealth {
 		componentsPath := defaultComponentsConfigPath
 		if options.ComponentsPath != "" {
@@ -91,18 +113,90 @@ func StartServer(interval time.Duration, server Server, options common.Options)
 			slog.Info("Failed to create component processor, terminating", "err", err)
 			return
 		}
+		common.NewFileWatcher(componentsPath, interval, func(data []byte) error {
+			conf, err := health.ParseConfig(data)
+			if err != nil {
+				return err
+			}
+			componentsProc.UpdateConfig(conf)
+			slog.Info("Successfully reloaded components definition from ", "path", componentsPath)
+			return nil
+		}).Start(ctx)
 		componentsProc.Start(ctx)
 	} else {
 		slog.Info("Components health evaluation is disabled")
 	}
 
 	if !options.DisableIncidents {
+		if options.MappingsPath != "" {
+			mappings, err := processor.LoadMappings(options.MappingsPath)
+			if err != nil {
+				slog.Error("Failed to load component mappings, terminating", "err", err)
+				return
+			}
+			processor.SetMappings(mappings)
+
+			common.NewFileWatcher(options.MappingsPath, interval, func(data []byte) error {
+				mappings, err := processor.ParseMappings(data)
+				if err != nil {
+					return err
+				}
+				processor.SetMappings(mappings)
+				slog.Info("Successfully reloaded component mappings from ", "path", options.MappingsPath)
+				return nil
+			}).Start(ctx)
+		}
+
+		groupingConfig, err := processor.BuildGroupingConfig(options.GroupingOptions)
+		if err != nil {
+			slog.Error("Failed to load grouping config, terminating", "err", err)
+			return
+		}
+
+		// The node roles are used for mapping the signal to the nodes and the
+		// installed resources for exporting only the relevant components. They are
+		// optional: without them, the generic compute layer and all the components are used.
+		var signalSources []processor.SignalSource
+		if kubeClient, dynamicClient, err := newKubeClients(options); err != nil {
+			slog.Warn("Failed to create kube clients, node roles and installed components won't be available",
+				"err", err)
+			if options.EnableEvents {
+				slog.Error("Kube clients are required for the events signal, terminating")
+				return
+			}
+		} else {
+			processor.NewNodesWatcher(kubeClient, discoveryInterval).Start(ctx)
+			processor.NewComponentsDiscovery(kubeClient, dynamicClient, discoveryInterval).Start(ctx)
+
+			if options.EnableEvents {
+				eventsInterval := options.EventsInterval
+				if eventsInterval <= 0 {
+					eventsInterval = interval
+				}
+				events := processor.NewEventsIngester(kubeClient, eventsInterval, options.EventsMinCount)
+				events.Start(ctx)
+				signalSources = append(signalSources, events)
+			}
+		}
+
+		stateStore, err := buildStateStore(options)
+		if err != nil {
+			slog.Error("Failed to create state store, terminating", "err", err)
+			return
+		}
+
 		processorCfg := processor.ProcessorConfig{
-			Interval:        interval,
-			PromURL:         options.PromURL,
-			AlertManagerURL: options.AlertManagerURL,
+			Interval:          interval,
+			PromURL:           options.PromURL,
+			AlertManagerURL:   options.AlertManagerURL,
+			Grouping:          groupingConfig,
+			StateStore:        stateStore,
+			StateSaveInterval: options.StateSaveInterval,
+			SignalSources:     signalSources,
+			ClusterLabel:      options.ClusterLabel,
 		}
-		processor, err := processor.NewProcessor(processorCfg, healthMapMetrics, componentsMetrics, groupSeverityCountMetrics)
+		processor, err := processor.NewProcessor(processorCfg, healthMapMetrics, componentsMetrics, groupSeverityCountMetrics,
+			incidentMetrics)
 		if err != nil {
 			slog.Error("Failed to create processor, terminating", "err", err)
 			return
@@ -118,6 +212,18 @@ func StartServer(interval time.Duration, server Server, options common.Options)
 		}
 
 		processor.Start(ctx)
+		stateSaver = processor
+
+		// The alerts pushed by Alertmanager are processed right away,
+		// the polling of Prometheus reconciles the missed ones.
+		server.Handle(alertmanagerWebhookPath, newAlertmanagerWebhookHandler(processor))
+
+		api, err := newAPIHandler(options, processor)
+		if err != nil {
+			slog.Error("Failed to create incidents API, terminating", "err", err)
+			return
+		}
+		registerAPI(server, api)
 	} else {
 		slog.Info("Incident detection is disabled")
 	}
@@ -126,6 +232,7 @@ func StartServer(interval time.Duration, server Server, options common.Options)
 	reg.MustRegister(healthMapMetrics)
 	reg.MustRegister(componentsMetrics)
 	reg.MustRegister(groupSeverityCountMetrics)
+	reg.MustRegister(incidentMetrics.Collectors()...)
 	reg.MustRegister(componentHealthAlerts)
 	reg.MustRegister(componentHealthObjects)
 	reg.MustRegister(componentsHealth)
@@ -139,20 +246,67 @@ func StartServer(interval time.Duration, server Server, options common.Options)
 	if err != nil {
 		slog.Error("Failed to run server", "err", err)
 	}
+
+	if stateSaver != nil {
+		slog.Info("Saving incidents state before shutdown")
+		saveCtx, saveCancel := context.WithTimeout(context.Background(), stateSaveTimeout)
+		defer saveCancel()
+		if err := stateSaver.SaveState(saveCtx); err != nil {
+			slog.Error("Failed to save incidents state", "err", err)
+		}
+	}
+}
+
+// buildStateStore creates the store for persisting the incidents state
+// based on the options. It returns nil when the state persistence is disabled.
+func buildStateStore(options common.Options) (processor.StateStore, error) {
+	switch options.StateStore {
+	case "":
+		return nil, nil
+	case "file":
+		if options.StateFile == "" {
+			return nil, fmt.Errorf("--state-file is required for the file state store")
+		}
+		return processor.NewFileStateStore(options.StateFile), nil
+	case string(processor.ConfigMapStateObject), string(processor.SecretStateObject):
+		client, _, err := newKubeClients(options)
+		if err != nil {
+			return nil, err
+		}
+		return processor.NewKubeStateStore(client, processor.StateObjectKind(options.StateStore),
+			options.StateNamespace, options.StateName)
+	default:
+		return nil, fmt.Errorf("unsupported state store %q", options.StateStore)
+	}
 }
 
 // loadConfig reads the file
-// and unmarshals the component config.
+// and unmarshals and validates the component config.
 func loadConfig(filePath string) (*health.ComponentsConfig, error) {
-	conf := &health.ComponentsConfig{}
 	cData, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
-	err = yaml.Unmarshal(cData, conf)
+	conf, err := health.ParseConfig(cData)
 	if err != nil {
 		return nil, err
 	}
 	slog.Info("Successfully loaded components definition from ", "path", filePath)
 	return conf, nil
 }
+
+func newKubeClients(options common.Options) (kubernetes.Interface, dynamic.Interface, error) {
+	restConfig, err := common.GetKubeConfig(options.Kubeconfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	kubeClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return kubeClient, dynamicClient, nil
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/openshift/cluster-health-analyzer/pkg/processor"
+)
+
+const (
+	// alertmanagerWebhookPath is the path of the Alertmanager webhook receiver.
+	alertmanagerWebhookPath = "/webhook/alertmanager"
+
+	// maxWebhookBodySize limits the size of the accepted webhook payload.
+	maxWebhookBodySize = 10 << 20
+)
+
+// webhookProcessor processes the alerts received via the Alertmanager webhook.
+type webhookProcessor interface {
+	ProcessWebhook(msg processor.WebhookMessage, t time.Time)
+}
+
+// newAlertmanagerWebhookHandler returns the handler accepting the standard
+// Alertmanager webhook payload.
+func newAlertmanagerWebhookHandler(p webhookProcessor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var msg processor.WebhookMessage
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWebhookBodySize)).Decode(&msg); err != nil {
+			slog.Warn("Failed to decode Alertmanager webhook payload", "err", err)
+			http.Error(w, "invalid payload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		p.ProcessWebhook(msg, time.Now())
+		w.WriteHeader(http.StatusOK)
+	})
+}